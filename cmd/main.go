@@ -2,12 +2,30 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"vr-shope/internal/app"
 )
 
+const configPath = "internal/config/config.yaml"
+
 func main() {
-	err := app.Run("internal/config/config.yaml")
+	var command string
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	var err error
+	switch command {
+	case "migrate":
+		err = app.Migrate(configPath, os.Args[2:])
+	case "admin":
+		err = app.Admin(configPath, os.Args[2:])
+	default:
+		err = app.Run(configPath)
+	}
+
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS orders (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    total FLOAT8 NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE purchases RENAME COLUMN date TO created_at;
+ALTER TABLE purchases
+    ADD COLUMN order_id UUID REFERENCES orders(id) ON DELETE CASCADE,
+    ADD COLUMN product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    ADD COLUMN quantity INT NOT NULL DEFAULT 1,
+    ADD COLUMN wallet_usdt FLOAT8 NOT NULL DEFAULT 0.0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE purchases
+    DROP COLUMN wallet_usdt,
+    DROP COLUMN quantity,
+    DROP COLUMN product_id,
+    DROP COLUMN order_id;
+ALTER TABLE purchases RENAME COLUMN created_at TO date;
+
+DROP TABLE IF EXISTS orders;
+-- +goose StatementEnd
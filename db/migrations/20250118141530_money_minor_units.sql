@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ALTER COLUMN wallet_usdt DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN wallet_usdt TYPE BIGINT USING ROUND(wallet_usdt * 100)::BIGINT;
+ALTER TABLE users ALTER COLUMN wallet_usdt SET DEFAULT 0;
+
+ALTER TABLE products ALTER COLUMN cost TYPE BIGINT USING ROUND(cost * 100)::BIGINT;
+
+ALTER TABLE orders ALTER COLUMN total TYPE BIGINT USING ROUND(total * 100)::BIGINT;
+
+ALTER TABLE purchases ALTER COLUMN cost TYPE BIGINT USING ROUND(cost * 100)::BIGINT;
+ALTER TABLE purchases ALTER COLUMN wallet_usdt DROP DEFAULT;
+ALTER TABLE purchases ALTER COLUMN wallet_usdt TYPE BIGINT USING ROUND(wallet_usdt * 100)::BIGINT;
+ALTER TABLE purchases ALTER COLUMN wallet_usdt SET DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE purchases ALTER COLUMN wallet_usdt DROP DEFAULT;
+ALTER TABLE purchases ALTER COLUMN wallet_usdt TYPE FLOAT8 USING wallet_usdt / 100.0;
+ALTER TABLE purchases ALTER COLUMN wallet_usdt SET DEFAULT 0.0;
+ALTER TABLE purchases ALTER COLUMN cost TYPE FLOAT8 USING cost / 100.0;
+
+ALTER TABLE orders ALTER COLUMN total TYPE FLOAT8 USING total / 100.0;
+
+ALTER TABLE products ALTER COLUMN cost TYPE FLOAT8 USING cost / 100.0;
+
+ALTER TABLE users ALTER COLUMN wallet_usdt DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN wallet_usdt TYPE FLOAT8 USING wallet_usdt / 100.0;
+ALTER TABLE users ALTER COLUMN wallet_usdt SET DEFAULT 0.0;
+-- +goose StatementEnd
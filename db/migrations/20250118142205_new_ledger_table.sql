@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL CHECK (kind IN ('deposit', 'purchase', 'refund', 'adjustment')),
+    reference_id UUID,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES ledger_transactions(id),
+    account VARCHAR(64) NOT NULL,
+    user_id UUID,
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_account_idx ON ledger_entries (account, id);
+CREATE INDEX IF NOT EXISTS ledger_entries_transaction_idx ON ledger_entries (transaction_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION ledger_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER ledger_transactions_append_only
+    BEFORE UPDATE OR DELETE ON ledger_transactions
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+WITH opening AS (
+    SELECT id AS user_id, wallet_usdt, gen_random_uuid() AS transaction_id
+    FROM users
+    WHERE wallet_usdt <> 0
+), transactions AS (
+    INSERT INTO ledger_transactions (id, kind, description)
+    SELECT transaction_id, 'adjustment', 'opening balance'
+    FROM opening
+)
+INSERT INTO ledger_entries (transaction_id, account, user_id, amount)
+SELECT transaction_id, 'user:' || user_id, user_id, wallet_usdt FROM opening
+UNION ALL
+SELECT transaction_id, 'equity:adjustments', NULL, -wallet_usdt FROM opening;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_transactions;
+DROP FUNCTION IF EXISTS ledger_append_only();
+-- +goose StatementEnd
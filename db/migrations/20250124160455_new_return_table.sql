@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE purchases
+    ADD COLUMN returned_quantity INT NOT NULL DEFAULT 0,
+    ADD COLUMN refunded_amount BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS return_requests (
+    id UUID PRIMARY KEY,
+    purchase_id UUID NOT NULL REFERENCES purchases(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    reason TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('requested', 'approved', 'rejected')),
+    refund_amount BIGINT NOT NULL DEFAULT 0,
+    restocked BOOLEAN NOT NULL DEFAULT FALSE,
+    resolved_by UUID,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS return_requests_user_idx ON return_requests (user_id, created_at);
+CREATE INDEX IF NOT EXISTS return_requests_purchase_idx ON return_requests (purchase_id);
+
+CREATE TABLE IF NOT EXISTS return_events (
+    id BIGSERIAL PRIMARY KEY,
+    return_id UUID NOT NULL REFERENCES return_requests(id) ON DELETE CASCADE,
+    actor_id UUID,
+    action VARCHAR(16) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS return_events_return_idx ON return_events (return_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS return_events;
+DROP TABLE IF EXISTS return_requests;
+
+ALTER TABLE purchases
+    DROP COLUMN refunded_amount,
+    DROP COLUMN returned_quantity;
+-- +goose StatementEnd
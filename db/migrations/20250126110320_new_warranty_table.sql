@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products
+    ADD COLUMN warranty_days INT NOT NULL DEFAULT 0 CHECK (warranty_days >= 0);
+
+-- guarantees held an absolute expiry date; keep whatever coverage is left as the period
+UPDATE products
+SET warranty_days = GREATEST(guarantees::date - CURRENT_DATE, 0)
+WHERE guarantees IS NOT NULL;
+
+ALTER TABLE products DROP COLUMN guarantees;
+
+ALTER TABLE purchases ADD COLUMN warranty_until TIMESTAMP;
+
+UPDATE purchases p
+SET warranty_until = p.created_at + pr.warranty_days * INTERVAL '1 day'
+FROM products pr
+WHERE pr.id = p.product_id AND pr.warranty_days > 0;
+
+CREATE TABLE IF NOT EXISTS warranty_claims (
+    id UUID PRIMARY KEY,
+    purchase_id UUID NOT NULL REFERENCES purchases(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL
+        CHECK (status IN ('submitted', 'in_review', 'approved', 'rejected', 'resolved')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS warranty_claims_user_idx ON warranty_claims (user_id, created_at);
+CREATE INDEX IF NOT EXISTS warranty_claims_purchase_idx ON warranty_claims (purchase_id);
+
+CREATE TABLE IF NOT EXISTS warranty_claim_history (
+    id BIGSERIAL PRIMARY KEY,
+    claim_id UUID NOT NULL REFERENCES warranty_claims(id) ON DELETE CASCADE,
+    from_status VARCHAR(16),
+    to_status VARCHAR(16) NOT NULL,
+    actor_id UUID,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS warranty_claim_history_claim_idx ON warranty_claim_history (claim_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS warranty_claim_history;
+DROP TABLE IF EXISTS warranty_claims;
+
+ALTER TABLE purchases DROP COLUMN warranty_until;
+
+ALTER TABLE products ADD COLUMN guarantees TIMESTAMP;
+
+UPDATE products
+SET guarantees = NOW() + warranty_days * INTERVAL '1 day'
+WHERE warranty_days > 0;
+
+ALTER TABLE products DROP COLUMN warranty_days;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS legacy_ids (
+    legacy_id BIGINT PRIMARY KEY,
+    entity VARCHAR(32) NOT NULL,
+    id UUID NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS legacy_id_backfills (
+    entity VARCHAR(32) PRIMARY KEY,
+    completed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS legacy_id_backfills;
+DROP TABLE IF EXISTS legacy_ids;
+-- +goose StatementEnd
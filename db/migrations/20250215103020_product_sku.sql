@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+-- NULLs don't collide, products without a SKU stay allowed
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku_key ON products (sku);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS products_sku_key;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- staff member who confirmed or failed the deposit by hand, NULL when the
+-- payment provider settled it
+ALTER TABLE deposits ADD COLUMN IF NOT EXISTS settled_by UUID REFERENCES users(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deposits DROP COLUMN IF EXISTS settled_by;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE products ADD COLUMN sku VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku_key ON products (sku);
+
+-- +goose Down
+DROP INDEX IF EXISTS products_sku_key;
+ALTER TABLE products DROP COLUMN sku;
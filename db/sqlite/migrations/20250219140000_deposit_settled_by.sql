@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE deposits ADD COLUMN settled_by TEXT REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE deposits DROP COLUMN settled_by;
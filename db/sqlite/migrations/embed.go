@@ -0,0 +1,8 @@
+// Package migrations embeds the SQLite schema migrations, so the binary can
+// apply them from any working directory.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,565 @@
+// Package admin implements the administration commands of the vr-shope
+// binary. They call the service layer directly instead of going through HTTP.
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"vr-shope/internal/catalog"
+	"vr-shope/internal/models"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+const usage = `usage: admin [-output table|json] COMMAND [flags]
+
+commands:
+  create-staff     create a manager or admin account
+  reset-password   replace the password of a user and end their sessions
+  adjust-wallet    credit or debit a wallet with a reason
+  update-stock     set or change the stock of several products at once
+  import-products  create or update products from a CSV or NDJSON file
+  export-products  write the whole catalog as CSV or NDJSON
+  purchases        list recent purchases
+
+run "admin COMMAND -h" for the flags of a command`
+
+type UserService interface {
+	CreateStaff(ctx context.Context, user *models.User, role string) error
+}
+
+type PasswordService interface {
+	SetPassword(ctx context.Context, userID uuid.UUID, password string) error
+}
+
+type WalletService interface {
+	Adjust(ctx context.Context, userID uuid.UUID, amount money.Amount, reason string) (*models.WalletBalance, error)
+}
+
+type ProductService interface {
+	UpdateStock(ctx context.Context, updates []models.StockUpdate) ([]*models.Product, error)
+	ImportProducts(ctx context.Context, rows []models.ProductImportRow, opts models.ImportOptions) (*models.ImportReport, error)
+	ExportProducts(ctx context.Context, fn func(product *models.Product) error) error
+}
+
+type PurchaseService interface {
+	GetRecent(ctx context.Context, limit int) ([]*models.Purchase, error)
+}
+
+type Services struct {
+	Users     UserService
+	Passwords PasswordService
+	Wallets   WalletService
+	Products  ProductService
+	Purchases PurchaseService
+}
+
+// Connect opens the services once a command has been parsed, so usage errors
+// show up without a database.
+type Connect func(ctx context.Context) (*Services, error)
+
+type CLI struct {
+	connect Connect
+	in      io.Reader
+	out     io.Writer
+	json    bool
+}
+
+func New(connect Connect, in io.Reader, out io.Writer) *CLI {
+	return &CLI{
+		connect: connect,
+		in:      in,
+		out:     out,
+	}
+}
+
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s\n\nflags:\n", usage)
+		fs.PrintDefaults()
+	}
+	output := fs.String("output", "table", "output format, table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch *output {
+	case "table":
+	case "json":
+		c.json = true
+	default:
+		return fmt.Errorf("unknown output format: %s", *output)
+	}
+
+	if fs.NArg() == 0 {
+		return errors.New(usage)
+	}
+
+	command, args := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "create-staff":
+		return c.createStaff(ctx, args)
+	case "reset-password":
+		return c.resetPassword(ctx, args)
+	case "adjust-wallet":
+		return c.adjustWallet(ctx, args)
+	case "update-stock":
+		return c.updateStock(ctx, args)
+	case "import-products":
+		return c.importProducts(ctx, args)
+	case "export-products":
+		return c.exportProducts(ctx, args)
+	case "purchases":
+		return c.listPurchases(ctx, args)
+	default:
+		return fmt.Errorf("unknown admin command: %s\n%s", command, usage)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: admin %s [flags]\n\nflags:\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// readPassword takes the password from the flag, or from the first line of
+// the input so it stays out of the shell history.
+func (c *CLI) readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	line, err := bufio.NewReader(c.in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", models.ErrInvalidPassword
+	}
+
+	return password, nil
+}
+
+func (c *CLI) createStaff(ctx context.Context, args []string) error {
+	fs := newFlagSet("create-staff")
+	var user models.User
+	fs.StringVar(&user.Login, "login", "", "login of the new account")
+	fs.StringVar(&user.Email, "email", "", "email address, it is marked verified")
+	fs.StringVar(&user.Name, "name", "", "first name")
+	fs.StringVar(&user.LastName, "last-name", "", "last name")
+	fs.StringVar(&user.PhoneNumber, "phone", "", "phone number")
+	password := fs.String("password", "", "password, read from stdin when empty")
+	role := fs.String("role", models.RoleManager, "manager or admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	user.Password, err = c.readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	svc, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.Users.CreateStaff(ctx, &user, *role); err != nil {
+		return err
+	}
+
+	// the user model carries the password hash, keep it out of the output
+	result := struct {
+		ID    uuid.UUID `json:"id"`
+		Login string    `json:"login"`
+		Email string    `json:"email"`
+		Role  string    `json:"role"`
+	}{user.ID, user.Login, user.Email, user.Role}
+
+	return c.print(result, []string{"ID", "LOGIN", "EMAIL", "ROLE"}, [][]string{
+		{result.ID.String(), result.Login, result.Email, result.Role},
+	})
+}
+
+func (c *CLI) resetPassword(ctx context.Context, args []string) error {
+	fs := newFlagSet("reset-password")
+	userID := fs.String("user", "", "id of the user")
+	password := fs.String("password", "", "new password, read from stdin when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(*userID)
+	if err != nil {
+		return fmt.Errorf("invalid user id: %q", *userID)
+	}
+
+	newPassword, err := c.readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	svc, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.Passwords.SetPassword(ctx, id, newPassword); err != nil {
+		return err
+	}
+
+	result := struct {
+		UserID  uuid.UUID `json:"user_id"`
+		Message string    `json:"message"`
+	}{id, "password replaced, all sessions ended"}
+
+	return c.print(result, []string{"USER", "MESSAGE"}, [][]string{
+		{result.UserID.String(), result.Message},
+	})
+}
+
+func (c *CLI) adjustWallet(ctx context.Context, args []string) error {
+	fs := newFlagSet("adjust-wallet")
+	userID := fs.String("user", "", "id of the user")
+	amount := fs.String("amount", "", "amount to credit, negative to debit")
+	reason := fs.String("reason", "", "why the wallet is adjusted, stored in the ledger")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(*userID)
+	if err != nil {
+		return fmt.Errorf("invalid user id: %q", *userID)
+	}
+
+	value, err := money.Parse(*amount)
+	if err != nil {
+		return err
+	}
+
+	svc, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	balance, err := svc.Wallets.Adjust(ctx, id, value, *reason)
+	if err != nil {
+		return err
+	}
+
+	return c.print(balance, []string{"USER", "BALANCE", "LEDGER BALANCE", "RECONCILED"}, [][]string{
+		{balance.UserID.String(), balance.Balance.String(), balance.LedgerBalance.String(), strconv.FormatBool(balance.Reconciled)},
+	})
+}
+
+func (c *CLI) updateStock(ctx context.Context, args []string) error {
+	fs := newFlagSet("update-stock")
+	file := fs.String("file", "", `read updates from a file, one per line, "-" for stdin`)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: update-stock [-file FILE] [PRODUCT_ID=QUANTITY|PRODUCT_ID=+DELTA|PRODUCT_ID=-DELTA ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	lines := fs.Args()
+	if *file != "" {
+		fileLines, err := c.readLines(*file)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fileLines...)
+	}
+	if len(lines) == 0 {
+		return errors.New("no stock updates given")
+	}
+
+	updates := make([]models.StockUpdate, 0, len(lines))
+	for _, line := range lines {
+		update, err := parseStockUpdate(line)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, update)
+	}
+
+	svc, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	products, err := svc.Products.UpdateStock(ctx, updates)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(products))
+	for _, product := range products {
+		rows = append(rows, []string{product.ID.String(), product.Name, strconv.Itoa(product.QuantityStock)})
+	}
+
+	return c.print(products, []string{"ID", "NAME", "STOCK"}, rows)
+}
+
+func (c *CLI) readLines(path string) ([]string, error) {
+	r := c.in
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// parseStockUpdate reads ID=N to set the stock, or ID=+N and ID=-N to change
+// it. A comma works in place of the equals sign, so CSV exports can be piped
+// in as they are.
+func parseStockUpdate(s string) (models.StockUpdate, error) {
+	id, quantity, ok := strings.Cut(s, "=")
+	if !ok {
+		id, quantity, ok = strings.Cut(s, ",")
+	}
+	if !ok {
+		return models.StockUpdate{}, fmt.Errorf("invalid stock update %q, want PRODUCT_ID=QUANTITY", s)
+	}
+
+	productID, err := uuid.Parse(strings.TrimSpace(id))
+	if err != nil {
+		return models.StockUpdate{}, fmt.Errorf("invalid product id in %q", s)
+	}
+
+	quantity = strings.TrimSpace(quantity)
+	n, err := strconv.Atoi(quantity)
+	if err != nil {
+		return models.StockUpdate{}, fmt.Errorf("invalid quantity in %q", s)
+	}
+
+	return models.StockUpdate{
+		ProductID: productID,
+		Quantity:  n,
+		Relative:  strings.HasPrefix(quantity, "+") || strings.HasPrefix(quantity, "-"),
+	}, nil
+}
+
+// open returns the input for path, "-" being stdin. The caller closes it.
+func (c *CLI) open(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(c.in), nil
+	}
+
+	return os.Open(path)
+}
+
+func (c *CLI) importProducts(ctx context.Context, args []string) error {
+	fs := newFlagSet("import-products")
+	format := fs.String("format", "", "csv or ndjson, taken from the file extension when empty")
+	var opts models.ImportOptions
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "validate the file and report what would change without writing")
+	fs.BoolVar(&opts.Upsert, "upsert", false, "update products whose SKU is already in the catalog")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: import-products [-format csv|ndjson] [-dry-run] [-upsert] FILE|-")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("import-products takes exactly one file")
+	}
+
+	path := fs.Arg(0)
+	fileFormat := catalog.FormatForPath(path)
+	if *format != "" {
+		var err error
+		if fileFormat, err = catalog.ParseFormat(*format); err != nil {
+			return err
+		}
+	}
+
+	f, err := c.open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rows, err := catalog.Decode(f, fileFormat)
+	if err != nil {
+		return fmt.Errorf("invalid catalog file: %w", err)
+	}
+
+	svc, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	report, err := svc.Products.ImportProducts(ctx, rows, opts)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		if err := c.print(report, nil, nil); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(c.out, "rows: %d, created: %d, updated: %d, dry run: %t\n", report.Rows, report.Created, report.Updated, report.DryRun)
+		if len(report.Errors) > 0 {
+			rows := make([][]string, 0, len(report.Errors))
+			for _, rowErr := range report.Errors {
+				rows = append(rows, []string{strconv.Itoa(rowErr.Line), rowErr.SKU, rowErr.Error})
+			}
+			if err := c.print(report, []string{"LINE", "SKU", "ERROR"}, rows); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d of %d rows are invalid, nothing was imported", len(report.Errors), report.Rows)
+	}
+
+	return nil
+}
+
+func (c *CLI) exportProducts(ctx context.Context, args []string) error {
+	fs := newFlagSet("export-products")
+	format := fs.String("format", "", "csv or ndjson, taken from the file extension when empty")
+	file := fs.String("file", "", "write to a file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fileFormat := catalog.FormatForPath(*file)
+	if *format != "" {
+		var err error
+		if fileFormat, err = catalog.ParseFormat(*format); err != nil {
+			return err
+		}
+	}
+
+	svc, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := c.out
+	var f *os.File
+	if *file != "" {
+		if f, err = os.Create(*file); err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	encoder := catalog.NewEncoder(w, fileFormat)
+	if err := svc.Products.ExportProducts(ctx, encoder.Encode); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if f != nil {
+		return f.Close()
+	}
+
+	return nil
+}
+
+func (c *CLI) listPurchases(ctx context.Context, args []string) error {
+	fs := newFlagSet("purchases")
+	limit := fs.Int("limit", 20, "number of purchases to list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	svc, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	purchases, err := svc.Purchases.GetRecent(ctx, *limit)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(purchases))
+	for _, purchase := range purchases {
+		rows = append(rows, []string{
+			purchase.ID.String(),
+			purchase.Date.Local().Format(time.DateTime),
+			purchase.UserID.String(),
+			purchase.ProductID.String(),
+			strconv.Itoa(purchase.Quantity),
+			purchase.Cost.String(),
+		})
+	}
+
+	if purchases == nil {
+		purchases = []*models.Purchase{}
+	}
+
+	return c.print(purchases, []string{"ID", "DATE", "USER", "PRODUCT", "QUANTITY", "COST"}, rows)
+}
+
+// print writes v as indented JSON, or header and rows as an aligned table.
+func (c *CLI) print(v any, header []string, rows [][]string) error {
+	if c.json {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"vr-shope/internal/admin"
+	"vr-shope/internal/config"
+)
+
+// Admin runs an administration command against the configured database. Logs
+// go to stderr so they don't mix with the command output.
+func Admin(configPath string, args []string) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	logger := newLogger(cfg, os.Stderr)
+
+	var db *sql.DB
+	defer func() {
+		if db != nil {
+			db.Close()
+		}
+	}()
+
+	connect := func(ctx context.Context) (*admin.Services, error) {
+		var store *storage
+		var err error
+		db, store, err = openStorage(ctx, &cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+
+		svc, err := newServices(cfg, store, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		return &admin.Services{
+			Users:     svc.users,
+			Passwords: svc.passwordResets,
+			Wallets:   svc.wallets,
+			Products:  svc.products,
+			Purchases: svc.purchases,
+		}, nil
+	}
+
+	err = admin.New(connect, os.Stdin, os.Stdout).Run(context.Background(), args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	return err
+}
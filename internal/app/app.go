@@ -1,111 +1,194 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"vr-shope/internal/config"
+	"vr-shope/internal/handler/apikey"
+	"vr-shope/internal/handler/cart"
+	"vr-shope/internal/handler/category"
+	"vr-shope/internal/handler/deposit"
+	"vr-shope/internal/handler/keys"
+	"vr-shope/internal/handler/lockout"
+	"vr-shope/internal/handler/order"
+	"vr-shope/internal/handler/password"
 	"vr-shope/internal/handler/product"
 	"vr-shope/internal/handler/purchase"
+	"vr-shope/internal/handler/returns"
+	"vr-shope/internal/handler/twofactor"
 	"vr-shope/internal/handler/user"
+	"vr-shope/internal/handler/verification"
+	"vr-shope/internal/handler/wallet"
+	"vr-shope/internal/handler/warranty"
 	"vr-shope/internal/middleware"
-	"vr-shope/internal/repository"
-	"vr-shope/internal/service"
-	"vr-shope/internal/storage/postgresql"
+	"vr-shope/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func Run(configPath string) error {
-	serverCfg, dbCfg, loggerCfg, err := config.LoadConfig(configPath)
+	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	var level slog.Level
-	switch loggerCfg.LogLevel {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelDebug
-	}
-
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: level,
-	})
-	logger := slog.New(handler)
+	logger := newLogger(cfg, os.Stdout)
 
-	db, err := postgresql.OpenConnection(dbCfg)
+	db, store, err := openStorage(context.Background(), &cfg.Database)
 	if err != nil {
 		logger.Error("Error creating database connection", slog.Any("error", err))
-		return fmt.Errorf("failed to create database connection: %w", err)
+		return err
 	}
 	defer db.Close()
 
-	userStorage, err := repository.NewUserStorage(db)
-	if err != nil {
-		logger.Error("Error creating user storage", slog.Any("error", err))
-		return fmt.Errorf("failed to create user storage: %w", err)
-	}
-
-	userService := service.NewUserService(userStorage)
-	userHandler := user.NewHandler(userService, logger)
-
-	productStorage, err := repository.NewProductStorage(db)
+	svc, err := newServices(cfg, store, logger)
 	if err != nil {
-		logger.Error("Error creating track storage", slog.Any("error", err))
-		return fmt.Errorf("failed to create track storage: %w", err)
+		logger.Error("Error creating services", slog.Any("error", err))
+		return err
 	}
 
-	productService := service.NewProductService(productStorage)
-	productHandler := product.NewHandler(productService, logger)
-
-	purchaseStorage, err := repository.NewPurchaseStorage(db)
-	if err != nil {
-		logger.Error("Error creating playlist storage", slog.Any("error", err))
-		return fmt.Errorf("failed to create playlist storage: %w", err)
+	if err := svc.legacyIDs.Backfill(context.Background()); err != nil {
+		logger.Error("Error backfilling legacy ids", slog.Any("error", err))
+		return fmt.Errorf("failed to backfill legacy ids: %w", err)
 	}
 
-	purchaseService := service.NewPurchaseService(purchaseStorage)
-	purchaseHandler := purchase.NewHandler(purchaseService, logger)
+	keysHandler := keys.NewHandler(svc.issuer, logger)
+	apiKeyHandler := apikey.NewHandler(svc.apiKeys, logger)
+	verificationHandler := verification.NewHandler(svc.verification, logger)
+	lockoutHandler := lockout.NewHandler(svc.lockout, logger)
+	twoFactorHandler := twofactor.NewHandler(svc.twoFactor, logger)
+	userHandler := user.NewHandler(svc.users, logger)
+	passwordHandler := password.NewHandler(svc.passwordResets, logger)
+	productHandler := product.NewHandler(svc.products, logger)
+	categoryHandler := category.NewHandler(svc.categories, logger)
+	purchaseHandler := purchase.NewHandler(svc.purchases, logger)
+	orderHandler := order.NewHandler(svc.orders, logger)
+	cartHandler := cart.NewHandler(svc.carts, logger)
+	walletHandler := wallet.NewHandler(svc.wallets, logger)
+	depositHandler := deposit.NewHandler(svc.deposits, logger)
+	returnHandler := returns.NewHandler(svc.returns, logger)
+	warrantyHandler := warranty.NewHandler(svc.warranties, logger)
 
 	router := gin.Default()
+	// c.ClientIP() keys the per-IP login throttle, so X-Forwarded-For only
+	// counts when it comes from a configured proxy
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Error("Error setting trusted proxies", slog.Any("error", err))
+		return fmt.Errorf("invalid server.trusted_proxies: %w", err)
+	}
 
 	router.POST("/users/create", userHandler.CreateUser())
-	router.POST("/product/create", productHandler.CreateProduct())
-	router.POST("/purchase/create", purchaseHandler.CreatePurchase())
+	auth := middleware.AuthMiddleware(svc.issuer, svc.tokens)
+	staff := middleware.RequireRole(models.RoleManager, models.RoleAdmin)
+	admin := middleware.RequireRole(models.RoleAdmin)
+	legacyIDs := middleware.LegacyIDs(svc.legacyIDs)
+	scoped := func(scope string) gin.HandlerFunc {
+		return middleware.AuthOrAPIKey(svc.issuer, svc.tokens, svc.apiKeys, scope)
+	}
+
+	router.POST("/product/create", scoped(models.ScopeProductsWrite), staff, productHandler.CreateProduct())
+	router.POST("/purchase/create", scoped(models.ScopePurchasesWrite), purchaseHandler.CreatePurchase())
+	router.GET("/.well-known/jwks.json", keysHandler.GetJWKS())
 	router.POST("/users/login", userHandler.Login())
+	router.POST("/users/login/2fa", twoFactorHandler.Login())
+	router.POST("/users/refresh", userHandler.Refresh())
+	router.POST("/users/logout", auth, userHandler.Logout())
+	router.POST("/users/password/forgot", passwordHandler.ForgotPassword())
+	router.POST("/users/password/reset", passwordHandler.ResetPassword())
+	router.GET("/users/verify", verificationHandler.VerifyEmail())
+	router.POST("/users/verify/resend", verificationHandler.ResendVerification())
 
 	Routes := router.Group("/api/v1")
-	Routes.Use(middleware.AuthMiddleware())
+	Routes.Use(auth, legacyIDs)
 	{
-		Routes.GET("/users", userHandler.GetAllUsers())
+		Routes.GET("/users", staff, userHandler.GetAllUsers())
 		Routes.GET("/users/:id", userHandler.GetUserByID())
-		Routes.GET("/users&email=<user_email>", userHandler.GetUserByEmail())
-		Routes.GET("/users?offset=1&limit=10", userHandler.GetUserWithPagination())
+		Routes.GET("/users&email=<user_email>", staff, userHandler.GetUserByEmail())
+		Routes.GET("/users?offset=1&limit=10", staff, userHandler.GetUserWithPagination())
+		Routes.PUT("/users/:id/role", admin, userHandler.SetUserRole())
 		Routes.PUT("/users/:id", userHandler.UpdateUser())
 		Routes.DELETE("/users/:id", userHandler.DeleteUser())
 
-		Routes.GET("/product", productHandler.GetAllProducts())
-		Routes.GET("/product/:id", productHandler.GetProductByID())
-		Routes.GET("/product?name=<product_name>", productHandler.GetProductByName())
-		Routes.GET("/product?offset=1&limit=10", productHandler.GetProductsWithPagination())
-		Routes.PUT("/product/:id", productHandler.UpdateProduct())
-		Routes.DELETE("/product/:id", productHandler.DeleteProduct())
-
-		Routes.GET("/playlists", purchaseHandler.GetAllPurchases())
-		Routes.GET("/playlists/:id", purchaseHandler.GetPurchaseByID())
-		Routes.PUT("/playlists/:id", purchaseHandler.UpdatePurchase())
-		Routes.DELETE("/playlists/:id", purchaseHandler.DeletePurchase())
+		Routes.POST("/2fa/enroll", twoFactorHandler.Enroll())
+		Routes.POST("/2fa/confirm", twoFactorHandler.Confirm())
+		Routes.POST("/2fa/disable", twoFactorHandler.Disable())
+		Routes.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes())
+
+		Routes.POST("/api-keys", apiKeyHandler.CreateKey())
+		Routes.GET("/api-keys", apiKeyHandler.GetKeys())
+		Routes.DELETE("/api-keys/:id", apiKeyHandler.RevokeKey())
+
+		Routes.GET("/cart", cartHandler.GetCart())
+		Routes.POST("/cart/items", cartHandler.AddItem())
+		Routes.PUT("/cart/items/:id", cartHandler.UpdateItem())
+		Routes.DELETE("/cart/items/:id", cartHandler.DeleteItem())
+		Routes.POST("/cart/checkout", cartHandler.Checkout())
+
+		Routes.GET("/wallet", walletHandler.GetBalance())
+		Routes.GET("/wallet/transactions", walletHandler.GetTransactions())
+		Routes.POST("/wallet/deposits", depositHandler.CreateDeposit())
+		Routes.GET("/wallet/deposits", depositHandler.GetDeposits())
+		Routes.GET("/wallet/deposits/:id", depositHandler.GetDepositByID())
+
+		Routes.POST("/returns", returnHandler.CreateReturn())
+		Routes.GET("/returns", returnHandler.GetReturns())
+		Routes.GET("/returns/:id", returnHandler.GetReturnByID())
+
+		Routes.GET("/warranty/purchases/:id", warrantyHandler.GetCoverage())
+		Routes.POST("/warranty/claims", warrantyHandler.CreateClaim())
+		Routes.GET("/warranty/claims", warrantyHandler.GetClaims())
+		Routes.GET("/warranty/claims/:id", warrantyHandler.GetClaimByID())
+
+	}
+
+	// routes machine clients may call with a personal API key of the given scope
+	Scoped := router.Group("/api/v1")
+	{
+		Scoped.GET("/product", scoped(models.ScopeProductsRead), productHandler.GetAllProducts())
+		Scoped.GET("/product/export", scoped(models.ScopeProductsRead), productHandler.ExportProducts())
+		Scoped.POST("/product/import", scoped(models.ScopeProductsWrite), staff, productHandler.ImportProducts())
+		Scoped.GET("/product/:id", scoped(models.ScopeProductsRead), legacyIDs, productHandler.GetProductByID())
+		Scoped.GET("/product?name=<product_name>", scoped(models.ScopeProductsRead), productHandler.GetProductByName())
+		Scoped.GET("/product?offset=1&limit=10", scoped(models.ScopeProductsRead), productHandler.GetProductsWithPagination())
+		Scoped.PUT("/product/:id", scoped(models.ScopeProductsWrite), legacyIDs, staff, productHandler.UpdateProduct())
+		Scoped.DELETE("/product/:id", scoped(models.ScopeProductsWrite), legacyIDs, staff, productHandler.DeleteProduct())
+		Scoped.GET("/product/:id/categories", scoped(models.ScopeProductsRead), legacyIDs, categoryHandler.GetProductCategories())
+		Scoped.PUT("/product/:id/categories", scoped(models.ScopeProductsWrite), legacyIDs, staff, categoryHandler.SetProductCategories())
+
+		Scoped.GET("/categories", scoped(models.ScopeProductsRead), categoryHandler.GetCategories())
+		Scoped.GET("/categories/:id", scoped(models.ScopeProductsRead), categoryHandler.GetCategory())
+		Scoped.GET("/categories/:id/products", scoped(models.ScopeProductsRead), categoryHandler.GetCategoryProducts())
+		Scoped.POST("/categories", scoped(models.ScopeProductsWrite), staff, categoryHandler.CreateCategory())
+		Scoped.PUT("/categories/:id", scoped(models.ScopeProductsWrite), staff, categoryHandler.UpdateCategory())
+		Scoped.DELETE("/categories/:id", scoped(models.ScopeProductsWrite), staff, categoryHandler.DeleteCategory())
+
+		Scoped.GET("/playlists", scoped(models.ScopePurchasesRead), staff, purchaseHandler.GetAllPurchases())
+		Scoped.GET("/playlists/:id", scoped(models.ScopePurchasesRead), legacyIDs, purchaseHandler.GetPurchaseByID())
+
+		Scoped.GET("/orders", scoped(models.ScopeOrdersRead), orderHandler.GetOrders())
+		Scoped.GET("/orders/:id", scoped(models.ScopeOrdersRead), legacyIDs, orderHandler.GetOrderByID())
+		Scoped.POST("/orders/:id/cancel", scoped(models.ScopeOrdersWrite), legacyIDs, orderHandler.CancelOrder())
+		Scoped.POST("/orders/:id/status", scoped(models.ScopeOrdersWrite), legacyIDs, staff, orderHandler.TransitionOrder())
+	}
+
+	Admin := Routes.Group("/admin")
+	Admin.Use(staff)
+	{
+		Admin.GET("/returns", returnHandler.GetReturnQueue())
+		Admin.POST("/returns/:id/approve", returnHandler.ApproveReturn())
+		Admin.POST("/returns/:id/reject", returnHandler.RejectReturn())
+		Admin.POST("/deposits/:id/confirm", depositHandler.ConfirmDeposit())
+		Admin.POST("/deposits/:id/fail", depositHandler.FailDeposit())
+		Admin.GET("/warranty/claims", warrantyHandler.GetClaimQueue())
+		Admin.POST("/warranty/claims/:id/status", warrantyHandler.TransitionClaim())
+		Admin.GET("/lockouts", admin, lockoutHandler.GetLockouts())
+		Admin.POST("/users/:id/unlock", admin, lockoutHandler.UnlockUser())
+		Admin.POST("/lockouts/ips/:ip/unlock", admin, lockoutHandler.UnlockIP())
 	}
 
-	if err = router.Run(fmt.Sprintf(":%s", serverCfg.Port)); err != nil {
+	if err = router.Run(fmt.Sprintf(":%s", cfg.Server.Port)); err != nil {
 		return fmt.Errorf("Failed to start server: %w", err)
 	}
 
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/config"
+	"vr-shope/internal/migrate"
+)
+
+const migrateUsage = "usage: migrate up|down|status|redo|create NAME"
+
+// Migrate runs a migration command against the configured database. create
+// only writes a new file to the repository and needs no connection.
+func Migrate(configPath string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	b, err := backendFor(cfg.Database.Driver)
+	if err != nil {
+		return err
+	}
+
+	command, args := args[0], args[1:]
+
+	var db *sql.DB
+	switch command {
+	case "create":
+	case "up", "down", "status", "redo":
+		db, err = b.open(&cfg.Database)
+		if err != nil {
+			return fmt.Errorf("failed to create database connection: %w", err)
+		}
+		defer db.Close()
+	default:
+		return fmt.Errorf("unknown migrate command: %s\n%s", command, migrateUsage)
+	}
+
+	return migrate.Run(context.Background(), db, b.migrations, command, args...)
+}
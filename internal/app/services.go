@@ -0,0 +1,136 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"vr-shope/internal/config"
+	"vr-shope/internal/issuer"
+	"vr-shope/internal/mailer"
+	"vr-shope/internal/payment"
+	"vr-shope/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// services is the service layer shared by the HTTP server and the admin CLI.
+type services struct {
+	issuer         *issuer.Issuer
+	apiKeys        *service.APIKeyService
+	tokens         *service.TokenService
+	verification   *service.EmailVerificationService
+	lockout        *service.LockoutService
+	twoFactor      *service.TwoFactorService
+	users          *service.UserService
+	passwordResets *service.PasswordResetService
+	products       *service.ProductService
+	categories     *service.CategoryService
+	purchases      *service.PurchaseService
+	orders         *service.OrderService
+	carts          *service.CartService
+	wallets        *service.WalletService
+	deposits       *service.DepositService
+	returns        *service.ReturnService
+	warranties     *service.WarrantyService
+	legacyIDs      *service.LegacyIDService
+}
+
+func newLogger(cfg *config.Config, w io.Writer) *slog.Logger {
+	var level slog.Level
+	switch cfg.Logger.LogLevel {
+	case "debug":
+		level = slog.LevelDebug
+	case "info":
+		level = slog.LevelInfo
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		level = slog.LevelDebug
+	}
+
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+	})
+	return slog.New(handler)
+}
+
+func newServices(cfg *config.Config, store *storage, logger *slog.Logger) (*services, error) {
+	for _, key := range cfg.Auth.Signing.Keys {
+		if !key.DevRandom {
+			continue
+		}
+		if gin.Mode() == gin.ReleaseMode {
+			return nil, fmt.Errorf("signing key %q: dev_random is not allowed in release mode", key.ID)
+		}
+		logger.Warn("Signing key uses a random development secret", slog.String("kid", key.ID))
+	}
+
+	tokenIssuer, err := issuer.New(cfg.Auth.Signing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	var mail mailer.Mailer
+	switch cfg.Mail.Provider {
+	case "log", "":
+		mail = mailer.NewLogMailer(logger)
+	case "file":
+		mail, err = mailer.NewFileMailer(cfg.Mail.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file mailer: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown mail provider: %s", cfg.Mail.Provider)
+	}
+
+	if cfg.Payment.AutoConfirmAfter > 0 && cfg.Payment.Provider != "simulated" {
+		return nil, fmt.Errorf("payment.auto_confirm_after needs the simulated provider")
+	}
+
+	var paymentProvider payment.Provider
+	switch cfg.Payment.Provider {
+	case "simulated":
+		// simulated deposits credit wallets nobody paid into, development
+		// and tests only
+		if gin.Mode() == gin.ReleaseMode {
+			return nil, fmt.Errorf("payment provider simulated is not allowed in release mode")
+		}
+		logger.Warn("Using the simulated payment provider", slog.Duration("auto_confirm_after", cfg.Payment.AutoConfirmAfter))
+		paymentProvider = payment.NewSimulatedProvider(cfg.Payment.DepositTTL, cfg.Payment.AutoConfirmAfter)
+	case "":
+		return nil, fmt.Errorf("payment.provider is required")
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", cfg.Payment.Provider)
+	}
+
+	s := &services{issuer: tokenIssuer}
+
+	s.apiKeys = service.NewAPIKeyService(store.apiKeys)
+	s.tokens = service.NewTokenService(tokenIssuer, store.tokens, store.users, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+	s.verification = service.NewEmailVerificationService(tokenIssuer, store.users, mail, cfg.Mail.From, cfg.Auth.EmailVerificationURL, cfg.Auth.EmailVerificationTTL)
+	s.lockout = service.NewLockoutService(store.lockouts, store.users, mail, cfg.Mail.From, service.LockoutPolicy{
+		MaxAttempts:   cfg.Auth.Lockout.MaxAttempts,
+		IPMaxAttempts: cfg.Auth.Lockout.IPMaxAttempts,
+		BaseDelay:     cfg.Auth.Lockout.BaseDelay,
+		MaxDelay:      cfg.Auth.Lockout.MaxDelay,
+		Duration:      cfg.Auth.Lockout.Duration,
+		Window:        cfg.Auth.Lockout.Window,
+	})
+	s.twoFactor = service.NewTwoFactorService(tokenIssuer, store.totp, store.users, s.tokens, s.lockout, cfg.Auth.Signing.Issuer)
+	s.users = service.NewUserService(store.users, s.tokens, s.verification, s.twoFactor, s.lockout, store.tx)
+	s.passwordResets = service.NewPasswordResetService(store.passwordResets, store.users, mail, cfg.Mail.From, cfg.Auth.PasswordResetURL, cfg.Auth.PasswordResetTTL)
+	s.products = service.NewProductService(store.products, store.tx)
+	s.categories = service.NewCategoryService(store.categories, store.products, store.tx)
+	s.purchases = service.NewPurchaseService(store.purchases, store.tx)
+	s.orders = service.NewOrderService(store.orders, store.tx)
+	s.carts = service.NewCartService(store.carts, store.products, store.tx)
+	s.wallets = service.NewWalletService(store.wallets)
+	s.deposits = service.NewDepositService(store.deposits, paymentProvider)
+	s.returns = service.NewReturnService(store.returns, store.tx)
+	s.warranties = service.NewWarrantyService(store.warranties, store.purchases, store.tx)
+	s.legacyIDs = service.NewLegacyIDService(store.legacyIDs)
+
+	return s, nil
+}
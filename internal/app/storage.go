@@ -0,0 +1,191 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"vr-shope/internal/config"
+	"vr-shope/internal/migrate"
+	"vr-shope/internal/repository"
+	"vr-shope/internal/service"
+	"vr-shope/internal/storage/postgresql"
+	"vr-shope/internal/storage/sqlite"
+)
+
+// storage holds the repositories of the configured database backend.
+type storage struct {
+	tx             service.Transactor
+	users          service.UserRepository
+	tokens         service.TokenRepository
+	apiKeys        service.APIKeyRepository
+	lockouts       service.LockoutRepository
+	totp           service.TOTPRepository
+	passwordResets service.PasswordResetRepository
+	products       service.ProductRepository
+	categories     service.CategoryRepository
+	purchases      service.PurchaseRepository
+	orders         service.OrderRepository
+	carts          service.CartRepository
+	wallets        service.WalletRepository
+	deposits       service.DepositRepository
+	returns        service.ReturnRepository
+	warranties     service.WarrantyRepository
+	legacyIDs      service.LegacyIDRepository
+}
+
+// backend is everything that differs between the supported databases.
+type backend struct {
+	open       func(cfg *config.DBConfig) (*sql.DB, error)
+	build      func(db *sql.DB) (*storage, error)
+	migrations migrate.Source
+}
+
+func backendFor(driver string) (*backend, error) {
+	switch driver {
+	case "postgres", "":
+		return &backend{postgresql.OpenConnection, newPostgresStorage, migrate.Postgres}, nil
+	case "sqlite":
+		return &backend{sqlite.OpenConnection, newSQLiteStorage, migrate.SQLite}, nil
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", driver)
+	}
+}
+
+func openStorage(ctx context.Context, cfg *config.DBConfig) (*sql.DB, *storage, error) {
+	b, err := backendFor(cfg.Driver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := b.open(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create database connection: %w", err)
+	}
+
+	if cfg.AutoMigrate {
+		if err := migrate.Up(ctx, db, b.migrations); err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
+	}
+
+	s, err := b.build(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return db, s, nil
+}
+
+func newPostgresStorage(db *sql.DB) (*storage, error) {
+	s := &storage{tx: repository.NewTxManager(db)}
+
+	var err error
+	if s.users, err = repository.NewUserStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create user storage: %w", err)
+	}
+	if s.tokens, err = repository.NewTokenStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create token storage: %w", err)
+	}
+	if s.apiKeys, err = repository.NewAPIKeyStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create api key storage: %w", err)
+	}
+	if s.lockouts, err = repository.NewLockoutStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create lockout storage: %w", err)
+	}
+	if s.totp, err = repository.NewTOTPStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create totp storage: %w", err)
+	}
+	if s.passwordResets, err = repository.NewPasswordResetStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create password reset storage: %w", err)
+	}
+	if s.products, err = repository.NewProductStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create product storage: %w", err)
+	}
+	if s.categories, err = repository.NewCategoryStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create category storage: %w", err)
+	}
+	if s.purchases, err = repository.NewPurchaseStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create purchase storage: %w", err)
+	}
+	if s.orders, err = repository.NewOrderStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create order storage: %w", err)
+	}
+	if s.carts, err = repository.NewCartStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create cart storage: %w", err)
+	}
+	if s.wallets, err = repository.NewWalletStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create wallet storage: %w", err)
+	}
+	if s.deposits, err = repository.NewDepositStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create deposit storage: %w", err)
+	}
+	if s.returns, err = repository.NewReturnStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create return storage: %w", err)
+	}
+	if s.warranties, err = repository.NewWarrantyStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create warranty storage: %w", err)
+	}
+	if s.legacyIDs, err = repository.NewLegacyIDStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create legacy id storage: %w", err)
+	}
+
+	return s, nil
+}
+
+func newSQLiteStorage(db *sql.DB) (*storage, error) {
+	s := &storage{tx: sqlite.NewTxManager(db)}
+
+	var err error
+	if s.users, err = sqlite.NewUserStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create user storage: %w", err)
+	}
+	if s.tokens, err = sqlite.NewTokenStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create token storage: %w", err)
+	}
+	if s.apiKeys, err = sqlite.NewAPIKeyStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create api key storage: %w", err)
+	}
+	if s.lockouts, err = sqlite.NewLockoutStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create lockout storage: %w", err)
+	}
+	if s.totp, err = sqlite.NewTOTPStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create totp storage: %w", err)
+	}
+	if s.passwordResets, err = sqlite.NewPasswordResetStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create password reset storage: %w", err)
+	}
+	if s.products, err = sqlite.NewProductStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create product storage: %w", err)
+	}
+	if s.categories, err = sqlite.NewCategoryStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create category storage: %w", err)
+	}
+	if s.purchases, err = sqlite.NewPurchaseStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create purchase storage: %w", err)
+	}
+	if s.orders, err = sqlite.NewOrderStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create order storage: %w", err)
+	}
+	if s.carts, err = sqlite.NewCartStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create cart storage: %w", err)
+	}
+	if s.wallets, err = sqlite.NewWalletStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create wallet storage: %w", err)
+	}
+	if s.deposits, err = sqlite.NewDepositStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create deposit storage: %w", err)
+	}
+	if s.returns, err = sqlite.NewReturnStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create return storage: %w", err)
+	}
+	if s.warranties, err = sqlite.NewWarrantyStorage(db); err != nil {
+		return nil, fmt.Errorf("failed to create warranty storage: %w", err)
+	}
+	if s.legacyIDs, err = sqlite.NewLegacyIDStorage(); err != nil {
+		return nil, fmt.Errorf("failed to create legacy id storage: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,275 @@
+// Package catalog reads and writes the product catalog as CSV or NDJSON. Both
+// formats carry the fields of models.ProductRequest, so an export can be edited
+// in a spreadsheet and imported again.
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"vr-shope/internal/models"
+	"vr-shope/internal/money"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// columns are the CSV header names, the same as the JSON field names.
+var columns = []string{"sku", "name", "cost", "quantity_stock", "warranty_days", "country", "like"}
+
+const maxLineSize = 1 << 20
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case CSV:
+		return CSV, nil
+	case NDJSON, "jsonl":
+		return NDJSON, nil
+	default:
+		return "", fmt.Errorf("unknown catalog format: %q, want csv or ndjson", s)
+	}
+}
+
+// FormatForPath picks the format from the file extension, CSV when it says
+// nothing.
+func FormatForPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl", ".json":
+		return NDJSON
+	default:
+		return CSV
+	}
+}
+
+// FormatForContentType picks the format from a request content type, CSV when
+// it says nothing.
+func FormatForContentType(contentType string) Format {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-ndjson", "application/jsonl", "application/json":
+		return NDJSON
+	default:
+		return CSV
+	}
+}
+
+func (f Format) ContentType() string {
+	if f == NDJSON {
+		return "application/x-ndjson"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+// Decode reads every row of r. A row that can't be decoded is returned with
+// its Err set so the caller can report all of them at once; the error is only
+// for files that can't be read at all.
+func Decode(r io.Reader, format Format) ([]models.ProductImportRow, error) {
+	if format == NDJSON {
+		return decodeNDJSON(r)
+	}
+
+	return decodeCSV(r)
+}
+
+func decodeCSV(r io.Reader) ([]models.ProductImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv file is empty, want a header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		// spreadsheet programs like to start the file with a byte order mark
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(columns, name) {
+			return nil, fmt.Errorf("unknown csv column %q, want some of %s", name, strings.Join(columns, ","))
+		}
+		if _, ok := index[name]; ok {
+			return nil, fmt.Errorf("csv column %q appears twice", name)
+		}
+		index[name] = i
+	}
+	if _, ok := index["name"]; !ok {
+		return nil, errors.New(`csv header has no "name" column`)
+	}
+
+	var rows []models.ProductImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			rows = append(rows, models.ProductImportRow{
+				Line: parseErr.StartLine,
+				Err:  fmt.Errorf("got %d fields, want %d", len(record), len(header)),
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		product, err := parseRecord(record, index)
+		rows = append(rows, models.ProductImportRow{Line: line, Product: product, Err: err})
+	}
+}
+
+// parseRecord maps a CSV record onto a product. Missing columns and empty
+// cells leave the field at its zero value.
+func parseRecord(record []string, index map[string]int) (models.ProductRequest, error) {
+	cell := func(name string) string {
+		if i, ok := index[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	number := func(name string) (int, error) {
+		value := cell(name)
+		if value == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %q", name, value)
+		}
+		return n, nil
+	}
+
+	product := models.ProductRequest{
+		SKU:     cell("sku"),
+		Name:    cell("name"),
+		Country: cell("country"),
+	}
+
+	var err error
+	if cost := cell("cost"); cost != "" {
+		if product.Cost, err = money.Parse(cost); err != nil {
+			return product, fmt.Errorf("invalid cost %q: %w", cost, err)
+		}
+	}
+	if product.QuantityStock, err = number("quantity_stock"); err != nil {
+		return product, err
+	}
+	if product.WarrantyDays, err = number("warranty_days"); err != nil {
+		return product, err
+	}
+	if product.Like, err = number("like"); err != nil {
+		return product, err
+	}
+
+	return product, nil
+}
+
+func decodeNDJSON(r io.Reader) ([]models.ProductImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var rows []models.ProductImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var product models.ProductRequest
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&product)
+		if err == nil && decoder.More() {
+			err = errors.New("more than one value on the line")
+		}
+
+		product.SKU = strings.TrimSpace(product.SKU)
+		product.Name = strings.TrimSpace(product.Name)
+		product.Country = strings.TrimSpace(product.Country)
+		rows = append(rows, models.ProductImportRow{Line: line, Product: product, Err: err})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// Encoder writes products in one of the formats. Call Flush once all of them
+// are written.
+type Encoder struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// NewEncoder starts a catalog file on w. CSV files get their header right
+// away so an empty catalog still produces a valid file.
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	if format == NDJSON {
+		return &Encoder{json: json.NewEncoder(w)}
+	}
+
+	e := &Encoder{csv: csv.NewWriter(w)}
+	_ = e.csv.Write(columns)
+
+	return e
+}
+
+func (e *Encoder) Encode(product *models.Product) error {
+	request := models.ProductRequest{
+		SKU:           product.SKU,
+		Name:          product.Name,
+		Cost:          product.Cost,
+		QuantityStock: product.QuantityStock,
+		WarrantyDays:  product.WarrantyDays,
+		Country:       product.Country,
+		Like:          product.Like,
+	}
+
+	if e.json != nil {
+		return e.json.Encode(request)
+	}
+
+	return e.csv.Write([]string{
+		request.SKU,
+		request.Name,
+		request.Cost.String(),
+		strconv.Itoa(request.QuantityStock),
+		strconv.Itoa(request.WarrantyDays),
+		request.Country,
+		strconv.Itoa(request.Like),
+	})
+}
+
+func (e *Encoder) Flush() error {
+	if e.csv == nil {
+		return nil
+	}
+
+	e.csv.Flush()
+	return e.csv.Error()
+}
@@ -0,0 +1,253 @@
+package catalog_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"vr-shope/internal/catalog"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository/memory"
+	"vr-shope/internal/service"
+)
+
+func TestRoundTrip(t *testing.T) {
+	products := []*models.Product{
+		{SKU: "VR-001", Name: "Headset", Cost: 39999, QuantityStock: 12, WarrantyDays: 365, Country: "JP", Like: 7},
+		{SKU: "", Name: `Cable, 2m "braided"`, Cost: 5, QuantityStock: 0, WarrantyDays: 0, Country: "", Like: 0},
+		{SKU: "VR-003", Name: "Лінзи", Cost: 1250, QuantityStock: 3, WarrantyDays: 30, Country: "UA", Like: 1},
+	}
+
+	for _, format := range []catalog.Format{catalog.CSV, catalog.NDJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			encoder := catalog.NewEncoder(&buf, format)
+			for _, product := range products {
+				if err := encoder.Encode(product); err != nil {
+					t.Fatalf("encode: %v", err)
+				}
+			}
+			if err := encoder.Flush(); err != nil {
+				t.Fatalf("flush: %v", err)
+			}
+
+			rows, err := catalog.Decode(&buf, format)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(rows) != len(products) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(products))
+			}
+
+			for i, row := range rows {
+				if row.Err != nil {
+					t.Fatalf("row %d: %v", i, row.Err)
+				}
+				p := products[i]
+				want := models.ProductRequest{
+					SKU:           p.SKU,
+					Name:          p.Name,
+					Cost:          p.Cost,
+					QuantityStock: p.QuantityStock,
+					WarrantyDays:  p.WarrantyDays,
+					Country:       p.Country,
+					Like:          p.Like,
+				}
+				if row.Product != want {
+					t.Errorf("row %d: got %+v, want %+v", i, row.Product, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		format catalog.Format
+		in     string
+		// err is set when the whole file is rejected
+		err bool
+		// rowErrs lists, per decoded row, whether that row carries an error
+		rowErrs []bool
+		lines   []int
+	}{
+		{
+			name:    "csv header only",
+			format:  catalog.CSV,
+			in:      "sku,name,cost\n",
+			rowErrs: nil,
+		},
+		{
+			name:    "csv byte order mark and column subset",
+			format:  catalog.CSV,
+			in:      "\ufeffname,cost\nHeadset,10.50\n",
+			rowErrs: []bool{false},
+			lines:   []int{2},
+		},
+		{
+			name:   "csv empty file",
+			format: catalog.CSV,
+			in:     "",
+			err:    true,
+		},
+		{
+			name:   "csv unknown column",
+			format: catalog.CSV,
+			in:     "sku,name,price\nA,B,1\n",
+			err:    true,
+		},
+		{
+			name:   "csv repeated column",
+			format: catalog.CSV,
+			in:     "name,name\nA,B\n",
+			err:    true,
+		},
+		{
+			name:   "csv without name column",
+			format: catalog.CSV,
+			in:     "sku,cost\nA,1\n",
+			err:    true,
+		},
+		{
+			name:    "csv wrong field count",
+			format:  catalog.CSV,
+			in:      "sku,name,cost\nA,Headset,1\nB,Cable\nC,Lens,2\n",
+			rowErrs: []bool{false, true, false},
+			lines:   []int{2, 3, 4},
+		},
+		{
+			name:    "csv bad number",
+			format:  catalog.CSV,
+			in:      "name,quantity_stock,warranty_days,like\nA,ten,0,0\nB,1,1.5,0\nC,1,1,x\n",
+			rowErrs: []bool{true, true, true},
+		},
+		{
+			name:    "csv bad money amount",
+			format:  catalog.CSV,
+			in:      "name,cost\nA,1.234\nB,-\nC,1.-5\nD,$5\nE,0.99\n",
+			rowErrs: []bool{true, true, true, true, false},
+		},
+		{
+			name:    "ndjson blank lines keep line numbers",
+			format:  catalog.NDJSON,
+			in:      "{\"name\":\"A\",\"cost\":\"1.00\"}\n\n  \n{\"name\":\"B\",\"cost\":2}\n",
+			rowErrs: []bool{false, false},
+			lines:   []int{1, 4},
+		},
+		{
+			name:    "ndjson malformed json",
+			format:  catalog.NDJSON,
+			in:      "{\"name\":\"A\"\n[1,2]\n\"name\"\n",
+			rowErrs: []bool{true, true, true},
+		},
+		{
+			name:    "ndjson unknown field",
+			format:  catalog.NDJSON,
+			in:      "{\"name\":\"A\",\"price\":\"1.00\"}\n",
+			rowErrs: []bool{true},
+		},
+		{
+			name:    "ndjson two values on a line",
+			format:  catalog.NDJSON,
+			in:      "{\"name\":\"A\"} {\"name\":\"B\"}\n",
+			rowErrs: []bool{true},
+		},
+		{
+			name:    "ndjson bad money amount",
+			format:  catalog.NDJSON,
+			in:      "{\"name\":\"A\",\"cost\":\"1.2.3\"}\n{\"name\":\"B\",\"cost\":\"1e3\"}\n{\"name\":\"C\",\"cost\":\"--1\"}\n",
+			rowErrs: []bool{true, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := catalog.Decode(strings.NewReader(tt.in), tt.format)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got %d rows, want an error", len(rows))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(rows) != len(tt.rowErrs) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.rowErrs))
+			}
+			for i, row := range rows {
+				if (row.Err != nil) != tt.rowErrs[i] {
+					t.Errorf("row %d: got error %v, want error %v", i, row.Err, tt.rowErrs[i])
+				}
+				if tt.lines != nil && row.Line != tt.lines[i] {
+					t.Errorf("row %d: got line %d, want %d", i, row.Line, tt.lines[i])
+				}
+			}
+		})
+	}
+}
+
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// The decoder passes a repeated SKU through, the import is what rejects it.
+func TestImportDuplicateSKU(t *testing.T) {
+	tests := []struct {
+		name   string
+		format catalog.Format
+		in     string
+	}{
+		{
+			name:   "csv",
+			format: catalog.CSV,
+			in:     "sku,name,cost\nVR-1,Headset,10\nVR-2,Cable,1\nVR-1,Headset again,12\n",
+		},
+		{
+			name:   "ndjson",
+			format: catalog.NDJSON,
+			in:     "{\"sku\":\"VR-1\",\"name\":\"Headset\",\"cost\":\"10\"}\n{\"sku\":\"VR-2\",\"name\":\"Cable\",\"cost\":\"1\"}\n{\"sku\":\"VR-1\",\"name\":\"Headset again\",\"cost\":\"12\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := catalog.Decode(strings.NewReader(tt.in), tt.format)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			repo, err := memory.NewProductStorage(memory.NewStore())
+			if err != nil {
+				t.Fatalf("product storage: %v", err)
+			}
+			products := service.NewProductService(repo, noTx{})
+
+			report, err := products.ImportProducts(context.Background(), rows, models.ImportOptions{Upsert: true})
+			if err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if len(report.Errors) != 1 {
+				t.Fatalf("got errors %+v, want one for the repeated sku", report.Errors)
+			}
+			if got := report.Errors[0]; got.SKU != "VR-1" || got.Line != rows[2].Line {
+				t.Errorf("got error %+v, want sku VR-1 on line %d", got, rows[2].Line)
+			}
+			if report.Created != 0 || report.Updated != 0 {
+				t.Errorf("got %d created and %d updated, want nothing written", report.Created, report.Updated)
+			}
+
+			all, err := repo.GetAll(context.Background())
+			if err != nil {
+				t.Fatalf("get all: %v", err)
+			}
+			if len(all) != 0 {
+				t.Errorf("got %d products in the catalog, want none", len(all))
+			}
+		})
+	}
+}
@@ -4,47 +4,108 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type DBConfig struct {
+	Driver   string `yaml:"driver"`
+	Path     string `yaml:"path"`
 	Host     string `yaml:"host"`
 	Port     string `yaml:"port"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	DBName   string `yaml:"dbname"`
 	SSLMode  string `yaml:"sslmode"`
+	// AutoMigrate applies pending migrations when the server starts
+	AutoMigrate bool `yaml:"auto_migrate"`
 }
 
 type ServerConfig struct {
 	Port string `yaml:"port"`
+	// TrustedProxies lists the addresses or CIDRs whose X-Forwarded-For header
+	// is believed, empty trusts none and uses the peer address
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type Logger struct {
 	LogLevel string `yaml:"log_level"`
 }
 
-func LoadConfig(configPath string) (*ServerConfig, *DBConfig, *Logger, error) {
+type PaymentConfig struct {
+	Provider         string        `yaml:"provider"`
+	DepositTTL       time.Duration `yaml:"deposit_ttl"`
+	AutoConfirmAfter time.Duration `yaml:"auto_confirm_after"`
+}
+
+type KeyConfig struct {
+	ID        string `yaml:"kid"`
+	Algorithm string `yaml:"algorithm"`
+	SecretEnv string `yaml:"secret_env"`
+	// DevRandom signs with a random HS256 secret made at startup, tokens stop
+	// working on restart; for development only
+	DevRandom      bool   `yaml:"dev_random"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+}
+
+type SigningConfig struct {
+	Issuer    string      `yaml:"issuer"`
+	ActiveKey string      `yaml:"active_key"`
+	Keys      []KeyConfig `yaml:"keys"`
+}
+
+type AuthConfig struct {
+	AccessTokenTTL       time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL      time.Duration `yaml:"refresh_token_ttl"`
+	PasswordResetTTL     time.Duration `yaml:"password_reset_ttl"`
+	PasswordResetURL     string        `yaml:"password_reset_url"`
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl"`
+	EmailVerificationURL string        `yaml:"email_verification_url"`
+	Lockout              LockoutConfig `yaml:"lockout"`
+	Signing              SigningConfig `yaml:"signing"`
+}
+
+type LockoutConfig struct {
+	MaxAttempts   int           `yaml:"max_attempts"`
+	IPMaxAttempts int           `yaml:"ip_max_attempts"`
+	BaseDelay     time.Duration `yaml:"base_delay"`
+	MaxDelay      time.Duration `yaml:"max_delay"`
+	Duration      time.Duration `yaml:"duration"`
+	Window        time.Duration `yaml:"window"`
+}
+
+type MailConfig struct {
+	Provider string `yaml:"provider"`
+	From     string `yaml:"from"`
+	Dir      string `yaml:"dir"`
+}
+
+type Config struct {
+	Server   ServerConfig  `yaml:"server"`
+	Database DBConfig      `yaml:"database"`
+	Logger   Logger        `yaml:"logger"`
+	Payment  PaymentConfig `yaml:"payment"`
+	Auth     AuthConfig    `yaml:"auth"`
+	Mail     MailConfig    `yaml:"mail"`
+}
+
+func LoadConfig(configPath string) (*Config, error) {
 	filename, err := filepath.Abs(configPath)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("invalid config path: %w", err)
+		return nil, fmt.Errorf("invalid config path: %w", err)
 	}
 
 	yamlFile, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error reading config file: %w", err)
-	}
-
-	var rawConfig struct {
-		Server   ServerConfig `yaml:"server"`
-		Database DBConfig     `yaml:"database"`
-		Logger   Logger       `yaml:"logger"`
+		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
-	if err := yaml.Unmarshal(yamlFile, &rawConfig); err != nil {
-		return nil, nil, nil, fmt.Errorf("error parsing config file: %w", err)
+	cfg := Config{Database: DBConfig{AutoMigrate: true}}
+	if err := yaml.Unmarshal(yamlFile, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %w", err)
 	}
 
-	return &rawConfig.Server, &rawConfig.Database, &rawConfig.Logger, nil
+	return &cfg, nil
 }
@@ -0,0 +1,111 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Service interface {
+	Create(ctx context.Context, actor models.Actor, request *models.APIKeyRequest) (*models.APIKey, string, error)
+	GetAll(ctx context.Context, actor models.Actor) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, actor models.Actor, id string) error
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func toResponse(key *models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func (h *Handler) CreateKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.APIKeyRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		key, plain, err := h.service.Create(c.Request.Context(), middleware.GetActor(c), &request)
+		if err != nil {
+			h.logger.Error("failed to create api key", "error", err)
+			if errors.Is(err, models.ErrInvalidScope) || errors.Is(err, models.ErrAPIKeyName) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+			return
+		}
+
+		h.logger.Info("api key created", slog.String("id", key.ID), slog.Any("scopes", key.Scopes))
+		c.JSON(http.StatusCreated, models.APIKeyCreatedResponse{
+			Message: "api key created, it is shown only once",
+			Key:     plain,
+			APIKey:  toResponse(key),
+		})
+	}
+}
+
+func (h *Handler) GetKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := h.service.GetAll(c.Request.Context(), middleware.GetActor(c))
+		if err != nil {
+			h.logger.Error("failed to get api keys", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get api keys"})
+			return
+		}
+
+		responses := make([]models.APIKeyResponse, 0, len(keys))
+		for _, key := range keys {
+			responses = append(responses, toResponse(key))
+		}
+
+		h.logger.Info("get api keys", slog.Int("count", len(responses)))
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func (h *Handler) RevokeKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		err := h.service.Revoke(c.Request.Context(), middleware.GetActor(c), id)
+		if err != nil {
+			h.logger.Error("failed to revoke api key", "error", err)
+			if errors.Is(err, models.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+			return
+		}
+
+		h.logger.Info("api key revoked", slog.String("id", id))
+		c.JSON(http.StatusOK, gin.H{"message": "api key revoked"})
+	}
+}
@@ -0,0 +1,210 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	AddItem(ctx context.Context, item *models.CartItem) error
+	GetItems(ctx context.Context, userID uuid.UUID) ([]*models.CartItem, error)
+	UpdateItem(ctx context.Context, item *models.CartItem) error
+	DeleteItem(ctx context.Context, userID, id uuid.UUID) error
+	Checkout(ctx context.Context, userID uuid.UUID) (*models.Order, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) GetCart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+
+		items, err := h.service.GetItems(c.Request.Context(), userID)
+		if err != nil {
+			h.logger.Error("failed to get cart", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cart"})
+			return
+		}
+
+		response := models.CartResponse{
+			Message: "get cart",
+			Items:   make([]models.CartItemResponse, 0, len(items)),
+		}
+		for _, item := range items {
+			response.Items = append(response.Items, models.CartItemResponse{
+				ID:          item.ID,
+				ProductID:   item.ProductID,
+				ProductName: item.ProductName,
+				Cost:        item.Cost,
+				Quantity:    item.Quantity,
+			})
+			response.Total += item.Cost.Mul(item.Quantity)
+		}
+
+		h.logger.Info("get cart", slog.Any("cart", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) AddItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.CartItemRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		item := models.CartItem{
+			UserID:    middleware.GetUserID(c),
+			ProductID: request.ProductID,
+			Quantity:  request.Quantity,
+		}
+
+		err := h.service.AddItem(c.Request.Context(), &item)
+		if err != nil {
+			h.logger.Error("failed to add cart item", "error", err)
+			h.writeError(c, err, "failed to add cart item")
+			return
+		}
+
+		response := models.CartItemResponse{
+			ID:          item.ID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Cost:        item.Cost,
+			Quantity:    item.Quantity,
+		}
+
+		h.logger.Info("cart item added", slog.Any("item", response))
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func (h *Handler) UpdateItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		var request models.CartItemRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		item := models.CartItem{
+			ID:       id,
+			UserID:   middleware.GetUserID(c),
+			Quantity: request.Quantity,
+		}
+
+		err = h.service.UpdateItem(c.Request.Context(), &item)
+		if err != nil {
+			h.logger.Error("failed to update cart item", "error", err)
+			h.writeError(c, err, "failed to update cart item")
+			return
+		}
+
+		h.logger.Info("cart item updated", slog.Any("id", id))
+		c.JSON(http.StatusOK, "cart item updated")
+	}
+}
+
+func (h *Handler) DeleteItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		err = h.service.DeleteItem(c.Request.Context(), middleware.GetUserID(c), id)
+		if err != nil {
+			h.logger.Error("failed to delete cart item", "error", err)
+			h.writeError(c, err, "failed to delete cart item")
+			return
+		}
+
+		h.logger.Info("cart item deleted", slog.Any("id", id))
+		c.JSON(http.StatusOK, "cart item deleted")
+	}
+}
+
+func (h *Handler) Checkout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		order, err := h.service.Checkout(c.Request.Context(), middleware.GetUserID(c))
+		if err != nil {
+			h.logger.Error("failed to checkout cart", "error", err)
+			h.writeError(c, err, "failed to checkout cart")
+			return
+		}
+
+		response := models.OrderResponse{
+			Message:   "order created",
+			ID:        order.ID,
+			UserID:    order.UserID,
+			Status:    order.Status,
+			Total:     order.Total,
+			Date:      order.Date,
+			UpdatedAt: order.UpdatedAt,
+			Purchases: make([]models.PurchaseResponse, 0, len(order.Purchases)),
+		}
+		for _, purchase := range order.Purchases {
+			response.Purchases = append(response.Purchases, models.PurchaseResponse{
+				ID:         purchase.ID,
+				OrderID:    purchase.OrderID,
+				UserID:     purchase.UserID,
+				ProductID:  purchase.ProductID,
+				Quantity:   purchase.Quantity,
+				Date:       purchase.Date,
+				WalletUSDT: purchase.WalletUSDT,
+				Cost:       purchase.Cost,
+			})
+		}
+
+		h.logger.Info("order created", slog.Any("order", response))
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidQuantity), errors.Is(err, models.ErrCartEmpty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInsufficientFunds):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrCartChanged), errors.Is(err, models.ErrOutOfStock):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,282 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Create(ctx context.Context, category *models.Category) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Category, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Category, error)
+	GetTree(ctx context.Context) ([]*models.Category, error)
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetProducts(ctx context.Context, id uuid.UUID, limit, offset string) ([]*models.Product, error)
+	GetProductCategories(ctx context.Context, productID uuid.UUID) ([]*models.Category, error)
+	SetProductCategories(ctx context.Context, productID uuid.UUID, categoryIDs []uuid.UUID) ([]*models.Category, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func toResponse(message string, category *models.Category) models.CategoryResponse {
+	response := models.CategoryResponse{
+		Message:  message,
+		ID:       category.ID,
+		ParentID: category.ParentID,
+		Name:     category.Name,
+		Slug:     category.Slug,
+		Position: category.Position,
+	}
+	for _, child := range category.Children {
+		response.Children = append(response.Children, toResponse("", child))
+	}
+
+	return response
+}
+
+func toResponses(message string, categories []*models.Category) []models.CategoryResponse {
+	responses := make([]models.CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		responses = append(responses, toResponse(message, category))
+	}
+
+	return responses
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidCategory),
+		errors.Is(err, models.ErrCategoryCycle),
+		errors.Is(err, models.ErrInvalidPagination):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrCategorySlugTaken),
+		errors.Is(err, models.ErrCategoryHasChildren):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// category looks the :id parameter up as an id, or as a slug when it isn't
+// one, so storefront links can use readable paths.
+func (h *Handler) category(c *gin.Context) (*models.Category, error) {
+	param := c.Param("id")
+	if id, err := uuid.Parse(param); err == nil {
+		return h.service.Get(c.Request.Context(), id)
+	}
+
+	return h.service.GetBySlug(c.Request.Context(), param)
+}
+
+func (h *Handler) GetCategories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categories, err := h.service.GetTree(c.Request.Context())
+		if err != nil {
+			h.logger.Error("failed to get categories", "error", err)
+			h.writeError(c, err, "failed to get categories")
+			return
+		}
+
+		c.JSON(http.StatusOK, toResponses("get category", categories))
+	}
+}
+
+func (h *Handler) GetCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		category, err := h.category(c)
+		if err != nil {
+			h.logger.Error("failed to get category", "error", err)
+			h.writeError(c, err, "failed to get category")
+			return
+		}
+
+		c.JSON(http.StatusOK, toResponse("category found", category))
+	}
+}
+
+func (h *Handler) CreateCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.CategoryRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		category := &models.Category{
+			ParentID: request.ParentID,
+			Name:     request.Name,
+			Slug:     request.Slug,
+			Position: request.Position,
+		}
+		if err := h.service.Create(c.Request.Context(), category); err != nil {
+			h.logger.Error("failed to create category", "error", err)
+			h.writeError(c, err, "failed to create category")
+			return
+		}
+
+		h.logger.Info("category created", slog.Any("id", category.ID), slog.String("slug", category.Slug))
+		c.JSON(http.StatusCreated, toResponse("category created", category))
+	}
+}
+
+func (h *Handler) UpdateCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		var request models.CategoryRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		category := &models.Category{
+			ID:       id,
+			ParentID: request.ParentID,
+			Name:     request.Name,
+			Slug:     request.Slug,
+			Position: request.Position,
+		}
+		if err := h.service.Update(c.Request.Context(), category); err != nil {
+			h.logger.Error("failed to update category", "error", err)
+			h.writeError(c, err, "failed to update category")
+			return
+		}
+
+		h.logger.Info("category updated", slog.Any("id", id))
+		c.JSON(http.StatusOK, toResponse("category updated", category))
+	}
+}
+
+func (h *Handler) DeleteCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		if err := h.service.Delete(c.Request.Context(), id); err != nil {
+			h.logger.Error("failed to delete category", "error", err)
+			h.writeError(c, err, "failed to delete category")
+			return
+		}
+
+		h.logger.Info("category deleted", slog.Any("id", id))
+		c.JSON(http.StatusOK, gin.H{"message": "category deleted"})
+	}
+}
+
+// GetCategoryProducts lists the products of a category including those of its
+// subcategories.
+func (h *Handler) GetCategoryProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		category, err := h.category(c)
+		if err != nil {
+			h.logger.Error("failed to get category", "error", err)
+			h.writeError(c, err, "failed to get category")
+			return
+		}
+
+		offset := c.DefaultQuery("offset", "0")
+		limit := c.DefaultQuery("limit", "10")
+
+		products, err := h.service.GetProducts(c.Request.Context(), category.ID, limit, offset)
+		if err != nil {
+			h.logger.Error("failed to get category products", "error", err)
+			h.writeError(c, err, "failed to get category products")
+			return
+		}
+
+		responses := make([]models.ProductResponse, 0, len(products))
+		for _, product := range products {
+			responses = append(responses, models.ProductResponse{
+				Message:       "product in category",
+				ID:            product.ID,
+				SKU:           product.SKU,
+				Name:          product.Name,
+				Cost:          product.Cost,
+				QuantityStock: product.QuantityStock,
+				WarrantyDays:  product.WarrantyDays,
+				Country:       product.Country,
+				Like:          product.Like,
+			})
+		}
+
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func (h *Handler) GetProductCategories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		categories, err := h.service.GetProductCategories(c.Request.Context(), productID)
+		if err != nil {
+			h.logger.Error("failed to get product categories", "error", err)
+			h.writeError(c, err, "failed to get product categories")
+			return
+		}
+
+		c.JSON(http.StatusOK, toResponses("product category", categories))
+	}
+}
+
+func (h *Handler) SetProductCategories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		var request models.ProductCategoriesRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		categories, err := h.service.SetProductCategories(c.Request.Context(), productID, request.CategoryIDs)
+		if err != nil {
+			h.logger.Error("failed to set product categories", "error", err)
+			h.writeError(c, err, "failed to set product categories")
+			return
+		}
+
+		h.logger.Info("product categories set", slog.Any("product", productID), slog.Int("categories", len(categories)))
+		c.JSON(http.StatusOK, toResponses("product category", categories))
+	}
+}
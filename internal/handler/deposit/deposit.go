@@ -0,0 +1,181 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Create(ctx context.Context, deposit *models.Deposit) error
+	Get(ctx context.Context, userID, id uuid.UUID) (*models.Deposit, error)
+	GetAll(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.Deposit, error)
+	Confirm(ctx context.Context, actorID, id uuid.UUID) (*models.Deposit, error)
+	Fail(ctx context.Context, actorID, id uuid.UUID) (*models.Deposit, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func toResponse(message string, deposit *models.Deposit) models.DepositResponse {
+	return models.DepositResponse{
+		Message:     message,
+		ID:          deposit.ID,
+		UserID:      deposit.UserID,
+		Amount:      deposit.Amount,
+		Status:      deposit.Status,
+		Provider:    deposit.Provider,
+		Reference:   deposit.Reference,
+		PayAddress:  deposit.PayAddress,
+		ExpiresAt:   deposit.ExpiresAt,
+		CreatedAt:   deposit.CreatedAt,
+		ConfirmedAt: deposit.ConfirmedAt,
+		SettledBy:   deposit.SettledBy,
+	}
+}
+
+func (h *Handler) CreateDeposit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.DepositRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		deposit := models.Deposit{
+			UserID: middleware.GetUserID(c),
+			Amount: request.Amount,
+		}
+
+		err := h.service.Create(c.Request.Context(), &deposit)
+		if err != nil {
+			h.logger.Error("failed to create deposit", "error", err)
+			if errors.Is(err, models.ErrInvalidAmount) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create deposit"})
+			return
+		}
+
+		response := toResponse("deposit created", &deposit)
+
+		h.logger.Info("deposit created", slog.Any("deposit", response))
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func (h *Handler) GetDepositByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		deposit, err := h.service.Get(c.Request.Context(), middleware.GetUserID(c), id)
+		if err != nil {
+			h.logger.Error("failed to get deposit", "error", err)
+			if errors.Is(err, models.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "deposit not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get deposit"})
+			return
+		}
+
+		response := toResponse("deposit found", deposit)
+
+		h.logger.Info("deposit found", slog.Any("deposit", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) GetDeposits() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		offset := c.DefaultQuery("offset", "0")
+		limit := c.DefaultQuery("limit", "10")
+
+		deposits, err := h.service.GetAll(c.Request.Context(), middleware.GetUserID(c), limit, offset)
+		if err != nil {
+			h.logger.Error("failed to get deposits", "error", err)
+			if errors.Is(err, models.ErrInvalidPagination) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get deposits"})
+			return
+		}
+
+		responses := make([]models.DepositResponse, 0, len(deposits))
+		for _, deposit := range deposits {
+			responses = append(responses, toResponse("get deposit", deposit))
+		}
+
+		h.logger.Info("get deposits", slog.Any("deposits", responses))
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+// ConfirmDeposit lets staff mark a pending deposit as paid, for providers that
+// are settled by hand.
+func (h *Handler) ConfirmDeposit() gin.HandlerFunc {
+	return h.settle("deposit confirmed", h.service.Confirm)
+}
+
+// FailDeposit lets staff mark a pending deposit as not paid.
+func (h *Handler) FailDeposit() gin.HandlerFunc {
+	return h.settle("deposit failed", h.service.Fail)
+}
+
+func (h *Handler) settle(message string, fn func(ctx context.Context, actorID, id uuid.UUID) (*models.Deposit, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		deposit, err := fn(c.Request.Context(), middleware.GetUserID(c), id)
+		if err != nil {
+			h.logger.Error("failed to settle deposit", "error", err)
+			switch {
+			case errors.Is(err, models.ErrNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "deposit not found"})
+			case errors.Is(err, models.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrDepositNotPending),
+				errors.Is(err, models.ErrSettlementNotAllowed):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to settle deposit"})
+			}
+			return
+		}
+
+		response := toResponse(message, deposit)
+
+		h.logger.Info(message, slog.Any("deposit", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
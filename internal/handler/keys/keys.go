@@ -0,0 +1,35 @@
+package keys
+
+import (
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/issuer"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Service interface {
+	JWKS() issuer.JWKSet
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) GetJWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set := h.service.JWKS()
+
+		h.logger.Debug("get jwks", slog.Int("keys", len(set.Keys)))
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, set)
+	}
+}
@@ -0,0 +1,96 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	GetLocked(ctx context.Context) ([]*models.Lockout, error)
+	UnlockUser(ctx context.Context, id uuid.UUID) error
+	UnlockIP(ctx context.Context, ip string) error
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) GetLockouts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lockouts, err := h.service.GetLocked(c.Request.Context())
+		if err != nil {
+			h.logger.Error("failed to get lockouts", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get lockouts"})
+			return
+		}
+
+		responses := make([]models.LockoutResponse, 0, len(lockouts))
+		for _, lockout := range lockouts {
+			responses = append(responses, models.LockoutResponse{
+				Scope:         lockout.Scope,
+				Subject:       lockout.Subject,
+				Failures:      lockout.Failures,
+				LastFailureAt: lockout.LastFailureAt,
+				LockedUntil:   lockout.LockedUntil,
+			})
+		}
+
+		h.logger.Info("get lockouts", slog.Int("count", len(responses)))
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func (h *Handler) UnlockUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		if err := h.service.UnlockUser(c.Request.Context(), id); err != nil {
+			h.logger.Error("failed to unlock user", "error", err)
+			if errors.Is(err, models.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock user"})
+			return
+		}
+
+		h.logger.Info("user unlocked", slog.String("id", id.String()), slog.String("by", middleware.GetUserID(c).String()))
+		c.JSON(http.StatusOK, gin.H{"message": "user unlocked"})
+	}
+}
+
+func (h *Handler) UnlockIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.Param("ip")
+
+		if err := h.service.UnlockIP(c.Request.Context(), ip); err != nil {
+			h.logger.Error("failed to unlock address", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock address"})
+			return
+		}
+
+		h.logger.Info("address unlocked", slog.String("ip", ip), slog.String("by", middleware.GetUserID(c).String()))
+		c.JSON(http.StatusOK, gin.H{"message": "address unlocked"})
+	}
+}
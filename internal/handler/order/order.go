@@ -0,0 +1,208 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.Order, error)
+	GetByUser(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.Order, error)
+	Transition(ctx context.Context, id, actorID uuid.UUID, status, note string) (*models.Order, error)
+	Cancel(ctx context.Context, actor models.Actor, id uuid.UUID, note string) (*models.Order, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func toResponse(message string, order *models.Order) models.OrderResponse {
+	response := models.OrderResponse{
+		Message:   message,
+		ID:        order.ID,
+		UserID:    order.UserID,
+		Status:    order.Status,
+		Total:     order.Total,
+		Date:      order.Date,
+		UpdatedAt: order.UpdatedAt,
+		Purchases: make([]models.PurchaseResponse, 0, len(order.Purchases)),
+	}
+	for _, purchase := range order.Purchases {
+		response.Purchases = append(response.Purchases, models.PurchaseResponse{
+			ID:         purchase.ID,
+			OrderID:    purchase.OrderID,
+			UserID:     purchase.UserID,
+			ProductID:  purchase.ProductID,
+			Quantity:   purchase.Quantity,
+			Date:       purchase.Date,
+			WalletUSDT: purchase.WalletUSDT,
+			Cost:       purchase.Cost,
+		})
+	}
+	for _, transition := range order.History {
+		response.History = append(response.History, models.OrderTransitionResponse{
+			From:    transition.From,
+			To:      transition.To,
+			ActorID: transition.ActorID,
+			Note:    transition.Note,
+			Date:    transition.Date,
+		})
+	}
+
+	return response
+}
+
+func (h *Handler) GetOrders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		offset := c.DefaultQuery("offset", "0")
+		limit := c.DefaultQuery("limit", "10")
+
+		orders, err := h.service.GetByUser(c.Request.Context(), middleware.GetUserID(c), limit, offset)
+		if err != nil {
+			h.logger.Error("failed to get orders", "error", err)
+			if errors.Is(err, models.ErrInvalidPagination) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get orders"})
+			return
+		}
+
+		responses := make([]models.OrderResponse, 0, len(orders))
+		for _, order := range orders {
+			responses = append(responses, toResponse("get order", order))
+		}
+
+		h.logger.Info("get orders", slog.Any("orders", responses))
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func (h *Handler) GetOrderByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		order, err := h.service.Get(c.Request.Context(), middleware.GetActor(c), id)
+		if err != nil {
+			h.logger.Error("failed to get order", "error", err)
+			if errors.Is(err, models.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+				return
+			}
+			if errors.Is(err, models.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get order"})
+			return
+		}
+
+		response := toResponse("order found", order)
+
+		h.logger.Info("order found", slog.Any("order", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) TransitionOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		var request models.OrderTransitionRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		order, err := h.service.Transition(c.Request.Context(), id, middleware.GetUserID(c), request.Status, request.Note)
+		if err != nil {
+			h.logger.Error("failed to change order status", "error", err)
+			switch {
+			case errors.Is(err, models.ErrInvalidStatus):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			case errors.Is(err, models.ErrInvalidTransition), errors.Is(err, models.ErrConcurrentUpdate):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change order status"})
+			}
+			return
+		}
+
+		response := toResponse("order status changed", order)
+
+		h.logger.Info("order status changed", slog.Any("order", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) CancelOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		var request models.OrderTransitionRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				h.logger.Error("failed to bind request", "error", err)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+				return
+			}
+		}
+
+		order, err := h.service.Cancel(c.Request.Context(), middleware.GetActor(c), id, request.Note)
+		if err != nil {
+			h.logger.Error("failed to cancel order", "error", err)
+			switch {
+			case errors.Is(err, models.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			case errors.Is(err, models.ErrInvalidTransition), errors.Is(err, models.ErrConcurrentUpdate):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel order"})
+			}
+			return
+		}
+
+		response := toResponse("order cancelled", order)
+
+		h.logger.Info("order cancelled", slog.Any("order", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,80 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Service interface {
+	Forgot(ctx context.Context, email string) error
+	Reset(ctx context.Context, token, password string) error
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) ForgotPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.ForgotPasswordRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("Invalid request", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		if err := h.service.Forgot(c.Request.Context(), request.Email); err != nil {
+			h.logger.Error("Error requesting password reset", slog.Any("error", err))
+			if errors.Is(err, models.ErrInvalidEmail) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not request password reset"})
+			return
+		}
+
+		h.logger.Info("Password reset requested")
+		c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a reset link has been sent"})
+	}
+}
+
+func (h *Handler) ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.ResetPasswordRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("Invalid request", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		err := h.service.Reset(c.Request.Context(), request.Token, request.Password)
+		if err != nil {
+			h.logger.Error("Error resetting password", slog.Any("error", err))
+			switch {
+			case errors.Is(err, models.ErrInvalidToken):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			case errors.Is(err, models.ErrInvalidPassword):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset password"})
+			}
+			return
+		}
+
+		h.logger.Info("Password reset")
+		c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+	}
+}
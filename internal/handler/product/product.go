@@ -2,24 +2,32 @@ package product
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"vr-shope/internal/catalog"
 	"vr-shope/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type Service interface {
 	Create(ctx context.Context, product *models.Product) error
-	Get(ctx context.Context, id int) (*models.Product, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.Product, error)
 	GetAll(ctx context.Context) ([]*models.Product, error)
 	Update(ctx context.Context, product *models.Product) error
-	Delete(ctx context.Context, id int) error
+	Delete(ctx context.Context, id uuid.UUID) error
 	GetProductByName(ctx context.Context, name string) ([]*models.Product, error)
 	GetProductsWithPagination(ctx context.Context, limit, offset string) ([]*models.Product, error)
+	ImportProducts(ctx context.Context, rows []models.ProductImportRow, opts models.ImportOptions) (*models.ImportReport, error)
+	ExportProducts(ctx context.Context, fn func(product *models.Product) error) error
 }
 
+const maxImportSize = 32 << 20
+
 type Handler struct {
 	service Service
 	logger  *slog.Logger
@@ -32,6 +40,18 @@ func NewHandler(service Service, logger *slog.Logger) *Handler {
 	}
 }
 
+// productErrorStatus maps the errors a caller can fix to their status code.
+func productErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, models.ErrInvalidProduct):
+		return http.StatusBadRequest, true
+	case errors.Is(err, models.ErrSKUTaken):
+		return http.StatusConflict, true
+	default:
+		return 0, false
+	}
+}
+
 func (h *Handler) CreateProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var productReq models.ProductRequest
@@ -43,10 +63,11 @@ func (h *Handler) CreateProduct() gin.HandlerFunc {
 		}
 
 		productServ := &models.Product{
+			SKU:           productReq.SKU,
 			Name:          productReq.Name,
 			Cost:          productReq.Cost,
 			QuantityStock: productReq.QuantityStock,
-			Guarantees:    productReq.Guarantees,
+			WarrantyDays:  productReq.WarrantyDays,
 			Country:       productReq.Country,
 			Like:          productReq.Like,
 		}
@@ -54,6 +75,10 @@ func (h *Handler) CreateProduct() gin.HandlerFunc {
 		err := h.service.Create(c.Request.Context(), productServ)
 		if err != nil {
 			h.logger.Error("Error creating product", slog.Any("err", err))
+			if status, ok := productErrorStatus(err); ok {
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 			return
 		}
@@ -70,7 +95,7 @@ func (h *Handler) CreateProduct() gin.HandlerFunc {
 func (h *Handler) GetProductByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
+		id, err := uuid.Parse(idStr)
 		if err != nil {
 			h.logger.Error("Error parsing product ID", slog.Any("err", err))
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
@@ -87,10 +112,11 @@ func (h *Handler) GetProductByID() gin.HandlerFunc {
 		productResp := models.ProductResponse{
 			Message:       "product found",
 			ID:            product.ID,
+			SKU:           product.SKU,
 			Name:          product.Name,
 			Cost:          product.Cost,
 			QuantityStock: product.QuantityStock,
-			Guarantees:    product.Guarantees,
+			WarrantyDays:  product.WarrantyDays,
 			Country:       product.Country,
 			Like:          product.Like,
 		}
@@ -114,10 +140,11 @@ func (h *Handler) GetAllProducts() gin.HandlerFunc {
 			productResponses = append(productResponses, models.ProductResponse{
 				Message:       "get product",
 				ID:            product.ID,
+				SKU:           product.SKU,
 				Name:          product.Name,
 				Cost:          product.Cost,
 				QuantityStock: product.QuantityStock,
-				Guarantees:    product.Guarantees,
+				WarrantyDays:  product.WarrantyDays,
 				Country:       product.Country,
 				Like:          product.Like,
 			})
@@ -131,7 +158,7 @@ func (h *Handler) GetAllProducts() gin.HandlerFunc {
 func (h *Handler) UpdateProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
+		id, err := uuid.Parse(idStr)
 		if err != nil {
 			h.logger.Error("Error parsing product ID", slog.Any("err", err))
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
@@ -147,11 +174,12 @@ func (h *Handler) UpdateProduct() gin.HandlerFunc {
 		}
 
 		productServ := &models.Product{
-			ID:            uint64(id),
+			ID:            id,
+			SKU:           productReq.SKU,
 			Name:          productReq.Name,
 			Cost:          productReq.Cost,
 			QuantityStock: productReq.QuantityStock,
-			Guarantees:    productReq.Guarantees,
+			WarrantyDays:  productReq.WarrantyDays,
 			Country:       productReq.Country,
 			Like:          productReq.Like,
 		}
@@ -159,6 +187,10 @@ func (h *Handler) UpdateProduct() gin.HandlerFunc {
 		err = h.service.Update(c.Request.Context(), productServ)
 		if err != nil {
 			h.logger.Error("Error updating product", slog.Any("err", err))
+			if status, ok := productErrorStatus(err); ok {
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update product"})
 			return
 		}
@@ -174,7 +206,7 @@ func (h *Handler) UpdateProduct() gin.HandlerFunc {
 func (h *Handler) DeleteProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
+		id, err := uuid.Parse(idStr)
 		if err != nil {
 			h.logger.Error("Error parsing product ID", slog.Any("err", err))
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
@@ -211,10 +243,11 @@ func (h *Handler) GetProductByName() gin.HandlerFunc {
 			productResponse := models.ProductResponse{
 				Message:       "product by name",
 				ID:            product.ID,
+				SKU:           product.SKU,
 				Name:          product.Name,
 				Cost:          product.Cost,
 				QuantityStock: product.QuantityStock,
-				Guarantees:    product.Guarantees,
+				WarrantyDays:  product.WarrantyDays,
 				Country:       product.Country,
 				Like:          product.Like,
 			}
@@ -244,10 +277,11 @@ func (h *Handler) GetProductsWithPagination() gin.HandlerFunc {
 			productResponse := models.ProductResponse{
 				Message:       "product by name",
 				ID:            product.ID,
+				SKU:           product.SKU,
 				Name:          product.Name,
 				Cost:          product.Cost,
 				QuantityStock: product.QuantityStock,
-				Guarantees:    product.Guarantees,
+				WarrantyDays:  product.WarrantyDays,
 				Country:       product.Country,
 				Like:          product.Like,
 			}
@@ -258,3 +292,102 @@ func (h *Handler) GetProductsWithPagination() gin.HandlerFunc {
 		c.JSON(http.StatusOK, productsResponse)
 	}
 }
+
+func boolQuery(c *gin.Context, name string) (bool, error) {
+	value, err := strconv.ParseBool(c.DefaultQuery(name, "false"))
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %q", name, c.Query(name))
+	}
+
+	return value, nil
+}
+
+// ImportProducts takes a CSV or NDJSON catalog file as the request body. The
+// format comes from the format query parameter or the content type, dry_run
+// and upsert switch on the matching import options.
+func (h *Handler) ImportProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		format := catalog.FormatForContentType(c.ContentType())
+		if name := c.Query("format"); name != "" {
+			if format, err = catalog.ParseFormat(name); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		var opts models.ImportOptions
+		if opts.DryRun, err = boolQuery(c, "dry_run"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if opts.Upsert, err = boolQuery(c, "upsert"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+		rows, err := catalog.Decode(body, format)
+		if err != nil {
+			h.logger.Error("Error reading catalog file", slog.Any("error", err))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Catalog file is too large"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid catalog file: %v", err)})
+			return
+		}
+
+		report, err := h.service.ImportProducts(c.Request.Context(), rows, opts)
+		if err != nil {
+			h.logger.Error("Error importing products", slog.Any("error", err))
+			if status, ok := productErrorStatus(err); ok {
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import products"})
+			return
+		}
+
+		if len(report.Errors) > 0 {
+			h.logger.Info("Product import rejected", slog.Int("rows", report.Rows), slog.Int("errors", len(report.Errors)))
+			c.JSON(http.StatusUnprocessableEntity, report)
+			return
+		}
+
+		h.logger.Info("Products imported", slog.Any("report", report))
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// ExportProducts streams the whole catalog as CSV, or NDJSON with
+// format=ndjson, in the shape ImportProducts accepts.
+func (h *Handler) ExportProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := catalog.ParseFormat(c.DefaultQuery("format", string(catalog.CSV)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+		c.Status(http.StatusOK)
+
+		encoder := catalog.NewEncoder(c.Writer, format)
+		err = h.service.ExportProducts(c.Request.Context(), encoder.Encode)
+		if err == nil {
+			err = encoder.Flush()
+		}
+		if err != nil {
+			// the status line is already out, all that is left is to cut the
+			// response short
+			h.logger.Error("Error exporting products", slog.Any("error", err))
+			c.Abort()
+			return
+		}
+
+		h.logger.Info("Products exported", slog.String("format", string(format)))
+	}
+}
@@ -2,21 +2,21 @@ package purchase
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
-	"strconv"
 	"time"
+	"vr-shope/internal/middleware"
 	"vr-shope/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type Service interface {
-	Create(ctx context.Context, purchase *models.Purchase) error
-	Get(ctx context.Context, id int64) (*models.Purchase, error)
+	Create(ctx context.Context, actor models.Actor, purchase *models.Purchase) error
+	Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.Purchase, error)
 	GetAll(ctx context.Context) ([]*models.Purchase, error)
-	Update(ctx context.Context, purchase *models.Purchase) error
-	Delete(ctx context.Context, id int64) error
 }
 
 type Handler struct {
@@ -40,41 +40,75 @@ func (h *Handler) CreatePurchase() gin.HandlerFunc {
 			return
 		}
 
+		quantity := request.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+
+		// the buyer is always the caller, whose wallet gets debited
+		actor := middleware.GetActor(c)
+
 		purchase := models.Purchase{
-			UserID:    uint64(request.UserID),
-			ProductID: uint64(request.ProductID),
+			UserID:    actor.UserID,
+			ProductID: request.ProductID,
+			Quantity:  quantity,
 			Date:      time.Now(),
 		}
 
-		err := h.service.Create(c.Request.Context(), &purchase)
+		err := h.service.Create(c.Request.Context(), actor, &purchase)
 		if err != nil {
 			h.logger.Error("failed to create purchase", "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create purchase"})
+			switch {
+			case errors.Is(err, models.ErrForbidden), errors.Is(err, models.ErrEmailNotVerified):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrInsufficientFunds):
+				c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrOutOfStock):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrInvalidQuantity):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create purchase"})
+			}
 			return
 		}
 
 		response := models.PurchaseResponse{
-			Message: "purchase created",
+			Message:    "purchase created",
+			ID:         purchase.ID,
+			OrderID:    purchase.OrderID,
+			UserID:     purchase.UserID,
+			ProductID:  purchase.ProductID,
+			Quantity:   purchase.Quantity,
+			Date:       purchase.Date,
+			WalletUSDT: purchase.WalletUSDT,
+			Cost:       purchase.Cost,
 		}
 
 		h.logger.Info("purchase created", slog.Any("purchase", response))
-		c.JSON(http.StatusCreated, response.Message)
+		c.JSON(http.StatusCreated, response)
 	}
 }
 
 func (h *Handler) GetPurchaseByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
+		id, err := uuid.Parse(idStr)
 		if err != nil {
 			h.logger.Error("invalid id format", "error", err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
 			return
 		}
 
-		purchase, err := h.service.Get(c.Request.Context(), int64(id))
+		purchase, err := h.service.Get(c.Request.Context(), middleware.GetActor(c), id)
 		if err != nil {
 			h.logger.Error("failed to get purchase", "error", err)
+			if errors.Is(err, models.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
 			return
 		}
@@ -82,8 +116,10 @@ func (h *Handler) GetPurchaseByID() gin.HandlerFunc {
 		response := models.PurchaseResponse{
 			Message:    "purchase found",
 			ID:         purchase.ID,
+			OrderID:    purchase.OrderID,
 			UserID:     purchase.UserID,
 			ProductID:  purchase.ProductID,
+			Quantity:   purchase.Quantity,
 			Date:       purchase.Date,
 			WalletUSDT: purchase.WalletUSDT,
 			Cost:       purchase.Cost,
@@ -108,8 +144,10 @@ func (h *Handler) GetAllPurchases() gin.HandlerFunc {
 			responses = append(responses, models.PurchaseResponse{
 				Message:    "get purchase",
 				ID:         purchase.ID,
+				OrderID:    purchase.OrderID,
 				UserID:     purchase.UserID,
 				ProductID:  purchase.ProductID,
+				Quantity:   purchase.Quantity,
 				Date:       purchase.Date,
 				WalletUSDT: purchase.WalletUSDT,
 				Cost:       purchase.Cost,
@@ -120,67 +158,3 @@ func (h *Handler) GetAllPurchases() gin.HandlerFunc {
 		c.JSON(http.StatusOK, responses)
 	}
 }
-
-func (h *Handler) UpdatePurchase() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			h.logger.Error("invalid id format", "error", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
-			return
-		}
-
-		var request models.PurchaseRequest
-		if err := c.ShouldBindJSON(&request); err != nil {
-			h.logger.Error("failed to bind request", "error", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
-			return
-		}
-
-		updateData := models.Purchase{
-			ID:        uint64(id),
-			UserID:    uint64(request.UserID),
-			ProductID: uint64(request.ProductID),
-			Date:      time.Now(),
-		}
-
-		err = h.service.Update(c.Request.Context(), &updateData)
-		if err != nil {
-			h.logger.Error("failed to update purchase", "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update purchase"})
-			return
-		}
-
-		response := models.PurchaseResponse{
-			Message: "purchase updated",
-		}
-		h.logger.Info("purchase updated", slog.Any("purchase", response))
-		c.JSON(http.StatusOK, response.Message)
-	}
-}
-
-func (h *Handler) DeletePurchase() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
-
-		if err != nil {
-			h.logger.Error("invalid id format", "error", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
-			return
-		}
-
-		if err := h.service.Delete(c.Request.Context(), int64(id)); err != nil {
-			h.logger.Error("failed to delete purchase", "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete purchase"})
-			return
-		}
-
-		purchaseResp := models.PurchaseResponse{
-			Message: "purchase deleted",
-		}
-		h.logger.Info("purchase deleted", slog.Any("purchase", purchaseResp))
-		c.JSON(http.StatusNoContent, purchaseResp.Message)
-	}
-}
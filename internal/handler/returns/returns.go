@@ -0,0 +1,239 @@
+package returns
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Create(ctx context.Context, userID uuid.UUID, request *models.ReturnRequest) (*models.Return, error)
+	Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.Return, error)
+	GetByUser(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.Return, error)
+	GetByStatus(ctx context.Context, status, limit, offset string) ([]*models.Return, error)
+	Approve(ctx context.Context, id, actorID uuid.UUID, request *models.ReturnApproveRequest) (*models.Return, error)
+	Reject(ctx context.Context, id, actorID uuid.UUID, note string) (*models.Return, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func toResponse(message string, ret *models.Return) models.ReturnResponse {
+	response := models.ReturnResponse{
+		Message:      message,
+		ID:           ret.ID,
+		PurchaseID:   ret.PurchaseID,
+		UserID:       ret.UserID,
+		Quantity:     ret.Quantity,
+		Reason:       ret.Reason,
+		Status:       ret.Status,
+		RefundAmount: ret.RefundAmount,
+		Restocked:    ret.Restocked,
+		ResolvedBy:   ret.ResolvedBy,
+		ResolvedAt:   ret.ResolvedAt,
+		Date:         ret.Date,
+		UpdatedAt:    ret.UpdatedAt,
+	}
+	for _, event := range ret.Events {
+		response.Events = append(response.Events, models.ReturnEventResponse{
+			ActorID: event.ActorID,
+			Action:  event.Action,
+			Note:    event.Note,
+			Date:    event.Date,
+		})
+	}
+
+	return response
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidQuantity),
+		errors.Is(err, models.ErrInvalidReturnInput),
+		errors.Is(err, models.ErrInvalidPagination),
+		errors.Is(err, models.ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrReturnQuantity),
+		errors.Is(err, models.ErrRefundAmount),
+		errors.Is(err, models.ErrNotReturnable),
+		errors.Is(err, models.ErrReturnResolved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func (h *Handler) CreateReturn() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.ReturnRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		ret, err := h.service.Create(c.Request.Context(), middleware.GetUserID(c), &request)
+		if err != nil {
+			h.logger.Error("failed to create return", "error", err)
+			h.writeError(c, err, "failed to create return")
+			return
+		}
+
+		response := toResponse("return requested", ret)
+
+		h.logger.Info("return requested", slog.Any("return", response))
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func (h *Handler) GetReturns() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		offset := c.DefaultQuery("offset", "0")
+		limit := c.DefaultQuery("limit", "10")
+
+		returns, err := h.service.GetByUser(c.Request.Context(), middleware.GetUserID(c), limit, offset)
+		if err != nil {
+			h.logger.Error("failed to get returns", "error", err)
+			h.writeError(c, err, "failed to get returns")
+			return
+		}
+
+		responses := make([]models.ReturnResponse, 0, len(returns))
+		for _, ret := range returns {
+			responses = append(responses, toResponse("get return", ret))
+		}
+
+		h.logger.Info("get returns", slog.Any("returns", responses))
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func (h *Handler) GetReturnQueue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", models.ReturnRequested)
+		offset := c.DefaultQuery("offset", "0")
+		limit := c.DefaultQuery("limit", "10")
+
+		returns, err := h.service.GetByStatus(c.Request.Context(), status, limit, offset)
+		if err != nil {
+			h.logger.Error("failed to get returns", "error", err)
+			h.writeError(c, err, "failed to get returns")
+			return
+		}
+
+		responses := make([]models.ReturnResponse, 0, len(returns))
+		for _, ret := range returns {
+			responses = append(responses, toResponse("get return", ret))
+		}
+
+		h.logger.Info("get return queue", slog.Any("returns", responses))
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func (h *Handler) GetReturnByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		ret, err := h.service.Get(c.Request.Context(), middleware.GetActor(c), id)
+		if err != nil {
+			h.logger.Error("failed to get return", "error", err)
+			h.writeError(c, err, "failed to get return")
+			return
+		}
+
+		response := toResponse("return found", ret)
+
+		h.logger.Info("return found", slog.Any("return", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) ApproveReturn() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		var request models.ReturnApproveRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				h.logger.Error("failed to bind request", "error", err)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+				return
+			}
+		}
+
+		ret, err := h.service.Approve(c.Request.Context(), id, middleware.GetUserID(c), &request)
+		if err != nil {
+			h.logger.Error("failed to approve return", "error", err)
+			h.writeError(c, err, "failed to approve return")
+			return
+		}
+
+		response := toResponse("return approved", ret)
+
+		h.logger.Info("return approved", slog.Any("return", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) RejectReturn() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		var request models.ReturnRejectRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		ret, err := h.service.Reject(c.Request.Context(), id, middleware.GetUserID(c), request.Note)
+		if err != nil {
+			h.logger.Error("failed to reject return", "error", err)
+			h.writeError(c, err, "failed to reject return")
+			return
+		}
+
+		response := toResponse("return rejected", ret)
+
+		h.logger.Info("return rejected", slog.Any("return", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
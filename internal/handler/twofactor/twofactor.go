@@ -0,0 +1,164 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Service interface {
+	Enroll(ctx context.Context, actor models.Actor) (*models.TOTPEnrollment, error)
+	Confirm(ctx context.Context, actor models.Actor, code string) ([]string, error)
+	Disable(ctx context.Context, actor models.Actor, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, actor models.Actor, code string) ([]string, error)
+	Login(ctx context.Context, mfaToken, code, clientIP string) (*models.TokenPair, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, message string) {
+	var locked *models.LockedError
+	switch {
+	case errors.As(err, &locked):
+		c.Header("Retry-After", strconv.Itoa(int(time.Until(locked.Until).Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidTOTPCode), errors.Is(err, models.ErrInvalidToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrTwoFactorEnabled),
+		errors.Is(err, models.ErrTwoFactorNotEnabled),
+		errors.Is(err, models.ErrTwoFactorNotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func (h *Handler) Enroll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enrollment, err := h.service.Enroll(c.Request.Context(), middleware.GetActor(c))
+		if err != nil {
+			h.logger.Error("Error starting two-factor enrollment", slog.Any("error", err))
+			h.writeError(c, err, "Could not start two-factor enrollment")
+			return
+		}
+
+		h.logger.Info("Two-factor enrollment started")
+		c.JSON(http.StatusOK, models.TOTPEnrollmentResponse{
+			Secret: enrollment.Secret,
+			URI:    enrollment.URI,
+		})
+	}
+}
+
+func (h *Handler) Confirm() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.TOTPCodeRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("Invalid request", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		codes, err := h.service.Confirm(c.Request.Context(), middleware.GetActor(c), request.Code)
+		if err != nil {
+			h.logger.Error("Error confirming two-factor enrollment", slog.Any("error", err))
+			h.writeError(c, err, "Could not enable two-factor authentication")
+			return
+		}
+
+		h.logger.Info("Two-factor authentication enabled")
+		c.JSON(http.StatusOK, models.RecoveryCodesResponse{
+			Message:       "Two-factor authentication enabled, store the recovery codes somewhere safe",
+			RecoveryCodes: codes,
+		})
+	}
+}
+
+func (h *Handler) Disable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.TOTPCodeRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("Invalid request", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		if err := h.service.Disable(c.Request.Context(), middleware.GetActor(c), request.Code); err != nil {
+			h.logger.Error("Error disabling two-factor authentication", slog.Any("error", err))
+			h.writeError(c, err, "Could not disable two-factor authentication")
+			return
+		}
+
+		h.logger.Info("Two-factor authentication disabled")
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+	}
+}
+
+func (h *Handler) RegenerateRecoveryCodes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.TOTPCodeRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("Invalid request", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), middleware.GetActor(c), request.Code)
+		if err != nil {
+			h.logger.Error("Error regenerating recovery codes", slog.Any("error", err))
+			h.writeError(c, err, "Could not regenerate recovery codes")
+			return
+		}
+
+		h.logger.Info("Recovery codes regenerated")
+		c.JSON(http.StatusOK, models.RecoveryCodesResponse{
+			Message:       "Recovery codes regenerated, the previous codes no longer work",
+			RecoveryCodes: codes,
+		})
+	}
+}
+
+func (h *Handler) Login() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.TwoFactorLoginRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("Invalid request", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		tokens, err := h.service.Login(c.Request.Context(), request.MFAToken, request.Code, c.ClientIP())
+		if err != nil {
+			h.logger.Error("Error completing two-factor login", slog.Any("error", err))
+			h.writeError(c, err, "Could not log in")
+			return
+		}
+
+		h.logger.Info("Token issued")
+		c.JSON(http.StatusOK, models.TokenResponse{
+			Token:        tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(time.Until(tokens.ExpiresAt).Seconds()),
+		})
+	}
+}
@@ -2,23 +2,30 @@ package user
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
+	"vr-shope/internal/middleware"
 	"vr-shope/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type Service interface {
 	CreateUser(ctx context.Context, user *models.User) error
-	Get(ctx context.Context, id int) (*models.User, error)
+	Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.User, error)
 	GetAll(ctx context.Context) ([]*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUsersWithPagination(ctx context.Context, limit, offset string) ([]*models.User, error)
-	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id int) error
-	GetToken(ctx context.Context, login string, password string) (string, error)
+	Update(ctx context.Context, actor models.Actor, user *models.User) error
+	Delete(ctx context.Context, actor models.Actor, id uuid.UUID) error
+	GetToken(ctx context.Context, login, password, clientIP string) (*models.LoginResult, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, userID uuid.UUID, tokenID string, expiresAt time.Time, refreshToken string) error
+	SetRole(ctx context.Context, id uuid.UUID, role string) error
 }
 
 type Handler struct {
@@ -52,17 +59,20 @@ func (h *Handler) CreateUser() gin.HandlerFunc {
 			Email:       userReq.Email,
 		}
 
+		userResp := models.UserResponse{
+			Message: "User created, check your email to verify the address",
+		}
+
 		err := h.service.CreateUser(c.Request.Context(), userServ)
-		if err != nil {
+		if errors.Is(err, models.ErrVerificationNotSent) {
+			h.logger.Error("Error sending verification email", slog.Any("err", err))
+			userResp.Message = "User created, verification email could not be sent, request a new one"
+		} else if err != nil {
 			h.logger.Error("Error creating userReq", slog.Any("err", err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
 			return
 		}
 
-		userResp := models.UserResponse{
-			Message: "User created",
-		}
-
 		h.logger.Info("User created", slog.Any("userResp", userResp))
 		c.JSON(http.StatusCreated, userResp.Message)
 	}
@@ -71,16 +81,20 @@ func (h *Handler) CreateUser() gin.HandlerFunc {
 func (h *Handler) GetUserByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
+		id, err := uuid.Parse(idStr)
 		if err != nil {
-			h.logger.Error("Error converting id to int", slog.Any("id", id))
-			c.JSON(http.StatusBadRequest, gin.H{"error": err})
+			h.logger.Error("Error parsing user id, expected a UUID or legacy integer id", slog.String("id", idStr), slog.Any("err", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
 			return
 		}
 
-		user, err := h.service.Get(c.Request.Context(), id)
+		user, err := h.service.Get(c.Request.Context(), middleware.GetActor(c), id)
 		if err != nil {
 			h.logger.Error("Error fetching user", slog.Any("err", err))
+			if errors.Is(err, models.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+				return
+			}
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
 		}
@@ -95,6 +109,8 @@ func (h *Handler) GetUserByID() gin.HandlerFunc {
 			Email:           user.Email,
 			WalletUSDT:      user.WalletUSDT,
 			NumberPurchases: user.NumberPurchases,
+			Role:            user.Role,
+			EmailVerified:   user.EmailVerified,
 		}
 
 		h.logger.Info("User found", slog.Any("userResp", userResp))
@@ -123,6 +139,8 @@ func (h *Handler) GetAllUsers() gin.HandlerFunc {
 				Email:           user.Email,
 				WalletUSDT:      user.WalletUSDT,
 				NumberPurchases: user.NumberPurchases,
+				Role:            user.Role,
+				EmailVerified:   user.EmailVerified,
 			})
 		}
 
@@ -134,7 +152,7 @@ func (h *Handler) GetAllUsers() gin.HandlerFunc {
 func (h *Handler) UpdateUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
+		id, err := uuid.Parse(idStr)
 		if err != nil {
 			h.logger.Error("Error parsing user id", slog.Any("err", err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing user id"})
@@ -150,19 +168,22 @@ func (h *Handler) UpdateUser() gin.HandlerFunc {
 		}
 
 		userServ := &models.User{
-			ID:          uint64(id),
+			ID:          id,
 			Login:       userReq.Login,
 			Name:        userReq.Name,
 			LastName:    userReq.LastName,
 			PhoneNumber: userReq.PhoneNumber,
 			Password:    userReq.Password,
 			Email:       userReq.Email,
-			WalletUSDT:  userReq.WalletUSDT,
 		}
 
-		err = h.service.Update(c.Request.Context(), userServ)
+		err = h.service.Update(c.Request.Context(), middleware.GetActor(c), userServ)
 		if err != nil {
 			h.logger.Error("Error updating user", slog.Any("err", err))
+			if errors.Is(err, models.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update user"})
 			return
 		}
@@ -179,16 +200,20 @@ func (h *Handler) UpdateUser() gin.HandlerFunc {
 func (h *Handler) DeleteUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
+		id, err := uuid.Parse(idStr)
 		if err != nil {
 			h.logger.Error("Error parsing user id", slog.Any("err", err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing user id"})
 			return
 		}
 
-		err = h.service.Delete(c.Request.Context(), id)
+		err = h.service.Delete(c.Request.Context(), middleware.GetActor(c), id)
 		if err != nil {
 			h.logger.Error("Error deleting user", slog.Any("err", err))
+			if errors.Is(err, models.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete user"})
 			return
 		}
@@ -202,6 +227,42 @@ func (h *Handler) DeleteUser() gin.HandlerFunc {
 	}
 }
 
+func (h *Handler) SetUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("Error parsing user id", slog.Any("err", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error parsing user id"})
+			return
+		}
+
+		var roleReq models.RoleRequest
+		if err := c.ShouldBindJSON(&roleReq); err != nil {
+			h.logger.Error("Error binding JSON", slog.Any("err", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		err = h.service.SetRole(c.Request.Context(), id, roleReq.Role)
+		if err != nil {
+			h.logger.Error("Error updating user role", slog.Any("err", err))
+			switch {
+			case errors.Is(err, models.ErrInvalidRole):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update user role"})
+			}
+			return
+		}
+
+		h.logger.Info("User role updated", slog.Any("id", id), slog.String("role", roleReq.Role))
+		c.JSON(http.StatusOK, "User role updated")
+	}
+}
+
 func (h *Handler) GetUserByEmail() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		email := c.Query("email")
@@ -223,6 +284,8 @@ func (h *Handler) GetUserByEmail() gin.HandlerFunc {
 			Email:           user.Email,
 			WalletUSDT:      user.WalletUSDT,
 			NumberPurchases: user.NumberPurchases,
+			Role:            user.Role,
+			EmailVerified:   user.EmailVerified,
 		}
 
 		h.logger.Info("User found", slog.Any("userResp", userResp))
@@ -240,19 +303,93 @@ func (h *Handler) Login() gin.HandlerFunc {
 			return
 		}
 
-		token, err := h.service.GetToken(c.Request.Context(), user.Login, user.Password)
+		result, err := h.service.GetToken(c.Request.Context(), user.Login, user.Password, c.ClientIP())
 		if err != nil {
 			h.logger.Error("User not found", slog.Any("error", err))
+			var locked *models.LockedError
+			if errors.As(err, &locked) {
+				c.Header("Retry-After", strconv.Itoa(int(time.Until(locked.Until).Seconds())+1))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
 		}
 
-		tokenResponse := models.TokenResponse{
-			Token: token,
+		if result.Tokens == nil {
+			h.logger.Info("Second factor required")
+			c.JSON(http.StatusOK, models.MFAChallengeResponse{
+				MFARequired: true,
+				MFAToken:    result.MFAToken,
+				ExpiresIn:   int(time.Until(result.MFAExpiresAt).Seconds()),
+			})
+			return
+		}
+
+		h.logger.Info("Token issued")
+		c.JSON(http.StatusOK, toTokenResponse(result.Tokens))
+	}
+}
+
+func (h *Handler) Refresh() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.RefreshRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("Invalid request", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		tokens, err := h.service.RefreshToken(c.Request.Context(), request.RefreshToken)
+		if err != nil {
+			h.logger.Error("Error refreshing token", slog.Any("error", err))
+			if errors.Is(err, models.ErrInvalidToken) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not refresh token"})
+			return
 		}
 
-		h.logger.Info("Token found", slog.Any("tokenResponse", tokenResponse))
-		c.JSON(http.StatusOK, tokenResponse)
+		h.logger.Info("Token refreshed")
+		c.JSON(http.StatusOK, toTokenResponse(tokens))
+	}
+}
+
+func (h *Handler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.LogoutRequest
+
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				h.logger.Error("Invalid request", slog.Any("error", err))
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+				return
+			}
+		}
+
+		expiresAt, _ := c.Get("tokenExpiresAt")
+		expiry, _ := expiresAt.(time.Time)
+
+		err := h.service.Logout(c.Request.Context(), middleware.GetUserID(c), c.GetString("tokenID"), expiry, request.RefreshToken)
+		if err != nil {
+			h.logger.Error("Error logging out", slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
+			return
+		}
+
+		h.logger.Info("User logged out", slog.Any("id", middleware.GetUserID(c)))
+		c.JSON(http.StatusOK, "User logged out")
+	}
+}
+
+func toTokenResponse(tokens *models.TokenPair) models.TokenResponse {
+	return models.TokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(tokens.ExpiresAt).Seconds()),
 	}
 }
 
@@ -280,6 +417,8 @@ func (h *Handler) GetUserWithPagination() gin.HandlerFunc {
 				Email:           user.Email,
 				WalletUSDT:      user.WalletUSDT,
 				NumberPurchases: user.NumberPurchases,
+				Role:            user.Role,
+				EmailVerified:   user.EmailVerified,
 			}
 			usersResponse = append(usersResponse, userResponse)
 		}
@@ -0,0 +1,73 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Service interface {
+	Resend(ctx context.Context, email string) error
+	Verify(ctx context.Context, token string) error
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) VerifyEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := h.service.Verify(c.Request.Context(), c.Query("token"))
+		if err != nil {
+			h.logger.Error("Error verifying email", slog.Any("error", err))
+			switch {
+			case errors.Is(err, models.ErrVerificationExpired):
+				c.JSON(http.StatusGone, gin.H{"error": "Verification link has expired, request a new one"})
+			case errors.Is(err, models.ErrInvalidToken):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification link"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify email"})
+			}
+			return
+		}
+
+		h.logger.Info("Email verified")
+		c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+	}
+}
+
+func (h *Handler) ResendVerification() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.ResendVerificationRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("Invalid request", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		if err := h.service.Resend(c.Request.Context(), request.Email); err != nil {
+			h.logger.Error("Error resending verification email", slog.Any("error", err))
+			if errors.Is(err, models.ErrInvalidEmail) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not resend verification email"})
+			return
+		}
+
+		h.logger.Info("Verification email requested")
+		c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered and unverified, a verification link has been sent"})
+	}
+}
@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	GetBalance(ctx context.Context, userID uuid.UUID) (*models.WalletBalance, error)
+	GetTransactions(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.WalletTransaction, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) GetBalance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		balance, err := h.service.GetBalance(c.Request.Context(), middleware.GetUserID(c))
+		if err != nil {
+			h.logger.Error("failed to get wallet balance", "error", err)
+			if errors.Is(err, models.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get wallet balance"})
+			return
+		}
+
+		if !balance.Reconciled {
+			h.logger.Warn("wallet balance does not match ledger",
+				slog.Any("userID", balance.UserID),
+				slog.String("balance", balance.Balance.String()),
+				slog.String("ledger", balance.LedgerBalance.String()),
+			)
+		}
+
+		response := models.WalletBalanceResponse{
+			Message:       "get wallet",
+			UserID:        balance.UserID,
+			Balance:       balance.Balance,
+			LedgerBalance: balance.LedgerBalance,
+			Reconciled:    balance.Reconciled,
+		}
+
+		h.logger.Info("get wallet", slog.Any("wallet", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) GetTransactions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		offset := c.DefaultQuery("offset", "0")
+		limit := c.DefaultQuery("limit", "10")
+
+		transactions, err := h.service.GetTransactions(c.Request.Context(), middleware.GetUserID(c), limit, offset)
+		if err != nil {
+			h.logger.Error("failed to get wallet transactions", "error", err)
+			if errors.Is(err, models.ErrInvalidPagination) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get wallet transactions"})
+			return
+		}
+
+		responses := make([]models.WalletTransactionResponse, 0, len(transactions))
+		for _, transaction := range transactions {
+			responses = append(responses, models.WalletTransactionResponse{
+				ID:            transaction.ID,
+				TransactionID: transaction.TransactionID,
+				Kind:          transaction.Kind,
+				ReferenceID:   transaction.ReferenceID,
+				Description:   transaction.Description,
+				Amount:        transaction.Amount,
+				Balance:       transaction.Balance,
+				Date:          transaction.Date,
+			})
+		}
+
+		h.logger.Info("get wallet transactions", slog.Any("transactions", responses))
+		c.JSON(http.StatusOK, responses)
+	}
+}
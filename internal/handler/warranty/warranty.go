@@ -0,0 +1,233 @@
+package warranty
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vr-shope/internal/middleware"
+	"vr-shope/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Coverage(ctx context.Context, actor models.Actor, purchaseID uuid.UUID) (*models.WarrantyCoverage, error)
+	Create(ctx context.Context, userID uuid.UUID, request *models.WarrantyClaimRequest) (*models.WarrantyClaim, error)
+	Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.WarrantyClaim, error)
+	GetByUser(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.WarrantyClaim, error)
+	GetByStatus(ctx context.Context, status, limit, offset string) ([]*models.WarrantyClaim, error)
+	Transition(ctx context.Context, id, actorID uuid.UUID, status, note string) (*models.WarrantyClaim, error)
+}
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func toResponse(message string, claim *models.WarrantyClaim) models.WarrantyClaimResponse {
+	response := models.WarrantyClaimResponse{
+		Message:     message,
+		ID:          claim.ID,
+		PurchaseID:  claim.PurchaseID,
+		UserID:      claim.UserID,
+		Description: claim.Description,
+		Status:      claim.Status,
+		Date:        claim.Date,
+		UpdatedAt:   claim.UpdatedAt,
+	}
+	for _, transition := range claim.History {
+		response.History = append(response.History, models.ClaimTransitionResponse{
+			From:    transition.From,
+			To:      transition.To,
+			ActorID: transition.ActorID,
+			Note:    transition.Note,
+			Date:    transition.Date,
+		})
+	}
+
+	return response
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidClaim),
+		errors.Is(err, models.ErrInvalidPagination),
+		errors.Is(err, models.ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrWarrantyExpired),
+		errors.Is(err, models.ErrNotCovered),
+		errors.Is(err, models.ErrClaimOpen),
+		errors.Is(err, models.ErrInvalidTransition),
+		errors.Is(err, models.ErrConcurrentUpdate):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func (h *Handler) GetCoverage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		coverage, err := h.service.Coverage(c.Request.Context(), middleware.GetActor(c), id)
+		if err != nil {
+			h.logger.Error("failed to get warranty coverage", "error", err)
+			h.writeError(c, err, "failed to get warranty coverage")
+			return
+		}
+
+		response := models.WarrantyCoverageResponse{
+			Message:      "warranty coverage",
+			PurchaseID:   coverage.PurchaseID,
+			ProductID:    coverage.ProductID,
+			PurchasedAt:  coverage.PurchasedAt,
+			CoveredUntil: coverage.CoveredUntil,
+			Active:       coverage.Active,
+		}
+
+		h.logger.Info("warranty coverage", slog.Any("coverage", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) CreateClaim() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.WarrantyClaimRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		claim, err := h.service.Create(c.Request.Context(), middleware.GetUserID(c), &request)
+		if err != nil {
+			h.logger.Error("failed to create warranty claim", "error", err)
+			h.writeError(c, err, "failed to create warranty claim")
+			return
+		}
+
+		response := toResponse("warranty claim submitted", claim)
+
+		h.logger.Info("warranty claim submitted", slog.Any("claim", response))
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func (h *Handler) GetClaims() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		offset := c.DefaultQuery("offset", "0")
+		limit := c.DefaultQuery("limit", "10")
+
+		claims, err := h.service.GetByUser(c.Request.Context(), middleware.GetUserID(c), limit, offset)
+		if err != nil {
+			h.logger.Error("failed to get warranty claims", "error", err)
+			h.writeError(c, err, "failed to get warranty claims")
+			return
+		}
+
+		responses := make([]models.WarrantyClaimResponse, 0, len(claims))
+		for _, claim := range claims {
+			responses = append(responses, toResponse("get warranty claim", claim))
+		}
+
+		h.logger.Info("get warranty claims", slog.Any("claims", responses))
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func (h *Handler) GetClaimQueue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", models.ClaimSubmitted)
+		offset := c.DefaultQuery("offset", "0")
+		limit := c.DefaultQuery("limit", "10")
+
+		claims, err := h.service.GetByStatus(c.Request.Context(), status, limit, offset)
+		if err != nil {
+			h.logger.Error("failed to get warranty claims", "error", err)
+			h.writeError(c, err, "failed to get warranty claims")
+			return
+		}
+
+		responses := make([]models.WarrantyClaimResponse, 0, len(claims))
+		for _, claim := range claims {
+			responses = append(responses, toResponse("get warranty claim", claim))
+		}
+
+		h.logger.Info("get warranty claim queue", slog.Any("claims", responses))
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func (h *Handler) GetClaimByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		claim, err := h.service.Get(c.Request.Context(), middleware.GetActor(c), id)
+		if err != nil {
+			h.logger.Error("failed to get warranty claim", "error", err)
+			h.writeError(c, err, "failed to get warranty claim")
+			return
+		}
+
+		response := toResponse("warranty claim found", claim)
+
+		h.logger.Info("warranty claim found", slog.Any("claim", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (h *Handler) TransitionClaim() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("invalid id format", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+
+		var request models.ClaimTransitionRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			h.logger.Error("failed to bind request", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		claim, err := h.service.Transition(c.Request.Context(), id, middleware.GetUserID(c), request.Status, request.Note)
+		if err != nil {
+			h.logger.Error("failed to change warranty claim status", "error", err)
+			h.writeError(c, err, "failed to change warranty claim status")
+			return
+		}
+
+		response := toResponse("warranty claim status changed", claim)
+
+		h.logger.Info("warranty claim status changed", slog.Any("claim", response))
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,294 @@
+package issuer
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"vr-shope/internal/config"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrNoSigningKey     = errors.New("active signing key is not configured")
+	ErrInvalidKeyConfig = errors.New("invalid key configuration")
+)
+
+type key struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+type Issuer struct {
+	name   string
+	active *key
+	keys   map[string]*key
+}
+
+func New(cfg config.SigningConfig) (*Issuer, error) {
+	issuer := &Issuer{
+		name: cfg.Issuer,
+		keys: make(map[string]*key, len(cfg.Keys)),
+	}
+
+	for _, keyCfg := range cfg.Keys {
+		if keyCfg.ID == "" {
+			return nil, fmt.Errorf("%w: kid is required", ErrInvalidKeyConfig)
+		}
+		if _, ok := issuer.keys[keyCfg.ID]; ok {
+			return nil, fmt.Errorf("%w: duplicate kid %q", ErrInvalidKeyConfig, keyCfg.ID)
+		}
+
+		k, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", keyCfg.ID, err)
+		}
+		issuer.keys[k.id] = k
+	}
+
+	active, ok := issuer.keys[cfg.ActiveKey]
+	if !ok || active.signKey == nil {
+		return nil, ErrNoSigningKey
+	}
+	issuer.active = active
+
+	return issuer, nil
+}
+
+func loadKey(cfg config.KeyConfig) (*key, error) {
+	k := &key{id: cfg.ID}
+
+	switch cfg.Algorithm {
+	case "HS256":
+		k.method = jwt.SigningMethodHS256
+
+		var secret []byte
+		switch {
+		case cfg.DevRandom && cfg.SecretEnv != "":
+			return nil, fmt.Errorf("%w: secret_env and dev_random exclude each other", ErrInvalidKeyConfig)
+		case cfg.DevRandom:
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, fmt.Errorf("failed to generate dev secret: %w", err)
+			}
+		case cfg.SecretEnv != "":
+			env := os.Getenv(cfg.SecretEnv)
+			if env == "" {
+				return nil, fmt.Errorf("%w: environment variable %s is not set", ErrInvalidKeyConfig, cfg.SecretEnv)
+			}
+			secret = []byte(env)
+		default:
+			return nil, fmt.Errorf("%w: HS256 key needs secret_env", ErrInvalidKeyConfig)
+		}
+		k.signKey = secret
+		k.verifyKey = secret
+
+	case "RS256", "EdDSA":
+		if cfg.Algorithm == "RS256" {
+			k.method = jwt.SigningMethodRS256
+		} else {
+			k.method = jwt.SigningMethodEdDSA
+		}
+
+		if cfg.PrivateKeyFile != "" {
+			private, err := readPrivateKey(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey = private
+			k.verifyKey = private.Public()
+		}
+		if cfg.PublicKeyFile != "" {
+			public, err := readPublicKey(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+		if k.verifyKey == nil {
+			return nil, fmt.Errorf("%w: %s key needs a private or public key file", ErrInvalidKeyConfig, cfg.Algorithm)
+		}
+
+		if err := checkKeyType(cfg.Algorithm, k.verifyKey); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, cfg.Algorithm)
+	}
+
+	return k, nil
+}
+
+func readPEM(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: %s is not PEM encoded", ErrInvalidKeyConfig, path)
+	}
+
+	return block.Bytes, nil
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported private key type", ErrInvalidKeyConfig)
+		}
+		return signer, nil
+	}
+
+	rsaKey, err := x509.ParsePKCS1PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse private key: %v", ErrInvalidKeyConfig, err)
+	}
+
+	return rsaKey, nil
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return parsed, nil
+	}
+
+	rsaKey, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse public key: %v", ErrInvalidKeyConfig, err)
+	}
+
+	return rsaKey, nil
+}
+
+func checkKeyType(algorithm string, public crypto.PublicKey) error {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		if algorithm == "RS256" {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if algorithm == "EdDSA" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: key type does not match %s", ErrInvalidKeyConfig, algorithm)
+}
+
+func (i *Issuer) Sign(claims jwt.MapClaims) (string, error) {
+	if i.name != "" {
+		claims["iss"] = i.name
+	}
+
+	token := jwt.NewWithClaims(i.active.method, claims)
+	token.Header["kid"] = i.active.id
+
+	return token.SignedString(i.active.signKey)
+}
+
+func (i *Issuer) Parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		k, ok := i.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+
+		// the key decides the algorithm, never the token header
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+
+		return k.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if i.name != "" && !claims.VerifyIssuer(i.name, true) {
+		return nil, fmt.Errorf("invalid token issuer")
+	}
+
+	return claims, nil
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (i *Issuer) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(i.keys))}
+
+	for _, k := range i.keys {
+		switch public := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Kid: k.id,
+				Alg: k.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Kid: k.id,
+				Alg: k.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(a, b int) bool {
+		return set.Keys[a].Kid < set.Keys[b].Kid
+	})
+
+	return set
+}
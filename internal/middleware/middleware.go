@@ -1,42 +1,90 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"vr-shope/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
-var secretKey = []byte("sfbwm37c7gd7c")
+type TokenParser interface {
+	Parse(tokenString string) (jwt.MapClaims, error)
+}
 
-func ValidateToken(tokenString string) (int, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
-		}
-		return secretKey, nil
-	})
+type RevocationList interface {
+	IsRevoked(ctx context.Context, userID uuid.UUID, tokenID string, issuedAt time.Time) (bool, error)
+}
+
+type Claims struct {
+	UserID    uuid.UUID
+	Role      string
+	TokenID   string
+	ExpiresAt time.Time
+}
 
+func ValidateToken(ctx context.Context, tokenString string, parser TokenParser, revocations RevocationList) (*Claims, error) {
+	claims, err := parser.Parse(tokenString)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return 0, fmt.Errorf("invalid token")
+	if typ, _ := claims["typ"].(string); typ != models.TokenTypeAccess {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	userID, ok := claims["user_id"].(float64)
+	subject, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	exp, ok := claims["exp"].(float64)
 	if !ok {
-		return 0, fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	iat, _ := claims["iat"].(float64)
+
+	revoked, err := revocations.IsRevoked(ctx, userID, tokenID, time.Unix(int64(iat), 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, fmt.Errorf("token revoked")
+	}
+
+	role, _ := claims["role"].(string)
+	if role == "" {
+		role = models.RoleCustomer
 	}
 
-	return int(userID), nil
+	return &Claims{
+		UserID:    userID,
+		Role:      role,
+		TokenID:   tokenID,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*models.APIKeyPrincipal, error)
+}
+
+func AuthMiddleware(parser TokenParser, revocations RevocationList) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 
@@ -48,14 +96,121 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenString = tokenString[len("Bearer "):]
 
-		userID, err := ValidateToken(tokenString)
+		claims, err := ValidateToken(c.Request.Context(), tokenString, parser, revocations)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		c.Set("userID", userID)
+		c.Set("userID", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("tokenID", claims.TokenID)
+		c.Set("tokenExpiresAt", claims.ExpiresAt)
+		c.Next()
+	}
+}
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "ApiKey ") {
+		return header[len("ApiKey "):]
+	}
+
+	return ""
+}
+
+// AuthOrAPIKey behaves like AuthMiddleware but also lets personal API keys
+// through when they were granted scope. Routes only accept API keys when they
+// opt in with this middleware, everything else stays JWT only.
+func AuthOrAPIKey(parser TokenParser, revocations RevocationList, keys APIKeyAuthenticator, scope string) gin.HandlerFunc {
+	bearer := AuthMiddleware(parser, revocations)
+
+	return func(c *gin.Context) {
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			bearer(c)
+			return
+		}
+
+		principal, err := keys.Authenticate(c.Request.Context(), key)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			c.Abort()
+			return
+		}
+
+		if !slices.Contains(principal.Scopes, scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("API key lacks the %s scope", scope)})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", principal.UserID)
+		c.Set("role", principal.Role)
+		c.Set("apiKeyID", principal.KeyID)
+		c.Next()
+	}
+}
+
+type LegacyIDResolver interface {
+	Resolve(ctx context.Context, legacyID uint64) (uuid.UUID, error)
+}
+
+// LegacyIDs rewrites numeric path parameters handed out before the API used
+// uuids into the uuid they stand for, so old links keep working.
+func LegacyIDs(resolver LegacyIDResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i, param := range c.Params {
+			legacyID, err := strconv.ParseUint(param.Value, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			id, err := resolver.Resolve(c.Request.Context(), legacyID)
+			if errors.Is(err, models.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve id"})
+				c.Abort()
+				return
+			}
+
+			c.Params[i].Value = id.String()
+			c.Header("Deprecation", "true")
+		}
+
+		c.Next()
+	}
+}
+
+func GetUserID(c *gin.Context) uuid.UUID {
+	id, _ := c.Get("userID")
+	userID, _ := id.(uuid.UUID)
+	return userID
+}
+
+func GetActor(c *gin.Context) models.Actor {
+	return models.Actor{
+		UserID: GetUserID(c),
+		Role:   c.GetString("role"),
+	}
+}
+
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString("role")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
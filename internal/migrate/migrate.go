@@ -0,0 +1,60 @@
+// Package migrate applies the schema migrations embedded in the binary.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	pgmigrations "vr-shope/db/migrations"
+	sqlitemigrations "vr-shope/db/sqlite/migrations"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Source describes the migrations of one database backend.
+type Source struct {
+	Dialect string
+	FS      fs.FS
+	// Dir is where create writes new migrations, relative to the repository
+	// root. The binary never reads from it.
+	Dir string
+}
+
+var (
+	Postgres = Source{Dialect: "postgres", FS: pgmigrations.FS, Dir: "db/migrations"}
+	SQLite   = Source{Dialect: "sqlite3", FS: sqlitemigrations.FS, Dir: "db/sqlite/migrations"}
+)
+
+func Up(ctx context.Context, db *sql.DB, src Source) error {
+	return Run(ctx, db, src, "up")
+}
+
+// Run executes one of up, down, status, redo or create. Only create works
+// without a database, db may be nil for it.
+func Run(ctx context.Context, db *sql.DB, src Source, command string, args ...string) error {
+	if err := goose.SetDialect(src.Dialect); err != nil {
+		return err
+	}
+
+	switch command {
+	case "up", "down", "status", "redo":
+		if len(args) > 0 {
+			return fmt.Errorf("%s takes no arguments", command)
+		}
+
+		goose.SetBaseFS(src.FS)
+		defer goose.SetBaseFS(nil)
+
+		return goose.RunContext(ctx, command, db, ".")
+	case "create":
+		if len(args) != 1 {
+			return errors.New("create takes the name of the migration")
+		}
+
+		return goose.Create(nil, src.Dir, args[0], "sql")
+	default:
+		return fmt.Errorf("unknown migrate command: %s", command)
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeProductsRead   = "products:read"
+	ScopeProductsWrite  = "products:write"
+	ScopePurchasesRead  = "purchases:read"
+	ScopePurchasesWrite = "purchases:write"
+	ScopeOrdersRead     = "orders:read"
+	ScopeOrdersWrite    = "orders:write"
+)
+
+var APIKeyScopes = []string{
+	ScopeProductsRead,
+	ScopeProductsWrite,
+	ScopePurchasesRead,
+	ScopePurchasesWrite,
+	ScopeOrdersRead,
+	ScopeOrdersWrite,
+}
+
+type APIKey struct {
+	ID         string
+	Name       string
+	Prefix     string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+type APIKeyPrincipal struct {
+	KeyID  string
+	UserID uuid.UUID
+	Role   string
+	Scopes []string
+}
+
+type APIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyCreatedResponse struct {
+	Message string         `json:"message"`
+	Key     string         `json:"key"`
+	APIKey  APIKeyResponse `json:"api_key"`
+}
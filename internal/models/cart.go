@@ -0,0 +1,35 @@
+package models
+
+import (
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+type CartItem struct {
+	ID          uuid.UUID    `json:"id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	ProductID   uuid.UUID    `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Cost        money.Amount `json:"cost"`
+	Quantity    int          `json:"quantity"`
+}
+
+type CartItemRequest struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+type CartItemResponse struct {
+	ID          uuid.UUID    `json:"id"`
+	ProductID   uuid.UUID    `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Cost        money.Amount `json:"cost"`
+	Quantity    int          `json:"quantity"`
+}
+
+type CartResponse struct {
+	Message string             `json:"message"`
+	Items   []CartItemResponse `json:"items"`
+	Total   money.Amount       `json:"total"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "github.com/google/uuid"
+
+// Category is a node of the catalog tree. Children is only filled in when a
+// whole tree or subtree is requested.
+type Category struct {
+	ID       uuid.UUID   `json:"id"`
+	ParentID *uuid.UUID  `json:"parent_id"`
+	Name     string      `json:"name"`
+	Slug     string      `json:"slug"`
+	Position int         `json:"position"`
+	Children []*Category `json:"children"`
+}
+
+type CategoryRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+	Name     string     `json:"name"`
+	// Slug is derived from Name when empty on create and kept as it is when
+	// empty on update, so renaming a category doesn't break its links.
+	Slug     string `json:"slug"`
+	Position int    `json:"position"`
+}
+
+type CategoryResponse struct {
+	Message  string             `json:"message,omitempty"`
+	ID       uuid.UUID          `json:"id"`
+	ParentID *uuid.UUID         `json:"parent_id"`
+	Name     string             `json:"name"`
+	Slug     string             `json:"slug"`
+	Position int                `json:"position"`
+	Children []CategoryResponse `json:"children,omitempty"`
+}
+
+type ProductCategoriesRequest struct {
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+}
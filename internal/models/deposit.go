@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+type Deposit struct {
+	ID          uuid.UUID    `json:"id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	Amount      money.Amount `json:"amount"`
+	Status      string       `json:"status"`
+	Provider    string       `json:"provider"`
+	Reference   string       `json:"reference"`
+	PayAddress  string       `json:"pay_address"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ConfirmedAt *time.Time   `json:"confirmed_at"`
+	SettledBy   *uuid.UUID   `json:"settled_by,omitempty"`
+}
+
+type DepositRequest struct {
+	Amount money.Amount `json:"amount"`
+}
+
+type DepositResponse struct {
+	Message     string       `json:"message"`
+	ID          uuid.UUID    `json:"id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	Amount      money.Amount `json:"amount"`
+	Status      string       `json:"status"`
+	Provider    string       `json:"provider"`
+	Reference   string       `json:"reference"`
+	PayAddress  string       `json:"pay_address"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ConfirmedAt *time.Time   `json:"confirmed_at"`
+	SettledBy   *uuid.UUID   `json:"settled_by,omitempty"`
+}
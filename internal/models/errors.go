@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidQuantity = errors.New("quantity must be greater than zero")
+	ErrCartEmpty       = errors.New("cart is empty")
+	ErrCartChanged     = errors.New("cart was modified during checkout")
+
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrOutOfStock        = errors.New("product out of stock")
+	ErrEmailNotVerified  = errors.New("email address must be verified before purchasing")
+)
+
+var (
+	ErrInvalidPagination = errors.New("invalid pagination parameters")
+	ErrInvalidAmount     = errors.New("amount must be greater than zero")
+)
+
+var (
+	ErrDepositNotPending    = errors.New("deposit is not pending")
+	ErrSettlementNotAllowed = errors.New("deposits of this provider can not be settled by hand")
+)
+
+var (
+	ErrInvalidStatus     = errors.New("invalid status")
+	ErrInvalidTransition = errors.New("status transition is not allowed")
+	ErrConcurrentUpdate  = errors.New("resource was modified concurrently")
+)
+
+var (
+	ErrReturnQuantity     = errors.New("return quantity exceeds the quantity left to return")
+	ErrRefundAmount       = errors.New("refund amount exceeds the amount left to refund")
+	ErrNotReturnable      = errors.New("purchase can not be returned")
+	ErrReturnResolved     = errors.New("return request is already resolved")
+	ErrInvalidReturnInput = errors.New("invalid return request")
+)
+
+var (
+	ErrWarrantyExpired = errors.New("warranty coverage has expired")
+	ErrNotCovered      = errors.New("purchase is not covered by warranty")
+	ErrClaimOpen       = errors.New("purchase already has an open warranty claim")
+	ErrInvalidClaim    = errors.New("invalid warranty claim")
+)
+
+var (
+	ErrForbidden   = errors.New("forbidden")
+	ErrInvalidRole = errors.New("invalid role")
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid login or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidPassword    = errors.New("password is required")
+	ErrInvalidEmail       = errors.New("invalid email")
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTOTPCode      = errors.New("invalid authentication code")
+)
+
+var (
+	ErrInvalidScope  = errors.New("invalid api key scope")
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrAPIKeyName    = errors.New("api key name is required")
+)
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// LockedError is returned while a login or client address is throttled and
+// tells the caller when to try again.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, try again after %s", ErrTooManyAttempts, e.Until.Format(time.RFC3339))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+var (
+	ErrVerificationExpired = errors.New("verification link has expired")
+	ErrVerificationNotSent = errors.New("verification email could not be sent")
+)
+
+var (
+	ErrSKUTaken       = errors.New("sku is already used by another product")
+	ErrInvalidProduct = errors.New("invalid product")
+)
+
+var (
+	ErrInvalidCategory     = errors.New("invalid category")
+	ErrCategorySlugTaken   = errors.New("category slug is already used by another category")
+	ErrCategoryHasChildren = errors.New("category still has subcategories")
+	ErrCategoryCycle       = errors.New("category can not be moved below itself")
+)
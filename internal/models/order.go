@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OrderCreated   = "created"
+	OrderPaid      = "paid"
+	OrderFulfilled = "fulfilled"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+	OrderRefunded  = "refunded"
+)
+
+type Order struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	Status    string             `json:"status"`
+	Total     money.Amount       `json:"total"`
+	Date      time.Time          `json:"date"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Purchases []*Purchase        `json:"purchases"`
+	History   []*OrderTransition `json:"history"`
+}
+
+type OrderTransition struct {
+	From    string     `json:"from"`
+	To      string     `json:"to"`
+	ActorID *uuid.UUID `json:"actor_id"`
+	Note    string     `json:"note"`
+	Date    time.Time  `json:"date"`
+}
+
+type OrderTransitionRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type OrderTransitionResponse struct {
+	From    string     `json:"from"`
+	To      string     `json:"to"`
+	ActorID *uuid.UUID `json:"actor_id"`
+	Note    string     `json:"note"`
+	Date    time.Time  `json:"date"`
+}
+
+type OrderResponse struct {
+	Message   string                    `json:"message"`
+	ID        uuid.UUID                 `json:"id"`
+	UserID    uuid.UUID                 `json:"user_id"`
+	Status    string                    `json:"status"`
+	Total     money.Amount              `json:"total"`
+	Date      time.Time                 `json:"date"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+	Purchases []PurchaseResponse        `json:"purchases"`
+	History   []OrderTransitionResponse `json:"history,omitempty"`
+}
@@ -1,33 +1,79 @@
 package models
 
-import "time"
+import (
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
 
 type Product struct {
-	ID            uint64    `json:"id"`
-	Name          string    `json:"name"`
-	Cost          float64   `json:"cost"`
-	QuantityStock int       `json:"quantity_stock"`
-	Guarantees    time.Time `json:"guarantees"`
-	Country       string    `json:"country"`
-	Like          int       `json:"like"`
+	ID            uuid.UUID    `json:"id"`
+	SKU           string       `json:"sku"`
+	Name          string       `json:"name"`
+	Cost          money.Amount `json:"cost"`
+	QuantityStock int          `json:"quantity_stock"`
+	WarrantyDays  int          `json:"warranty_days"`
+	Country       string       `json:"country"`
+	Like          int          `json:"like"`
 }
 
 type ProductRequest struct {
-	Name          string    `json:"name"`
-	Cost          float64   `json:"cost"`
-	QuantityStock int       `json:"quantity_stock"`
-	Guarantees    time.Time `json:"guarantees"`
-	Country       string    `json:"country"`
-	Like          int       `json:"like"`
+	SKU           string       `json:"sku"`
+	Name          string       `json:"name"`
+	Cost          money.Amount `json:"cost"`
+	QuantityStock int          `json:"quantity_stock"`
+	WarrantyDays  int          `json:"warranty_days"`
+	Country       string       `json:"country"`
+	Like          int          `json:"like"`
 }
 
 type ProductResponse struct {
-	Message       string    `json:"message"`
-	ID            uint64    `json:"id"`
-	Name          string    `json:"name"`
-	Cost          float64   `json:"cost"`
-	QuantityStock int       `json:"quantity_stock"`
-	Guarantees    time.Time `json:"guarantees"`
-	Country       string    `json:"country"`
-	Like          int       `json:"like"`
+	Message       string       `json:"message"`
+	ID            uuid.UUID    `json:"id"`
+	SKU           string       `json:"sku"`
+	Name          string       `json:"name"`
+	Cost          money.Amount `json:"cost"`
+	QuantityStock int          `json:"quantity_stock"`
+	WarrantyDays  int          `json:"warranty_days"`
+	Country       string       `json:"country"`
+	Like          int          `json:"like"`
+}
+
+// StockUpdate sets the stock of a product to Quantity, or changes it by
+// Quantity when Relative is set.
+type StockUpdate struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	Relative  bool      `json:"relative"`
+}
+
+// ProductImportRow is one row of a catalog file. Err is set when the row could
+// not be decoded, Line points at it in the file.
+type ProductImportRow struct {
+	Line    int
+	Product ProductRequest
+	Err     error
+}
+
+type ImportOptions struct {
+	DryRun bool
+	// Upsert updates the product that already has the SKU of a row instead
+	// of rejecting the row.
+	Upsert bool
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportReport tells what an import wrote, or would have written on a dry run.
+// Nothing is written while Errors is not empty.
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Errors  []ImportRowError `json:"errors"`
 }
@@ -1,27 +1,36 @@
 package models
 
-import "time"
+import (
+	"time"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
 
 type Purchase struct {
-	ID         uint64    `json:"id"`
-	UserID     uint64    `json:"user_id"`
-	ProductID  uint64    `json:"product_id"`
-	Date       time.Time `json:"date"`
-	WalletUSDT float32   `json:"wallet_usdt"`
-	Cost       float32   `json:"cost"`
+	ID         uuid.UUID    `json:"id"`
+	OrderID    uuid.UUID    `json:"order_id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	ProductID  uuid.UUID    `json:"product_id"`
+	Quantity   int          `json:"quantity"`
+	Date       time.Time    `json:"date"`
+	WalletUSDT money.Amount `json:"wallet_usdt"`
+	Cost       money.Amount `json:"cost"`
 }
 
 type PurchaseRequest struct {
-	UserID    int `json:"user_id"`
-	ProductID int `json:"product_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
 }
 
 type PurchaseResponse struct {
-	Message    string    `json:"message"`
-	ID         uint64    `json:"id"`
-	UserID     uint64    `json:"user_id"`
-	ProductID  uint64    `json:"product_id"`
-	Date       time.Time `json:"date"`
-	WalletUSDT float32   `json:"wallet_usdt"`
-	Cost       float32   `json:"cost"`
+	Message    string       `json:"message"`
+	ID         uuid.UUID    `json:"id"`
+	OrderID    uuid.UUID    `json:"order_id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	ProductID  uuid.UUID    `json:"product_id"`
+	Quantity   int          `json:"quantity"`
+	Date       time.Time    `json:"date"`
+	WalletUSDT money.Amount `json:"wallet_usdt"`
+	Cost       money.Amount `json:"cost"`
 }
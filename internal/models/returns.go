@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+)
+
+type Return struct {
+	ID           uuid.UUID      `json:"id"`
+	PurchaseID   uuid.UUID      `json:"purchase_id"`
+	UserID       uuid.UUID      `json:"user_id"`
+	Quantity     int            `json:"quantity"`
+	Reason       string         `json:"reason"`
+	Status       string         `json:"status"`
+	RefundAmount money.Amount   `json:"refund_amount"`
+	Restocked    bool           `json:"restocked"`
+	ResolvedBy   *uuid.UUID     `json:"resolved_by"`
+	ResolvedAt   *time.Time     `json:"resolved_at"`
+	Date         time.Time      `json:"date"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Events       []*ReturnEvent `json:"events"`
+}
+
+type ReturnEvent struct {
+	ActorID *uuid.UUID `json:"actor_id"`
+	Action  string     `json:"action"`
+	Note    string     `json:"note"`
+	Date    time.Time  `json:"date"`
+}
+
+type ReturnRequest struct {
+	PurchaseID uuid.UUID `json:"purchase_id"`
+	Quantity   int       `json:"quantity"`
+	Reason     string    `json:"reason"`
+}
+
+type ReturnApproveRequest struct {
+	RefundAmount *money.Amount `json:"refund_amount"`
+	Restock      *bool         `json:"restock"`
+	Note         string        `json:"note"`
+}
+
+type ReturnRejectRequest struct {
+	Note string `json:"note"`
+}
+
+type ReturnEventResponse struct {
+	ActorID *uuid.UUID `json:"actor_id"`
+	Action  string     `json:"action"`
+	Note    string     `json:"note"`
+	Date    time.Time  `json:"date"`
+}
+
+type ReturnResponse struct {
+	Message      string                `json:"message"`
+	ID           uuid.UUID             `json:"id"`
+	PurchaseID   uuid.UUID             `json:"purchase_id"`
+	UserID       uuid.UUID             `json:"user_id"`
+	Quantity     int                   `json:"quantity"`
+	Reason       string                `json:"reason"`
+	Status       string                `json:"status"`
+	RefundAmount money.Amount          `json:"refund_amount"`
+	Restocked    bool                  `json:"restocked"`
+	ResolvedBy   *uuid.UUID            `json:"resolved_by,omitempty"`
+	ResolvedAt   *time.Time            `json:"resolved_at,omitempty"`
+	Date         time.Time             `json:"date"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	Events       []ReturnEventResponse `json:"events,omitempty"`
+}
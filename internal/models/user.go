@@ -1,39 +1,66 @@
 package models
 
-import "time"
+import (
+	"time"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleManager  = "manager"
+	RoleAdmin    = "admin"
+)
+
+type Actor struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+func (a Actor) CanAccess(ownerID uuid.UUID) bool {
+	return a.Role == RoleAdmin || a.UserID == ownerID
+}
 
 type User struct {
-	ID              uint64  `json:"id"`
-	Login           string  `json:"login"`
-	Name            string  `json:"name"`
-	LastName        string  `json:"lastName"`
-	PhoneNumber     string  `json:"phoneNumber"`
-	Password        string  `json:"password"`
-	Email           string  `json:"email"`
-	WalletUSDT      float64 `json:"wallet_usdt"`
-	NumberPurchases int     `json:"number_purchases"`
+	ID              uuid.UUID    `json:"id"`
+	Login           string       `json:"login"`
+	Name            string       `json:"name"`
+	LastName        string       `json:"lastName"`
+	PhoneNumber     string       `json:"phoneNumber"`
+	Password        string       `json:"password"`
+	Email           string       `json:"email"`
+	WalletUSDT      money.Amount `json:"wallet_usdt"`
+	NumberPurchases int          `json:"number_purchases"`
+	Role            string       `json:"role"`
+	EmailVerified   bool         `json:"email_verified"`
 }
 
 type UserRequest struct {
-	Login       string  `json:"login"`
-	Name        string  `json:"name"`
-	LastName    string  `json:"lastName"`
-	PhoneNumber string  `json:"phoneNumber"`
-	Password    string  `json:"password"`
-	Email       string  `json:"email"`
-	WalletUSDT  float64 `json:"wallet_usdt"`
+	Login       string `json:"login"`
+	Name        string `json:"name"`
+	LastName    string `json:"lastName"`
+	PhoneNumber string `json:"phoneNumber"`
+	Password    string `json:"password"`
+	Email       string `json:"email"`
 }
 
 type UserResponse struct {
-	Message         string    `json:"message"`
-	ID              uint64    `json:"id"`
-	Login           string    `json:"login"`
-	Name            string    `json:"name"`
-	LastName        string    `json:"lastName"`
-	PhoneNumber     string    `json:"phoneNumber"`
-	Password        string    `json:"password"`
-	Email           string    `json:"email"`
-	CreatedAt       time.Time `json:"createdAt"`
-	WalletUSDT      float64   `json:"wallet_usdt"`
-	NumberPurchases int       `json:"number_purchases"`
+	Message         string       `json:"message"`
+	ID              uuid.UUID    `json:"id"`
+	Login           string       `json:"login"`
+	Name            string       `json:"name"`
+	LastName        string       `json:"lastName"`
+	PhoneNumber     string       `json:"phoneNumber"`
+	Password        string       `json:"password"`
+	Email           string       `json:"email"`
+	CreatedAt       time.Time    `json:"createdAt"`
+	WalletUSDT      money.Amount `json:"wallet_usdt"`
+	NumberPurchases int          `json:"number_purchases"`
+	Role            string       `json:"role"`
+	EmailVerified   bool         `json:"email_verified"`
+}
+
+type RoleRequest struct {
+	Role string `json:"role"`
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+type WalletTransaction struct {
+	ID            int64        `json:"id"`
+	TransactionID uuid.UUID    `json:"transaction_id"`
+	Kind          string       `json:"kind"`
+	ReferenceID   *uuid.UUID   `json:"reference_id"`
+	Description   string       `json:"description"`
+	Amount        money.Amount `json:"amount"`
+	Balance       money.Amount `json:"balance"`
+	Date          time.Time    `json:"date"`
+}
+
+type WalletBalance struct {
+	UserID        uuid.UUID    `json:"user_id"`
+	Balance       money.Amount `json:"balance"`
+	LedgerBalance money.Amount `json:"ledger_balance"`
+	Reconciled    bool         `json:"reconciled"`
+}
+
+type WalletBalanceResponse struct {
+	Message       string       `json:"message"`
+	UserID        uuid.UUID    `json:"user_id"`
+	Balance       money.Amount `json:"balance"`
+	LedgerBalance money.Amount `json:"ledger_balance"`
+	Reconciled    bool         `json:"reconciled"`
+}
+
+type WalletTransactionResponse struct {
+	ID            int64        `json:"id"`
+	TransactionID uuid.UUID    `json:"transaction_id"`
+	Kind          string       `json:"kind"`
+	ReferenceID   *uuid.UUID   `json:"reference_id"`
+	Description   string       `json:"description"`
+	Amount        money.Amount `json:"amount"`
+	Balance       money.Amount `json:"balance"`
+	Date          time.Time    `json:"date"`
+}
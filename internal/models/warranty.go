@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ClaimSubmitted = "submitted"
+	ClaimInReview  = "in_review"
+	ClaimApproved  = "approved"
+	ClaimRejected  = "rejected"
+	ClaimResolved  = "resolved"
+)
+
+type WarrantyCoverage struct {
+	PurchaseID   uuid.UUID  `json:"purchase_id"`
+	ProductID    *uuid.UUID `json:"product_id"`
+	PurchasedAt  time.Time  `json:"purchased_at"`
+	CoveredUntil *time.Time `json:"covered_until"`
+	Active       bool       `json:"active"`
+}
+
+type WarrantyClaim struct {
+	ID          uuid.UUID          `json:"id"`
+	PurchaseID  uuid.UUID          `json:"purchase_id"`
+	UserID      uuid.UUID          `json:"user_id"`
+	Description string             `json:"description"`
+	Status      string             `json:"status"`
+	Date        time.Time          `json:"date"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	History     []*ClaimTransition `json:"history"`
+}
+
+type ClaimTransition struct {
+	From    string     `json:"from"`
+	To      string     `json:"to"`
+	ActorID *uuid.UUID `json:"actor_id"`
+	Note    string     `json:"note"`
+	Date    time.Time  `json:"date"`
+}
+
+type WarrantyClaimRequest struct {
+	PurchaseID  uuid.UUID `json:"purchase_id"`
+	Description string    `json:"description"`
+}
+
+type ClaimTransitionRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type ClaimTransitionResponse struct {
+	From    string     `json:"from"`
+	To      string     `json:"to"`
+	ActorID *uuid.UUID `json:"actor_id"`
+	Note    string     `json:"note"`
+	Date    time.Time  `json:"date"`
+}
+
+type WarrantyCoverageResponse struct {
+	Message      string     `json:"message"`
+	PurchaseID   uuid.UUID  `json:"purchase_id"`
+	ProductID    *uuid.UUID `json:"product_id"`
+	PurchasedAt  time.Time  `json:"purchased_at"`
+	CoveredUntil *time.Time `json:"covered_until"`
+	Active       bool       `json:"active"`
+}
+
+type WarrantyClaimResponse struct {
+	Message     string                    `json:"message"`
+	ID          uuid.UUID                 `json:"id"`
+	PurchaseID  uuid.UUID                 `json:"purchase_id"`
+	UserID      uuid.UUID                 `json:"user_id"`
+	Description string                    `json:"description"`
+	Status      string                    `json:"status"`
+	Date        time.Time                 `json:"date"`
+	UpdatedAt   time.Time                 `json:"updated_at"`
+	History     []ClaimTransitionResponse `json:"history,omitempty"`
+}
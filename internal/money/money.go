@@ -0,0 +1,117 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	Decimals = 2
+	Scale    = 100
+)
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Amount is a USDT value in minor units (cents). It is marshalled to JSON as a
+// decimal number so the API keeps the shape it had when amounts were floats.
+type Amount int64
+
+// digits reports whether s holds nothing but ASCII digits, so signs, spaces
+// and underscores that strconv would accept are rejected.
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, ErrInvalidAmount
+	}
+	if !digits(whole) || !digits(frac) {
+		return 0, ErrInvalidAmount
+	}
+	if len(frac) > Decimals {
+		return 0, fmt.Errorf("%w: at most %d decimal places", ErrInvalidAmount, Decimals)
+	}
+	frac += strings.Repeat("0", Decimals-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	cents, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	if units > (math.MaxInt64-cents)/Scale {
+		return 0, fmt.Errorf("%w: out of range", ErrInvalidAmount)
+	}
+
+	amount := Amount(units*Scale + cents)
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+func (a Amount) Mul(n int) Amount {
+	return a * Amount(n)
+}
+
+func (a Amount) String() string {
+	sign := ""
+	v := int64(a)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, v/Scale, v%Scale)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
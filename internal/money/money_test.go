@@ -0,0 +1,54 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+		err  bool
+	}{
+		{in: "5", want: 500},
+		{in: "12.34", want: 1234},
+		{in: "0.5", want: 50},
+		{in: ".5", want: 50},
+		{in: "1.", want: 100},
+		{in: "+1.25", want: 125},
+		{in: "-1.25", want: -125},
+		{in: " 7.10 ", want: 710},
+		{in: "92233720368547758.07", want: 9223372036854775807},
+
+		{in: "", err: true},
+		{in: "-", err: true},
+		{in: ".", err: true},
+		{in: "--5", err: true},
+		{in: "+-1", err: true},
+		{in: "-+1", err: true},
+		{in: "1.+5", err: true},
+		{in: "1.-5", err: true},
+		{in: "1.2.3", err: true},
+		{in: "1.234", err: true},
+		{in: "1_000", err: true},
+		{in: "1e3", err: true},
+		{in: "0x10", err: true},
+		{in: "1 000", err: true},
+		{in: "abc", err: true},
+		{in: "92233720368547758.08", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("Parse(%q) = %v, %v, want ErrInvalidAmount", tt.in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vr-shope/internal/money"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusConfirmed Status = "confirmed"
+	StatusFailed    Status = "failed"
+	StatusExpired   Status = "expired"
+)
+
+var (
+	ErrIntentNotFound = errors.New("deposit intent not found")
+	ErrIntentSettled  = errors.New("deposit intent is already settled")
+)
+
+type Intent struct {
+	Reference string
+	Address   string
+	Amount    money.Amount
+	Status    Status
+	ExpiresAt time.Time
+}
+
+// Provider is a USDT payment backend. A deposit starts as an intent that the
+// customer pays into; the provider then reports whether the payment was
+// confirmed, failed or expired before being paid.
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, amount money.Amount) (*Intent, error)
+	GetIntent(ctx context.Context, reference string) (*Intent, error)
+}
+
+// Settler is implemented by providers whose intents staff can settle by hand.
+type Settler interface {
+	Confirm(reference string) error
+	Fail(reference string) error
+}
@@ -0,0 +1,114 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+type simulatedIntent struct {
+	intent    Intent
+	createdAt time.Time
+}
+
+// SimulatedProvider keeps intents in memory and never moves real money, it is
+// meant for development. Pending intents are settled explicitly with Confirm
+// and Fail, or confirmed automatically once autoConfirmAfter has passed when
+// it is non-zero.
+type SimulatedProvider struct {
+	mu               sync.Mutex
+	intents          map[string]*simulatedIntent
+	ttl              time.Duration
+	autoConfirmAfter time.Duration
+	now              func() time.Time
+}
+
+func NewSimulatedProvider(ttl, autoConfirmAfter time.Duration) *SimulatedProvider {
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+
+	return &SimulatedProvider{
+		intents:          make(map[string]*simulatedIntent),
+		ttl:              ttl,
+		autoConfirmAfter: autoConfirmAfter,
+		now:              time.Now,
+	}
+}
+
+func (p *SimulatedProvider) Name() string {
+	return "simulated"
+}
+
+func (p *SimulatedProvider) CreateIntent(ctx context.Context, amount money.Amount) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	reference := "sim_" + uuid.NewString()
+	stored := &simulatedIntent{
+		intent: Intent{
+			Reference: reference,
+			Address:   "sim://deposit/" + reference,
+			Amount:    amount,
+			Status:    StatusPending,
+			ExpiresAt: now.Add(p.ttl),
+		},
+		createdAt: now,
+	}
+	p.intents[reference] = stored
+
+	intent := stored.intent
+	return &intent, nil
+}
+
+func (p *SimulatedProvider) GetIntent(ctx context.Context, reference string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stored, ok := p.intents[reference]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	if stored.intent.Status == StatusPending {
+		now := p.now()
+		switch {
+		case p.autoConfirmAfter > 0 && !now.Before(stored.createdAt.Add(p.autoConfirmAfter)) && now.Before(stored.intent.ExpiresAt):
+			stored.intent.Status = StatusConfirmed
+		case !now.Before(stored.intent.ExpiresAt):
+			stored.intent.Status = StatusExpired
+		}
+	}
+
+	intent := stored.intent
+	return &intent, nil
+}
+
+func (p *SimulatedProvider) Confirm(reference string) error {
+	return p.settle(reference, StatusConfirmed)
+}
+
+func (p *SimulatedProvider) Fail(reference string) error {
+	return p.settle(reference, StatusFailed)
+}
+
+func (p *SimulatedProvider) settle(reference string, status Status) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stored, ok := p.intents[reference]
+	if !ok {
+		return ErrIntentNotFound
+	}
+	if stored.intent.Status != StatusPending {
+		return fmt.Errorf("%w: %s is %s", ErrIntentSettled, reference, stored.intent.Status)
+	}
+
+	stored.intent.Status = status
+	return nil
+}
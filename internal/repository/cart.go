@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrCartChanged = errors.New("cart was modified during checkout")
+
+type CartRepository struct {
+	db *sql.DB
+}
+
+func NewCartStorage(db *sql.DB) (*CartRepository, error) {
+	return &CartRepository{db: db}, nil
+}
+
+func (r *CartRepository) AddItem(ctx context.Context, item *CartItem) error {
+	query := `
+		INSERT INTO cart_items (id, user_id, product_id, quantity, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (user_id, product_id)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = NOW()
+		RETURNING id, quantity`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		item.ID,
+		item.UserID,
+		item.ProductID,
+		item.Quantity,
+	).Scan(&item.ID, &item.Quantity)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *CartRepository) GetItems(ctx context.Context, userID uuid.UUID) ([]*CartItem, error) {
+	query := `
+		SELECT c.id, c.user_id, c.product_id, p.name, p.cost, c.quantity, c.created_at, c.updated_at
+		FROM cart_items c
+		JOIN products p ON p.id = c.product_id
+		WHERE c.user_id = $1
+		ORDER BY c.created_at`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*CartItem
+	for rows.Next() {
+		var item CartItem
+		err := rows.Scan(
+			&item.ID,
+			&item.UserID,
+			&item.ProductID,
+			&item.ProductName,
+			&item.Cost,
+			&item.Quantity,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *CartRepository) UpdateItem(ctx context.Context, item *CartItem) error {
+	query := `
+		UPDATE cart_items
+		SET quantity = $3, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, item.ID, item.UserID, item.Quantity)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *CartRepository) DeleteItem(ctx context.Context, userID, id uuid.UUID) error {
+	query := `DELETE FROM cart_items WHERE id = $1 AND user_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *CartRepository) Checkout(ctx context.Context, order *Order, purchases []*Purchase) error {
+	tx, err := beginTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	order.Total, err = chargePurchases(ctx, tx, order.UserID, order.ID, purchases)
+	if err != nil {
+		return err
+	}
+
+	if err := createPaidOrder(ctx, tx, order); err != nil {
+		return err
+	}
+
+	for _, purchase := range purchases {
+		if err := insertPurchase(ctx, tx, purchase); err != nil {
+			return fmt.Errorf("failed to create purchase: %w", err)
+		}
+	}
+
+	const query = `DELETE FROM cart_items WHERE user_id = $1`
+
+	result, err := tx.ExecContext(ctx, query, order.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if int(n) != len(purchases) {
+		return ErrCartChanged
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategorySlugTaken   = errors.New("category slug already taken")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryStorage(db *sql.DB) (*CategoryRepository, error) {
+	return &CategoryRepository{db: db}, nil
+}
+
+func scanCategory(row interface{ Scan(dest ...any) error }) (*Category, error) {
+	var category Category
+	var parentID uuid.NullUUID
+	err := row.Scan(
+		&category.ID,
+		&parentID,
+		&category.Name,
+		&category.Slug,
+		&category.Position,
+		&category.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	category.ParentID = parentID.UUID
+
+	return &category, nil
+}
+
+func (r *CategoryRepository) list(ctx context.Context, query string, args ...any) ([]*Category, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *Category) error {
+	query := `
+		INSERT INTO categories (id, parent_id, name, slug, position, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		category.ID,
+		uuid.NullUUID{UUID: category.ParentID, Valid: category.ParentID != uuid.Nil},
+		category.Name,
+		category.Slug,
+		category.Position,
+	).Scan(&category.CreatedAt)
+	if isUniqueViolation(err, "categories_slug_key") {
+		return ErrCategorySlugTaken
+	}
+
+	return err
+}
+
+func (r *CategoryRepository) Get(ctx context.Context, id uuid.UUID) (*Category, error) {
+	query := `
+		SELECT id, parent_id, name, slug, position, created_at
+		FROM categories
+		WHERE id = $1`
+
+	category, err := scanCategory(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*Category, error) {
+	query := `
+		SELECT id, parent_id, name, slug, position, created_at
+		FROM categories
+		WHERE slug = $1`
+
+	category, err := scanCategory(conn(ctx, r.db).QueryRowContext(ctx, query, slug))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetAll returns every category in display order, parents are not
+// guaranteed to come before their children.
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]*Category, error) {
+	query := `
+		SELECT id, parent_id, name, slug, position, created_at
+		FROM categories
+		ORDER BY position, name, id`
+
+	return r.list(ctx, query)
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *Category) error {
+	query := `
+		UPDATE categories
+		SET parent_id = $2, name = $3, slug = $4, position = $5
+		WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		category.ID,
+		uuid.NullUUID{UUID: category.ParentID, Valid: category.ParentID != uuid.Nil},
+		category.Name,
+		category.Slug,
+		category.Position,
+	)
+	if isUniqueViolation(err, "categories_slug_key") {
+		return ErrCategorySlugTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a category that has no subcategories. Its products lose the
+// assignment but stay in the catalog.
+func (r *CategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	var hasChildren bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)`, id).Scan(&hasChildren)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return ErrCategoryHasChildren
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetProductCategories replaces the categories of a product with categoryIDs.
+func (r *CategoryRepository) SetProductCategories(ctx context.Context, productID uuid.UUID, categoryIDs []uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_categories WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO product_categories (product_id, category_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	for _, categoryID := range categoryIDs {
+		if _, err := tx.ExecContext(ctx, query, productID, categoryID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CategoryRepository) GetProductCategories(ctx context.Context, productID uuid.UUID) ([]*Category, error) {
+	query := `
+		SELECT c.id, c.parent_id, c.name, c.slug, c.position, c.created_at
+		FROM categories c
+		JOIN product_categories pc ON pc.category_id = c.id
+		WHERE pc.product_id = $1
+		ORDER BY c.position, c.name, c.id`
+
+	return r.list(ctx, query, productID)
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DepositPending   = "pending"
+	DepositConfirmed = "confirmed"
+	DepositFailed    = "failed"
+	DepositExpired   = "expired"
+)
+
+var (
+	ErrDepositNotFound   = errors.New("deposit not found")
+	ErrDepositNotPending = errors.New("deposit is not pending")
+)
+
+type DepositRepository struct {
+	db *sql.DB
+}
+
+func NewDepositStorage(db *sql.DB) (*DepositRepository, error) {
+	return &DepositRepository{db: db}, nil
+}
+
+func scanDeposit(row interface{ Scan(dest ...any) error }) (*Deposit, error) {
+	var deposit Deposit
+	var settledBy uuid.NullUUID
+	err := row.Scan(
+		&deposit.ID,
+		&deposit.UserID,
+		&deposit.Amount,
+		&deposit.Status,
+		&deposit.Provider,
+		&deposit.ProviderRef,
+		&deposit.PayAddress,
+		&deposit.ExpiresAt,
+		&deposit.CreatedAt,
+		&deposit.UpdatedAt,
+		&deposit.ConfirmedAt,
+		&settledBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	deposit.SettledBy = settledBy.UUID
+
+	return &deposit, nil
+}
+
+func (r *DepositRepository) Create(ctx context.Context, deposit *Deposit) error {
+	query := `
+		INSERT INTO deposits (id, user_id, amount, status, provider, provider_ref, pay_address, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		RETURNING created_at, updated_at`
+
+	return conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		deposit.ID,
+		deposit.UserID,
+		deposit.Amount,
+		deposit.Status,
+		deposit.Provider,
+		deposit.ProviderRef,
+		deposit.PayAddress,
+		deposit.ExpiresAt,
+	).Scan(&deposit.CreatedAt, &deposit.UpdatedAt)
+}
+
+func (r *DepositRepository) Get(ctx context.Context, id uuid.UUID) (*Deposit, error) {
+	query := `
+		SELECT id, user_id, amount, status, provider, provider_ref, pay_address, expires_at, created_at, updated_at, confirmed_at, settled_by
+		FROM deposits
+		WHERE id = $1`
+
+	deposit, err := scanDeposit(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDepositNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+func (r *DepositRepository) GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*Deposit, error) {
+	query := `
+		SELECT id, user_id, amount, status, provider, provider_ref, pay_address, expires_at, created_at, updated_at, confirmed_at, settled_by
+		FROM deposits
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		OFFSET $2 LIMIT $3`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deposits []*Deposit
+	for rows.Next() {
+		deposit, err := scanDeposit(rows)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, deposit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deposits, nil
+}
+
+// SetStatus settles a pending deposit without crediting it. settledBy is the
+// staff member doing it by hand, uuid.Nil for the provider.
+func (r *DepositRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, settledBy uuid.UUID) error {
+	query := `
+		UPDATE deposits
+		SET status = $2, settled_by = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, status, uuid.NullUUID{UUID: settledBy, Valid: settledBy != uuid.Nil})
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrDepositNotPending
+	}
+
+	return nil
+}
+
+func (r *DepositRepository) Confirm(ctx context.Context, id uuid.UUID, settledBy uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	const selectQuery = `
+		SELECT id, user_id, amount, status, provider, provider_ref, pay_address, expires_at, created_at, updated_at, confirmed_at, settled_by
+		FROM deposits
+		WHERE id = $1
+		FOR UPDATE`
+
+	deposit, err := scanDeposit(tx.QueryRowContext(ctx, selectQuery, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDepositNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if deposit.Status != DepositPending {
+		return ErrDepositNotPending
+	}
+
+	const updateQuery = `
+		UPDATE deposits
+		SET status = 'confirmed', confirmed_at = NOW(), updated_at = NOW(), settled_by = $2
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, updateQuery, id, uuid.NullUUID{UUID: settledBy, Valid: settledBy != uuid.Nil}); err != nil {
+		return fmt.Errorf("failed to confirm deposit: %w", err)
+	}
+
+	txn := &LedgerTransaction{
+		Kind:        LedgerDeposit,
+		ReferenceID: deposit.ID,
+		Description: fmt.Sprintf("%s deposit %s", deposit.Provider, deposit.ProviderRef),
+		Entries: []*LedgerEntry{
+			{Account: UserAccount(deposit.UserID), UserID: deposit.UserID, Amount: deposit.Amount},
+			{Account: AccountDeposits, Amount: -deposit.Amount},
+		},
+	}
+	if err := postTransaction(ctx, tx, txn); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/uuids"
+
+	"github.com/google/uuid"
+)
+
+var ErrLegacyIDNotFound = errors.New("legacy id not found")
+
+// tables whose ids were exposed as integers before the API switched to uuids
+var legacyTables = []string{
+	"users",
+	"products",
+	"purchases",
+	"orders",
+	"cart_items",
+	"deposits",
+	"return_requests",
+	"warranty_claims",
+	"ledger_transactions",
+}
+
+type LegacyIDRepository struct {
+	db *sql.DB
+}
+
+func NewLegacyIDStorage(db *sql.DB) (*LegacyIDRepository, error) {
+	return &LegacyIDRepository{db: db}, nil
+}
+
+// Backfill records the legacy id of every row that existed before the switch.
+// Each table is only processed once; rows created afterwards never had an
+// integer id and are left out.
+func (r *LegacyIDRepository) Backfill(ctx context.Context) error {
+	for _, table := range legacyTables {
+		if err := r.backfill(ctx, table); err != nil {
+			return fmt.Errorf("failed to backfill legacy ids for %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *LegacyIDRepository) backfill(ctx context.Context, table string) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	const claim = `INSERT INTO legacy_id_backfills (entity) VALUES ($1) ON CONFLICT (entity) DO NOTHING`
+
+	result, err := tx.ExecContext(ctx, claim, table)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT id FROM %s`, table))
+	if err != nil {
+		return err
+	}
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	const insert = `
+		INSERT INTO legacy_ids (legacy_id, entity, id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (legacy_id) DO NOTHING`
+
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, insert, int64(uuids.LegacyID(id)), table, id); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LegacyIDRepository) Resolve(ctx context.Context, legacyID uint64) (uuid.UUID, error) {
+	query := `SELECT id FROM legacy_ids WHERE legacy_id = $1`
+
+	var id uuid.UUID
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, int64(legacyID)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrLegacyIDNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	ThrottleLogin = "login"
+	ThrottleIP    = "ip"
+)
+
+type LockoutRepository struct {
+	db *sql.DB
+}
+
+func NewLockoutStorage(db *sql.DB) (*LockoutRepository, error) {
+	return &LockoutRepository{db: db}, nil
+}
+
+// RecordAttempt counts an attempt before the credentials are checked and
+// returns the attempts in the current window together with any lock already in
+// place. The counter starts over when the previous attempt is older than
+// window. Counting and reading happen in one statement, so concurrent
+// attempts each see their own count.
+func (r *LockoutRepository) RecordAttempt(ctx context.Context, scope, subject string, window time.Duration) (int, time.Time, error) {
+	query := `
+		INSERT INTO login_throttles (scope, subject, failures, last_failure_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, subject) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < NOW() - $3::float8 * INTERVAL '1 second' THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING failures, locked_until`
+
+	var failures int
+	var lockedUntil sql.NullTime
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, scope, subject, window.Seconds()).Scan(&failures, &lockedUntil)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return failures, lockedUntil.Time, nil
+}
+
+// Forgive takes back one counted attempt that turned out to be successful.
+func (r *LockoutRepository) Forgive(ctx context.Context, scope, subject string) error {
+	query := `
+		UPDATE login_throttles
+		SET failures = failures - 1
+		WHERE scope = $1 AND subject = $2 AND failures > 0`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, scope, subject); err != nil {
+		return fmt.Errorf("failed to forgive %s %s: %w", scope, subject, err)
+	}
+
+	return nil
+}
+
+func (r *LockoutRepository) Lock(ctx context.Context, scope, subject string, until time.Time) error {
+	query := `
+		UPDATE login_throttles
+		SET locked_until = GREATEST(locked_until, $3)
+		WHERE scope = $1 AND subject = $2`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, scope, subject, until); err != nil {
+		return fmt.Errorf("failed to lock %s %s: %w", scope, subject, err)
+	}
+
+	return nil
+}
+
+func (r *LockoutRepository) Reset(ctx context.Context, scope, subject string) (bool, error) {
+	query := `DELETE FROM login_throttles WHERE scope = $1 AND subject = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, scope, subject)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *LockoutRepository) GetLocked(ctx context.Context) ([]*LoginThrottle, error) {
+	query := `
+		SELECT scope, subject, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE locked_until > NOW()
+		ORDER BY locked_until DESC`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var throttles []*LoginThrottle
+	for rows.Next() {
+		var throttle LoginThrottle
+		if err := rows.Scan(
+			&throttle.Scope,
+			&throttle.Subject,
+			&throttle.Failures,
+			&throttle.LastFailureAt,
+			&throttle.LockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, &throttle)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return throttles, nil
+}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type CategoryRepository struct {
+	store *Store
+}
+
+func NewCategoryStorage(store *Store) (*CategoryRepository, error) {
+	return &CategoryRepository{store: store}, nil
+}
+
+// subtree returns id and the ids of every category below it. The caller
+// holds the lock.
+func (s *Store) subtree(id uuid.UUID) map[uuid.UUID]bool {
+	tree := make(map[uuid.UUID]bool)
+	if _, ok := s.categories[id]; !ok {
+		return tree
+	}
+
+	tree[id] = true
+	for grown := true; grown; {
+		grown = false
+		for _, category := range s.categories {
+			if !tree[category.ID] && tree[category.ParentID] {
+				tree[category.ID] = true
+				grown = true
+			}
+		}
+	}
+
+	return tree
+}
+
+func (r *CategoryRepository) slugTaken(slug string, id uuid.UUID) bool {
+	for _, category := range r.store.categories {
+		if category.Slug == slug && category.ID != id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortCategories puts categories in the display order of the SQL backends.
+func sortCategories(categories []*repository.Category) {
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID.String() < b.ID.String()
+	})
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *repository.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.slugTaken(category.Slug, category.ID) {
+		return repository.ErrCategorySlugTaken
+	}
+
+	category.CreatedAt = time.Now()
+	stored := *category
+	r.store.categories[category.ID] = &stored
+
+	return nil
+}
+
+func (r *CategoryRepository) Get(ctx context.Context, id uuid.UUID) (*repository.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.categories[id]
+	if !ok {
+		return nil, repository.ErrCategoryNotFound
+	}
+
+	category := *stored
+	return &category, nil
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*repository.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, stored := range r.store.categories {
+		if stored.Slug == slug {
+			category := *stored
+			return &category, nil
+		}
+	}
+
+	return nil, repository.ErrCategoryNotFound
+}
+
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]*repository.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var categories []*repository.Category
+	for _, stored := range r.store.categories {
+		category := *stored
+		categories = append(categories, &category)
+	}
+	sortCategories(categories)
+
+	return categories, nil
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *repository.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.categories[category.ID]
+	if !ok {
+		return repository.ErrCategoryNotFound
+	}
+	if r.slugTaken(category.Slug, category.ID) {
+		return repository.ErrCategorySlugTaken
+	}
+
+	updated := *category
+	updated.CreatedAt = stored.CreatedAt
+	r.store.categories[category.ID] = &updated
+
+	return nil
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.categories[id]; !ok {
+		return repository.ErrCategoryNotFound
+	}
+	for _, category := range r.store.categories {
+		if category.ParentID == id {
+			return repository.ErrCategoryHasChildren
+		}
+	}
+
+	delete(r.store.categories, id)
+	for _, assigned := range r.store.productCategories {
+		delete(assigned, id)
+	}
+
+	return nil
+}
+
+func (r *CategoryRepository) SetProductCategories(ctx context.Context, productID uuid.UUID, categoryIDs []uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.products[productID]; !ok {
+		return repository.ErrProductNotFound
+	}
+
+	assigned := make(map[uuid.UUID]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if _, ok := r.store.categories[id]; !ok {
+			return repository.ErrCategoryNotFound
+		}
+		assigned[id] = true
+	}
+	r.store.productCategories[productID] = assigned
+
+	return nil
+}
+
+func (r *CategoryRepository) GetProductCategories(ctx context.Context, productID uuid.UUID) ([]*repository.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var categories []*repository.Category
+	for id := range r.store.productCategories[productID] {
+		category := *r.store.categories[id]
+		categories = append(categories, &category)
+	}
+	sortCategories(categories)
+
+	return categories, nil
+}
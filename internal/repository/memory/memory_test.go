@@ -0,0 +1,32 @@
+package memory_test
+
+import (
+	"testing"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository/memory"
+	"vr-shope/internal/repository/repotest"
+
+	"github.com/google/uuid"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repotest.Backend {
+		store := memory.NewStore()
+		users, _ := memory.NewUserStorage(store)
+		products, _ := memory.NewProductStorage(store)
+		categories, _ := memory.NewCategoryStorage(store)
+		purchases, _ := memory.NewPurchaseStorage(store)
+
+		return &repotest.Backend{
+			Users:      users,
+			Products:   products,
+			Categories: categories,
+			Purchases:  purchases,
+			Credit: func(t *testing.T, userID uuid.UUID, amount money.Amount) {
+				if err := store.Credit(userID, amount); err != nil {
+					t.Fatalf("credit: %v", err)
+				}
+			},
+		}
+	})
+}
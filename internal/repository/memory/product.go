@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"context"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type ProductRepository struct {
+	store *Store
+}
+
+func NewProductStorage(store *Store) (*ProductRepository, error) {
+	return &ProductRepository{store: store}, nil
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *repository.Product) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.skuTaken(product.SKU, product.ID) {
+		return repository.ErrSKUTaken
+	}
+
+	stored := *product
+	stored.Like = 0
+	r.store.products[product.ID] = &stored
+
+	return nil
+}
+
+// skuTaken reports whether another product than id uses sku. Products
+// without a SKU never clash.
+func (r *ProductRepository) skuTaken(sku string, id uuid.UUID) bool {
+	if sku == "" {
+		return false
+	}
+	for _, product := range r.store.products {
+		if product.SKU == sku && product.ID != id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *ProductRepository) Get(ctx context.Context, id uuid.UUID) (*repository.Product, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.products[id]
+	if !ok {
+		return nil, repository.ErrProductNotFound
+	}
+
+	product := *stored
+	return &product, nil
+}
+
+func (r *ProductRepository) GetAll(ctx context.Context) ([]*repository.Product, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var products []*repository.Product
+	for _, id := range sortedIDs(r.store.products) {
+		product := *r.store.products[id]
+		products = append(products, &product)
+	}
+
+	return products, nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, product *repository.Product) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.products[product.ID]; !ok {
+		return repository.ErrProductNotFound
+	}
+	if r.skuTaken(product.SKU, product.ID) {
+		return repository.ErrSKUTaken
+	}
+
+	stored := *product
+	r.store.products[product.ID] = &stored
+
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.products[id]; !ok {
+		return repository.ErrProductNotFound
+	}
+	delete(r.store.products, id)
+	delete(r.store.productCategories, id)
+
+	for _, purchase := range r.store.purchases {
+		if purchase.ProductID == id {
+			purchase.ProductID = uuid.Nil
+		}
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) addLikes(id uuid.UUID, delta int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	product, ok := r.store.products[id]
+	if !ok {
+		return repository.ErrProductNotFound
+	}
+	product.Like += delta
+
+	return nil
+}
+
+func (r *ProductRepository) AddLike(ctx context.Context, id uuid.UUID) error {
+	return r.addLikes(id, 1)
+}
+
+func (r *ProductRepository) RemoveLike(ctx context.Context, id uuid.UUID) error {
+	return r.addLikes(id, -1)
+}
+
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*repository.Product, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, stored := range r.store.products {
+		if sku != "" && stored.SKU == sku {
+			product := *stored
+			return &product, nil
+		}
+	}
+
+	return nil, repository.ErrProductNotFound
+}
+
+func (r *ProductRepository) GetForName(ctx context.Context, name string) ([]*repository.Product, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var products []*repository.Product
+	for _, id := range sortedIDs(r.store.products) {
+		if stored := r.store.products[id]; stored.Name == name {
+			product := *stored
+			products = append(products, &product)
+		}
+	}
+
+	return products, nil
+}
+
+func (r *ProductRepository) GetProducts(ctx context.Context, offset, limit int) ([]*repository.Product, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var products []*repository.Product
+	for _, id := range page(sortedIDs(r.store.products), offset, limit) {
+		product := *r.store.products[id]
+		products = append(products, &product)
+	}
+
+	return products, nil
+}
+
+func (r *ProductRepository) GetByCategory(ctx context.Context, categoryID uuid.UUID, offset, limit int) ([]*repository.Product, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	tree := r.store.subtree(categoryID)
+
+	var ids []uuid.UUID
+	for _, id := range sortedIDs(r.store.products) {
+		for assigned := range r.store.productCategories[id] {
+			if tree[assigned] {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+
+	var products []*repository.Product
+	for _, id := range page(ids, offset, limit) {
+		product := *r.store.products[id]
+		products = append(products, &product)
+	}
+
+	return products, nil
+}
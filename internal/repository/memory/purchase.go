@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type PurchaseRepository struct {
+	store *Store
+}
+
+func NewPurchaseStorage(store *Store) (*PurchaseRepository, error) {
+	return &PurchaseRepository{store: store}, nil
+}
+
+func (r *PurchaseRepository) Create(ctx context.Context, purchase *repository.Purchase) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[purchase.UserID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	if !user.EmailVerifiedAt.Valid {
+		return repository.ErrEmailNotVerified
+	}
+
+	product, ok := r.store.products[purchase.ProductID]
+	if !ok {
+		return repository.ErrProductNotFound
+	}
+	if product.QuantityStock < purchase.Quantity {
+		return repository.ErrOutOfStock
+	}
+
+	cost := product.Cost.Mul(purchase.Quantity)
+	if user.WalletUSDT < cost {
+		return repository.ErrInsufficientFunds
+	}
+
+	product.QuantityStock -= purchase.Quantity
+	user.WalletUSDT -= cost
+	user.NumberPurchases++
+
+	purchase.Cost = cost
+	purchase.WalletUSDT = user.WalletUSDT
+	purchase.WarrantyUntil = sql.NullTime{}
+	if product.WarrantyDays > 0 {
+		purchase.WarrantyUntil = sql.NullTime{
+			Time:  purchase.Date.Add(time.Duration(product.WarrantyDays) * 24 * time.Hour),
+			Valid: true,
+		}
+	}
+
+	stored := *purchase
+	r.store.purchases[purchase.ID] = &stored
+
+	return nil
+}
+
+func (r *PurchaseRepository) Get(ctx context.Context, id uuid.UUID) (*repository.Purchase, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.purchases[id]
+	if !ok {
+		return nil, repository.ErrPurchaseNotFound
+	}
+
+	purchase := *stored
+	return &purchase, nil
+}
+
+func (r *PurchaseRepository) GetAll(ctx context.Context) ([]*repository.Purchase, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var purchases []*repository.Purchase
+	for _, id := range sortedIDs(r.store.purchases) {
+		purchase := *r.store.purchases[id]
+		purchases = append(purchases, &purchase)
+	}
+
+	return purchases, nil
+}
+
+func (r *PurchaseRepository) GetRecent(ctx context.Context, limit int) ([]*repository.Purchase, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var purchases []*repository.Purchase
+	for _, id := range sortedIDs(r.store.purchases) {
+		purchase := *r.store.purchases[id]
+		purchases = append(purchases, &purchase)
+	}
+	sort.SliceStable(purchases, func(i, j int) bool {
+		return purchases[i].Date.After(purchases[j].Date)
+	})
+	if limit < len(purchases) {
+		purchases = purchases[:limit]
+	}
+
+	return purchases, nil
+}
+
+func (r *PurchaseRepository) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, ok := r.store.purchases[id]
+	return ok, nil
+}
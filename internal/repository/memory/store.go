@@ -0,0 +1,88 @@
+// Package memory keeps users, products and purchases in process memory. It
+// follows the semantics of the Postgres repositories closely enough to stand
+// in for them in tests and local tooling.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type userRecord struct {
+	repository.User
+	verificationSentAt time.Time
+}
+
+// Store holds the state shared by the repositories of one backend, so a
+// purchase can move stock and wallet balances the way a single database would.
+type Store struct {
+	mu         sync.Mutex
+	users      map[uuid.UUID]*userRecord
+	products   map[uuid.UUID]*repository.Product
+	purchases  map[uuid.UUID]*repository.Purchase
+	categories map[uuid.UUID]*repository.Category
+	// productCategories maps a product to the set of its categories
+	productCategories map[uuid.UUID]map[uuid.UUID]bool
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:             make(map[uuid.UUID]*userRecord),
+		products:          make(map[uuid.UUID]*repository.Product),
+		purchases:         make(map[uuid.UUID]*repository.Purchase),
+		categories:        make(map[uuid.UUID]*repository.Category),
+		productCategories: make(map[uuid.UUID]map[uuid.UUID]bool),
+	}
+}
+
+// Credit adds amount to the cached wallet balance of a user. The in-memory
+// backend has no ledger, deposits land here directly.
+func (s *Store) Credit(userID uuid.UUID, amount money.Amount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.WalletUSDT += amount
+
+	return nil
+}
+
+// WithinTx lets the store stand in for a TxManager. Calls made by fn are not
+// isolated from other callers and nothing is undone when it fails.
+func (s *Store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func sortedIDs[T any](rows map[uuid.UUID]T) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+
+	return ids
+}
+
+func page(ids []uuid.UUID, offset, limit int) []uuid.UUID {
+	if offset >= len(ids) {
+		return nil
+	}
+	ids = ids[offset:]
+	if limit < len(ids) {
+		ids = ids[:limit]
+	}
+
+	return ids
+}
@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type UserStorage struct {
+	store *Store
+}
+
+func NewUserStorage(store *Store) (*UserStorage, error) {
+	return &UserStorage{store: store}, nil
+}
+
+func (s *Store) emailTaken(email string, except uuid.UUID) bool {
+	for id, user := range s.users {
+		if id != except && user.Email == email {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *UserStorage) Create(ctx context.Context, user *repository.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.emailTaken(user.Email, uuid.Nil) {
+		return repository.ErrEmailTaken
+	}
+
+	record := &userRecord{User: *user}
+	record.WalletUSDT = 0
+	record.NumberPurchases = 0
+	record.EmailVerifiedAt = sql.NullTime{}
+	record.TOTPEnabledAt = sql.NullTime{}
+	r.store.users[user.ID] = record
+
+	return nil
+}
+
+func (r *UserStorage) GetByID(ctx context.Context, id uuid.UUID) (*repository.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+
+	user := record.User
+	return &user, nil
+}
+
+func (r *UserStorage) GetAll(ctx context.Context) ([]*repository.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var users []*repository.User
+	for _, id := range sortedIDs(r.store.users) {
+		user := r.store.users[id].User
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
+func (r *UserStorage) Update(ctx context.Context, user *repository.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[user.ID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+
+	if r.store.emailTaken(user.Email, user.ID) {
+		return repository.ErrEmailTaken
+	}
+
+	if record.Email != user.Email {
+		record.EmailVerifiedAt = sql.NullTime{}
+	}
+	record.Login = user.Login
+	record.Name = user.Name
+	record.LastName = user.LastName
+	record.PhoneNumber = user.PhoneNumber
+	record.Email = user.Email
+
+	return nil
+}
+
+func (r *UserStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[id]; !ok {
+		return repository.ErrUserNotFound
+	}
+	delete(r.store.users, id)
+
+	for _, purchase := range r.store.purchases {
+		if purchase.UserID == id {
+			delete(r.store.purchases, purchase.ID)
+		}
+	}
+
+	return nil
+}
+
+func (r *UserStorage) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.emailTaken(email, uuid.Nil), nil
+}
+
+func (r *UserStorage) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, ok := r.store.users[id]
+	return ok, nil
+}
+
+func (r *UserStorage) GetByEmail(ctx context.Context, email string) (*repository.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, record := range r.store.users {
+		if record.Email == email {
+			user := record.User
+			return &user, nil
+		}
+	}
+
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *UserStorage) GetUserByLogin(ctx context.Context, login string) (*repository.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, id := range sortedIDs(r.store.users) {
+		if record := r.store.users[id]; record.Login == login {
+			user := record.User
+			return &user, nil
+		}
+	}
+
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *UserStorage) GetUsers(ctx context.Context, offset, limit int) ([]*repository.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var users []*repository.User
+	for _, id := range page(sortedIDs(r.store.users), offset, limit) {
+		record := r.store.users[id]
+		users = append(users, &repository.User{
+			ID:    record.ID,
+			Login: record.Login,
+			Email: record.Email,
+			Role:  record.Role,
+		})
+	}
+
+	return users, nil
+}
+
+func (r *UserStorage) SetRole(ctx context.Context, id uuid.UUID, role string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	record.Role = role
+
+	return nil
+}
+
+func (r *UserStorage) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword, salt string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	record.Password = hashedPassword
+	record.Salt = salt
+
+	return nil
+}
+
+func (r *UserStorage) MarkVerificationSent(ctx context.Context, id uuid.UUID, notBefore time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok || record.EmailVerifiedAt.Valid {
+		return false, nil
+	}
+	if !record.verificationSentAt.IsZero() && !record.verificationSentAt.Before(notBefore) {
+		return false, nil
+	}
+	record.verificationSentAt = time.Now()
+
+	return true, nil
+}
+
+func (r *UserStorage) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok || record.Email != email {
+		return repository.ErrUserNotFound
+	}
+	if !record.EmailVerifiedAt.Valid {
+		record.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	return nil
+}
@@ -1,40 +1,229 @@
 package repository
 
 import (
+	"database/sql"
 	"time"
+	"vr-shope/internal/money"
 
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID              uuid.UUID `json:"id"`
-	Login           string    `json:"login"`
-	Name            string    `json:"name"`
-	LastName        string    `json:"lastName"`
-	PhoneNumber     string    `json:"phoneNumber"`
-	Password        string    `json:"password"`
-	Email           string    `json:"email"`
-	CreatedAt       time.Time `json:"created_at"`
-	WalletUSDT      float64   `json:"wallet_usdt"`
-	NumberPurchases int       `json:"number_purchases"`
-	Salt            string    `json:"salt"`
+	ID              uuid.UUID    `json:"id"`
+	Login           string       `json:"login"`
+	Name            string       `json:"name"`
+	LastName        string       `json:"lastName"`
+	PhoneNumber     string       `json:"phoneNumber"`
+	Password        string       `json:"password"`
+	Email           string       `json:"email"`
+	CreatedAt       time.Time    `json:"created_at"`
+	WalletUSDT      money.Amount `json:"wallet_usdt"`
+	NumberPurchases int          `json:"number_purchases"`
+	Salt            string       `json:"salt"`
+	Role            string       `json:"role"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+	TOTPEnabledAt   sql.NullTime `json:"totp_enabled_at"`
 }
 
 type Purchase struct {
-	ID         uuid.UUID `json:"id"`
-	UserID     uuid.UUID `json:"user_id"`
-	ProductID  uuid.UUID `json:"product_id"`
-	Date       time.Time `json:"date"`
-	WalletUSDT float32   `json:"wallet_usdt"`
-	Cost       float32   `json:"cost"`
+	ID               uuid.UUID    `json:"id"`
+	OrderID          uuid.UUID    `json:"order_id"`
+	UserID           uuid.UUID    `json:"user_id"`
+	ProductID        uuid.UUID    `json:"product_id"`
+	Quantity         int          `json:"quantity"`
+	Date             time.Time    `json:"date"`
+	WalletUSDT       money.Amount `json:"wallet_usdt"`
+	Cost             money.Amount `json:"cost"`
+	ReturnedQuantity int          `json:"returned_quantity"`
+	RefundedAmount   money.Amount `json:"refunded_amount"`
+	WarrantyUntil    sql.NullTime `json:"warranty_until"`
+}
+
+type Order struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	Status    string       `json:"status"`
+	Total     money.Amount `json:"total"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type OrderTransition struct {
+	ID         int64     `json:"id"`
+	OrderID    uuid.UUID `json:"order_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    uuid.UUID `json:"actor_id"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CartItem struct {
+	ID          uuid.UUID    `json:"id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	ProductID   uuid.UUID    `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Cost        money.Amount `json:"cost"`
+	Quantity    int          `json:"quantity"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
 type Product struct {
-	ID            uuid.UUID `json:"id"`
-	Name          string    `json:"name"`
-	Cost          float64   `json:"cost"`
-	QuantityStock int       `json:"quantity_stock"`
-	Guarantees    time.Time `json:"guarantees"`
-	Country       string    `json:"country"`
-	Like          int       `json:"like"`
+	ID            uuid.UUID    `json:"id"`
+	SKU           string       `json:"sku"`
+	Name          string       `json:"name"`
+	Cost          money.Amount `json:"cost"`
+	QuantityStock int          `json:"quantity_stock"`
+	WarrantyDays  int          `json:"warranty_days"`
+	Country       string       `json:"country"`
+	Like          int          `json:"like"`
+}
+
+// Category is a node of the catalog tree. ParentID is uuid.Nil for top level
+// categories.
+type Category struct {
+	ID        uuid.UUID `json:"id"`
+	ParentID  uuid.UUID `json:"parent_id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LedgerTransaction struct {
+	ID          uuid.UUID      `json:"id"`
+	Kind        string         `json:"kind"`
+	ReferenceID uuid.UUID      `json:"reference_id"`
+	Description string         `json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Entries     []*LedgerEntry `json:"entries"`
+}
+
+type LedgerEntry struct {
+	ID            int64        `json:"id"`
+	TransactionID uuid.UUID    `json:"transaction_id"`
+	Account       string       `json:"account"`
+	UserID        uuid.UUID    `json:"user_id"`
+	Amount        money.Amount `json:"amount"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type WalletEntry struct {
+	LedgerEntry
+	Kind        string       `json:"kind"`
+	ReferenceID uuid.UUID    `json:"reference_id"`
+	Description string       `json:"description"`
+	Balance     money.Amount `json:"balance"`
+}
+
+type Deposit struct {
+	ID          uuid.UUID    `json:"id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	Amount      money.Amount `json:"amount"`
+	Status      string       `json:"status"`
+	Provider    string       `json:"provider"`
+	ProviderRef string       `json:"provider_ref"`
+	PayAddress  string       `json:"pay_address"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	ConfirmedAt sql.NullTime `json:"confirmed_at"`
+	// SettledBy is the staff member who settled the deposit by hand, uuid.Nil
+	// when the provider did
+	SettledBy uuid.UUID `json:"settled_by"`
+}
+
+type ReturnRequest struct {
+	ID           uuid.UUID    `json:"id"`
+	PurchaseID   uuid.UUID    `json:"purchase_id"`
+	UserID       uuid.UUID    `json:"user_id"`
+	Quantity     int          `json:"quantity"`
+	Reason       string       `json:"reason"`
+	Status       string       `json:"status"`
+	RefundAmount money.Amount `json:"refund_amount"`
+	Restocked    bool         `json:"restocked"`
+	ResolvedBy   uuid.UUID    `json:"resolved_by"`
+	ResolvedAt   sql.NullTime `json:"resolved_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+type ReturnEvent struct {
+	ID        int64     `json:"id"`
+	ReturnID  uuid.UUID `json:"return_id"`
+	ActorID   uuid.UUID `json:"actor_id"`
+	Action    string    `json:"action"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WarrantyClaim struct {
+	ID          uuid.UUID `json:"id"`
+	PurchaseID  uuid.UUID `json:"purchase_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ClaimTransition struct {
+	ID         int64     `json:"id"`
+	ClaimID    uuid.UUID `json:"claim_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    uuid.UUID `json:"actor_id"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	FamilyID   uuid.UUID    `json:"family_id"`
+	TokenHash  string       `json:"token_hash"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UsedAt     sql.NullTime `json:"used_at"`
+	ReplacedBy uuid.UUID    `json:"replaced_by"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	CreatedAt time.Time    `json:"created_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type TOTPState struct {
+	UserID    uuid.UUID      `json:"user_id"`
+	Secret    sql.NullString `json:"-"`
+	EnabledAt sql.NullTime   `json:"enabled_at"`
+	LastStep  sql.NullInt64  `json:"last_step"`
+}
+
+type LoginThrottle struct {
+	Scope         string       `json:"scope"`
+	Subject       string       `json:"subject"`
+	Failures      int          `json:"failures"`
+	LastFailureAt time.Time    `json:"last_failure_at"`
+	LockedUntil   sql.NullTime `json:"locked_until"`
+}
+
+type APIKey struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     []string     `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	Role       string       `json:"role"`
 }
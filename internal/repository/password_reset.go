@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrResetTokenNotFound = errors.New("password reset token not found")
+	ErrResetTokenExpired  = errors.New("password reset token expired")
+	ErrResetTokenUsed     = errors.New("password reset token already used")
+)
+
+type PasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetStorage(db *sql.DB) (*PasswordResetRepository, error) {
+	return &PasswordResetRepository{db: db}, nil
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, token *PasswordResetToken) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	// only the most recently requested link stays usable
+	const expireQuery = `UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`
+	if _, err := tx.ExecContext(ctx, expireQuery, token.UserID); err != nil {
+		return fmt.Errorf("failed to expire previous reset tokens: %w", err)
+	}
+
+	const insertQuery = `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING created_at`
+
+	err = tx.QueryRowContext(ctx, insertQuery, token.ID, token.UserID, token.TokenHash, token.ExpiresAt).Scan(&token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PasswordResetRepository) Reset(ctx context.Context, tokenHash, hashedPassword, salt string) error {
+	tx, err := beginTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	const selectQuery = `
+		SELECT id, user_id, token_hash, expires_at, created_at, used_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+		FOR UPDATE`
+
+	var token PasswordResetToken
+	err = tx.QueryRowContext(ctx, selectQuery, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UsedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrResetTokenNotFound
+	} else if err != nil {
+		return err
+	}
+
+	switch {
+	case token.UsedAt.Valid:
+		return ErrResetTokenUsed
+	case time.Now().After(token.ExpiresAt):
+		return ErrResetTokenExpired
+	}
+
+	const useQuery = `UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, useQuery, token.ID); err != nil {
+		return fmt.Errorf("failed to use reset token: %w", err)
+	}
+
+	if err := setPassword(ctx, tx, token.UserID, hashedPassword, salt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetPassword replaces the password of a user without a reset token and, like
+// Reset, signs the user out everywhere.
+func (r *PasswordResetRepository) SetPassword(ctx context.Context, userID uuid.UUID, hashedPassword, salt string) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if err := setPassword(ctx, tx, userID, hashedPassword, salt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func setPassword(ctx context.Context, tx queryer, userID uuid.UUID, hashedPassword, salt string) error {
+	const passwordQuery = `
+		UPDATE users
+		SET hashed_password = $2, salt = $3, sessions_revoked_at = NOW()
+		WHERE id = $1`
+	result, err := tx.ExecContext(ctx, passwordQuery, userID, hashedPassword, salt)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	const revokeQuery = `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, revokeQuery, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+	"vr-shope/internal/migrate"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+	"vr-shope/internal/repository/repotest"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// The contract runs against a real database only when VR_SHOPE_TEST_DSN points
+// at one. Every subtest truncates the tables it touches, never aim it at data
+// you want to keep.
+func TestPostgresContract(t *testing.T) {
+	dsn := os.Getenv("VR_SHOPE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("VR_SHOPE_TEST_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrate.Up(context.Background(), db, migrate.Postgres); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	repotest.Run(t, func(t *testing.T) *repotest.Backend {
+		const truncate = `TRUNCATE users, products, categories, purchases, orders, deposits, ledger_transactions, ledger_entries CASCADE`
+		if _, err := db.Exec(truncate); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+
+		users, _ := repository.NewUserStorage(db)
+		products, _ := repository.NewProductStorage(db)
+		categories, _ := repository.NewCategoryStorage(db)
+		purchases, _ := repository.NewPurchaseStorage(db)
+		deposits, _ := repository.NewDepositStorage(db)
+
+		return &repotest.Backend{
+			Users:      users,
+			Products:   products,
+			Categories: categories,
+			Purchases:  purchases,
+			Credit: func(t *testing.T, userID uuid.UUID, amount money.Amount) {
+				ctx := context.Background()
+				deposit := &repository.Deposit{
+					ID:          uuid.New(),
+					UserID:      userID,
+					Amount:      amount,
+					Status:      repository.DepositPending,
+					Provider:    "test",
+					ProviderRef: uuid.NewString(),
+					ExpiresAt:   time.Now().Add(time.Hour),
+				}
+				if err := deposits.Create(ctx, deposit); err != nil {
+					t.Fatalf("create deposit: %v", err)
+				}
+				if err := deposits.Confirm(ctx, deposit.ID, uuid.Nil); err != nil {
+					t.Fatalf("confirm deposit: %v", err)
+				}
+			},
+		}
+	})
+}
@@ -19,21 +19,25 @@ func NewProductStorage(db *sql.DB) (*ProductRepository, error) {
 
 func (r *ProductRepository) Create(ctx context.Context, product *Product) error {
 	query := `
-		INSERT INTO products (id, name, cost, quantity_stock, guarantees, country)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO products (id, name, cost, quantity_stock, warranty_days, country, sku)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
 		RETURNING id
 	`
 
-	_, err := r.db.ExecContext(
+	_, err := conn(ctx, r.db).ExecContext(
 		ctx,
 		query,
 		product.ID,
 		product.Name,
 		product.Cost,
 		product.QuantityStock,
-		product.Guarantees,
+		product.WarrantyDays,
 		product.Country,
+		product.SKU,
 	)
+	if isUniqueViolation(err, "products_sku_key") {
+		return ErrSKUTaken
+	}
 	if err != nil {
 		return err
 	}
@@ -43,26 +47,27 @@ func (r *ProductRepository) Create(ctx context.Context, product *Product) error
 
 func (r *ProductRepository) Get(ctx context.Context, id uuid.UUID) (*Product, error) {
 	query := `
-		SELECT id, name, cost, quantity_stock, guarantees, country, likes
+		SELECT id, COALESCE(sku, ''), name, cost, quantity_stock, warranty_days, country, likes
 		FROM products
 		WHERE id = $1
 	`
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
 
 	var product Product
 	err := row.Scan(
 		&product.ID,
+		&product.SKU,
 		&product.Name,
 		&product.Cost,
 		&product.QuantityStock,
-		&product.Guarantees,
+		&product.WarrantyDays,
 		&product.Country,
 		&product.Like,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, ErrProductNotFound
 		}
 		return nil, err
 	}
@@ -72,11 +77,11 @@ func (r *ProductRepository) Get(ctx context.Context, id uuid.UUID) (*Product, er
 
 func (r *ProductRepository) GetAll(ctx context.Context) ([]*Product, error) {
 	query := `
-		SELECT id, name, cost, quantity_stock, guarantees, country, likes
+		SELECT id, COALESCE(sku, ''), name, cost, quantity_stock, warranty_days, country, likes
 		FROM products
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -87,10 +92,11 @@ func (r *ProductRepository) GetAll(ctx context.Context) ([]*Product, error) {
 		var product Product
 		err := rows.Scan(
 			&product.ID,
+			&product.SKU,
 			&product.Name,
 			&product.Cost,
 			&product.QuantityStock,
-			&product.Guarantees,
+			&product.WarrantyDays,
 			&product.Country,
 			&product.Like,
 		)
@@ -104,30 +110,27 @@ func (r *ProductRepository) GetAll(ctx context.Context) ([]*Product, error) {
 }
 
 func (r *ProductRepository) Update(ctx context.Context, product *Product) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	defer tx.Rollback()
-
 	query := `
 		UPDATE products
-		SET name = $2, cost = $3, quantity_stock = $4, guarantees = $5, country = $6, likes = $7
+		SET name = $2, cost = $3, quantity_stock = $4, warranty_days = $5, country = $6, likes = $7, sku = NULLIF($8, '')
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(
+	result, err := conn(ctx, r.db).ExecContext(
 		ctx,
 		query,
 		product.ID,
 		product.Name,
 		product.Cost,
 		product.QuantityStock,
-		product.Guarantees,
+		product.WarrantyDays,
 		product.Country,
 		product.Like,
+		product.SKU,
 	)
+	if isUniqueViolation(err, "products_sku_key") {
+		return ErrSKUTaken
+	}
 	if err != nil {
 		return err
 	}
@@ -138,30 +141,19 @@ func (r *ProductRepository) Update(ctx context.Context, product *Product) error
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no rows were updated")
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return ErrProductNotFound
 	}
 
 	return nil
 }
 
 func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	defer tx.Rollback()
-
 	query := `
 		DELETE FROM products
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -172,18 +164,14 @@ func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no rows were deleted")
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return ErrProductNotFound
 	}
 
 	return nil
 }
 
 func (s *ProductRepository) AddLike(ctx context.Context, id uuid.UUID) error {
-	tx, err := s.db.Begin()
+	tx, err := beginTx(ctx, s.db, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -192,17 +180,17 @@ func (s *ProductRepository) AddLike(ctx context.Context, id uuid.UUID) error {
 
 	const checkQuery = `SELECT 1 FROM products WHERE id = $1`
 	var exists bool
-	err = s.db.QueryRowContext(ctx, checkQuery, id).Scan(&exists)
+	err = tx.QueryRowContext(ctx, checkQuery, id).Scan(&exists)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return sql.ErrNoRows
+			return ErrProductNotFound
 		}
 		return fmt.Errorf("failed to check if products exists: %w", err)
 	}
 
 	const query = `UPDATE products SET likes = likes + 1 WHERE id = $1`
 
-	result, err := s.db.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -214,7 +202,7 @@ func (s *ProductRepository) AddLike(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if n == 0 {
-		return sql.ErrNoRows
+		return ErrProductNotFound
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -225,7 +213,7 @@ func (s *ProductRepository) AddLike(ctx context.Context, id uuid.UUID) error {
 }
 
 func (s *ProductRepository) RemoveLike(ctx context.Context, id uuid.UUID) error {
-	tx, err := s.db.Begin()
+	tx, err := beginTx(ctx, s.db, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -234,17 +222,17 @@ func (s *ProductRepository) RemoveLike(ctx context.Context, id uuid.UUID) error
 
 	const checkQuery = `SELECT 1 FROM products WHERE id = $1`
 	var exists bool
-	err = s.db.QueryRowContext(ctx, checkQuery, id).Scan(&exists)
+	err = tx.QueryRowContext(ctx, checkQuery, id).Scan(&exists)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return sql.ErrNoRows
+			return ErrProductNotFound
 		}
 		return fmt.Errorf("failed to check if products exists: %w", err)
 	}
 
 	const query = `UPDATE products SET likes = likes - 1 WHERE id = $1`
 
-	result, err := s.db.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -256,7 +244,7 @@ func (s *ProductRepository) RemoveLike(ctx context.Context, id uuid.UUID) error
 	}
 
 	if n == 0 {
-		return sql.ErrNoRows
+		return ErrProductNotFound
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -267,8 +255,8 @@ func (s *ProductRepository) RemoveLike(ctx context.Context, id uuid.UUID) error
 }
 
 func (s *ProductRepository) GetForName(ctx context.Context, name string) ([]*Product, error) {
-	const query = `SELECT * FROM products WHERE name = $1`
-	rows, err := s.db.QueryContext(ctx, query, name)
+	const query = `SELECT id, COALESCE(sku, ''), name, cost, quantity_stock, warranty_days, country, likes FROM products WHERE name = $1`
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, name)
 	if err != nil {
 		return nil, err
 	}
@@ -278,13 +266,14 @@ func (s *ProductRepository) GetForName(ctx context.Context, name string) ([]*Pro
 	for rows.Next() {
 		product := &Product{}
 		if err := rows.Scan(
-			product.ID,
-			product.Name,
-			product.Cost,
-			product.QuantityStock,
-			product.Guarantees,
-			product.Country,
-			product.Like,
+			&product.ID,
+			&product.SKU,
+			&product.Name,
+			&product.Cost,
+			&product.QuantityStock,
+			&product.WarrantyDays,
+			&product.Country,
+			&product.Like,
 		); err != nil {
 			return nil, err
 		}
@@ -295,26 +284,22 @@ func (s *ProductRepository) GetForName(ctx context.Context, name string) ([]*Pro
 }
 
 func (s *ProductRepository) GetProducts(ctx context.Context, offset, limit int) ([]*Product, error) {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	defer tx.Rollback()
-
 	const query = `
         SELECT
             id,
+            COALESCE(sku, ''),
             name, 
             cost, 
-            quantityStock, 
-            guarantees,
+            quantity_stock,
+            warranty_days,
             country, 
             likes 
         FROM 
-            users OFFSET $1 LIMIT $2`
+            products
+        ORDER BY id
+        OFFSET $1 LIMIT $2`
 
-	rows, err := s.db.QueryContext(ctx, query, offset, limit)
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, offset, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -325,10 +310,11 @@ func (s *ProductRepository) GetProducts(ctx context.Context, offset, limit int)
 		product := &Product{}
 		if err := rows.Scan(
 			&product.ID,
+			&product.SKU,
 			&product.Name,
 			&product.Cost,
 			&product.QuantityStock,
-			&product.Guarantees,
+			&product.WarrantyDays,
 			&product.Country,
 			&product.Like,
 		); err != nil {
@@ -337,8 +323,70 @@ func (s *ProductRepository) GetProducts(ctx context.Context, offset, limit int)
 		products = append(products, product)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	return products, rows.Err()
+}
+
+func (s *ProductRepository) GetBySKU(ctx context.Context, sku string) (*Product, error) {
+	const query = `SELECT id, COALESCE(sku, ''), name, cost, quantity_stock, warranty_days, country, likes FROM products WHERE sku = $1`
+
+	product := &Product{}
+	err := conn(ctx, s.db).QueryRowContext(ctx, query, sku).Scan(
+		&product.ID,
+		&product.SKU,
+		&product.Name,
+		&product.Cost,
+		&product.QuantityStock,
+		&product.WarrantyDays,
+		&product.Country,
+		&product.Like,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// GetByCategory pages through the products of a category and of every
+// category below it.
+func (s *ProductRepository) GetByCategory(ctx context.Context, categoryID uuid.UUID, offset, limit int) ([]*Product, error) {
+	const query = `
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION
+			SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		)
+		SELECT id, COALESCE(sku, ''), name, cost, quantity_stock, warranty_days, country, likes
+		FROM products
+		WHERE id IN (SELECT product_id FROM product_categories WHERE category_id IN (SELECT id FROM tree))
+		ORDER BY id
+		OFFSET $2 LIMIT $3`
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, categoryID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*Product
+	for rows.Next() {
+		product := &Product{}
+		if err := rows.Scan(
+			&product.ID,
+			&product.SKU,
+			&product.Name,
+			&product.Cost,
+			&product.QuantityStock,
+			&product.WarrantyDays,
+			&product.Country,
+			&product.Like,
+		); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
 	}
 
 	return products, rows.Err()
@@ -1,14 +1,28 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"vr-shope/internal/money"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrProductNotFound   = errors.New("product not found")
+	ErrPurchaseNotFound  = errors.New("purchase not found")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrOutOfStock        = errors.New("product out of stock")
+	ErrEmailNotVerified  = errors.New("email address is not verified")
+	ErrEmailTaken        = errors.New("email already taken")
+	ErrSKUTaken          = errors.New("sku already taken")
+)
+
 type PurchaseRepository struct {
 	db *sql.DB
 }
@@ -17,172 +31,318 @@ func NewPurchaseStorage(db *sql.DB) (*PurchaseRepository, error) {
 	return &PurchaseRepository{db: db}, nil
 }
 
-func (r *PurchaseRepository) Create(ctx context.Context, purchase *Purchase) error {
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertPurchase(ctx context.Context, q queryer, purchase *Purchase) error {
 	query := `
-		INSERT INTO purchases (id, user_id, product_id, created_at, wallet_usdt, cost)
-		VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.ExecContext(
+		INSERT INTO purchases (id, order_id, user_id, product_id, quantity, created_at, wallet_usdt, cost, warranty_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, (
+			SELECT $6::timestamp + warranty_days * INTERVAL '1 day'
+			FROM products
+			WHERE id = $4 AND warranty_days > 0
+		))
+		RETURNING warranty_until`
+	return q.QueryRowContext(
 		ctx,
 		query,
 		purchase.ID,
+		uuid.NullUUID{UUID: purchase.OrderID, Valid: purchase.OrderID != uuid.Nil},
 		purchase.UserID,
 		purchase.ProductID,
+		purchase.Quantity,
 		purchase.Date,
 		purchase.WalletUSDT,
 		purchase.Cost,
-	)
-	return err
+	).Scan(&purchase.WarrantyUntil)
 }
 
-func (r *PurchaseRepository) Get(ctx context.Context, id uuid.UUID) (*Purchase, error) {
-	query := `
-		SELECT id, user_id, product_id, created_at, wallet_usdt, cost date
-		FROM purchases
-		WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, id)
-
+func scanPurchase(row interface{ Scan(dest ...any) error }) (*Purchase, error) {
 	var purchase Purchase
+	var orderID, productID uuid.NullUUID
 	err := row.Scan(
 		&purchase.ID,
+		&orderID,
 		&purchase.UserID,
-		&purchase.ProductID,
+		&productID,
+		&purchase.Quantity,
 		&purchase.Date,
 		&purchase.WalletUSDT,
 		&purchase.Cost,
+		&purchase.ReturnedQuantity,
+		&purchase.RefundedAmount,
+		&purchase.WarrantyUntil,
 	)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("purchase not found")
-	} else if err != nil {
+	if err != nil {
 		return nil, err
 	}
+	purchase.OrderID = orderID.UUID
+	purchase.ProductID = productID.UUID
 
 	return &purchase, nil
 }
 
-func (r *PurchaseRepository) GetAll(ctx context.Context) ([]*Purchase, error) {
-	query := `
-		SELECT id, user_id, product_id, created_at, wallet_usdt, cost,  date
-		FROM purchases`
-	rows, err := r.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
+func reserveStock(ctx context.Context, tx queryer, productID uuid.UUID, quantity int) (money.Amount, error) {
+	const query = `SELECT cost, quantity_stock FROM products WHERE id = $1 FOR UPDATE`
+
+	var cost money.Amount
+	var stock int
+	err := tx.QueryRowContext(ctx, query, productID).Scan(&cost, &stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrProductNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to lock product: %w", err)
 	}
-	defer rows.Close()
 
-	var purchases []*Purchase
-	for rows.Next() {
-		var purchase Purchase
-		err := rows.Scan(
-			&purchase.ID,
-			&purchase.UserID,
-			&purchase.ProductID,
-			&purchase.Date,
-			&purchase.WalletUSDT,
-			&purchase.Cost,
-		)
-		if err != nil {
-			return nil, err
-		}
-		purchases = append(purchases, &purchase)
+	if stock < quantity {
+		return 0, ErrOutOfStock
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	const update = `UPDATE products SET quantity_stock = quantity_stock - $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, update, productID, quantity); err != nil {
+		return 0, fmt.Errorf("failed to decrement stock: %w", err)
 	}
 
-	return purchases, nil
+	return cost.Mul(quantity), nil
 }
 
-func (r *PurchaseRepository) Update(ctx context.Context, purchase *Purchase) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+func restock(ctx context.Context, tx queryer, productID uuid.UUID, quantity int) error {
+	if productID == uuid.Nil || quantity == 0 {
+		return nil
 	}
 
-	defer tx.Rollback()
+	const query = `UPDATE products SET quantity_stock = quantity_stock + $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, productID, quantity); err != nil {
+		return fmt.Errorf("failed to restock product: %w", err)
+	}
 
-	query := `
+	return nil
+}
+
+func settleReturn(ctx context.Context, tx queryer, purchase *Purchase, quantity int, amount money.Amount, restockItems bool) error {
+	if quantity == 0 && amount == 0 {
+		return nil
+	}
+
+	const query = `
 		UPDATE purchases
-		SET user_id = $1, product_id = $2, created_at = $3, wallet_usdt = $4, cost = $5
-		WHERE id = $6`
-	_, err = r.db.ExecContext(
-		ctx,
-		query,
-		purchase.UserID,
-		purchase.ProductID,
-		purchase.Date,
-		purchase.WalletUSDT,
-		purchase.Cost,
-		purchase.ID,
-	)
+		SET returned_quantity = returned_quantity + $2, refunded_amount = refunded_amount + $3
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, purchase.ID, quantity, amount); err != nil {
+		return fmt.Errorf("failed to update purchase: %w", err)
+	}
+	purchase.ReturnedQuantity += quantity
+	purchase.RefundedAmount += amount
+
+	if restockItems {
+		return restock(ctx, tx, purchase.ProductID, quantity)
+	}
+
+	return nil
+}
+
+func debitWallet(ctx context.Context, tx queryer, userID, referenceID uuid.UUID, amount money.Amount, purchases int) error {
+	txn := &LedgerTransaction{
+		Kind:        LedgerPurchase,
+		ReferenceID: referenceID,
+		Description: fmt.Sprintf("purchase of %d item(s)", purchases),
+		Entries: []*LedgerEntry{
+			{Account: UserAccount(userID), UserID: userID, Amount: -amount},
+			{Account: AccountSales, Amount: amount},
+		},
+	}
+	if err := postTransaction(ctx, tx, txn); err != nil {
+		return fmt.Errorf("failed to debit wallet: %w", err)
+	}
+
+	return nil
+}
+
+func requireVerifiedEmail(ctx context.Context, tx queryer, userID uuid.UUID) error {
+	const query = `SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`
+
+	var verified bool
+	err := tx.QueryRowContext(ctx, query, userID).Scan(&verified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to check email verification: %w", err)
+	}
+
+	if !verified {
+		return ErrEmailNotVerified
+	}
+
+	return nil
+}
+
+func chargePurchases(ctx context.Context, tx queryer, userID, referenceID uuid.UUID, purchases []*Purchase) (money.Amount, error) {
+	if err := requireVerifiedEmail(ctx, tx, userID); err != nil {
+		return 0, err
+	}
+
+	wallet, err := lockWallet(ctx, tx, userID)
 	if err != nil {
-		fmt.Errorf("failed to update purchase")
+		return 0, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	locked := make([]*Purchase, len(purchases))
+	copy(locked, purchases)
+	sort.Slice(locked, func(i, j int) bool {
+		return bytes.Compare(locked[i].ProductID[:], locked[j].ProductID[:]) < 0
+	})
+
+	var total money.Amount
+	for _, purchase := range locked {
+		cost, err := reserveStock(ctx, tx, purchase.ProductID, purchase.Quantity)
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+		purchase.Cost = cost
+	}
+
+	if wallet < total {
+		return 0, ErrInsufficientFunds
 	}
-	return err
+
+	if total > 0 {
+		if err := debitWallet(ctx, tx, userID, referenceID, total, len(purchases)); err != nil {
+			return 0, err
+		}
+	}
+
+	const query = `UPDATE users SET number_purchases = number_purchases + $2 WHERE id = $1`
+
+	_, err = tx.ExecContext(ctx, query, userID, len(purchases))
+	if err != nil {
+		return 0, fmt.Errorf("failed to update purchase counter: %w", err)
+	}
+
+	for _, purchase := range purchases {
+		purchase.WalletUSDT = wallet - total
+	}
+
+	return total, nil
 }
 
-func (r *PurchaseRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	tx, err := r.db.Begin()
+func (r *PurchaseRepository) Create(ctx context.Context, purchase *Purchase) error {
+	tx, err := beginTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	defer tx.Rollback()
 
-	query := `
-		DELETE FROM purchases
-		WHERE id = $1`
-	_, err = r.db.ExecContext(ctx, query, id)
+	total, err := chargePurchases(ctx, tx, purchase.UserID, purchase.OrderID, []*Purchase{purchase})
 	if err != nil {
-		fmt.Errorf("failed to delete purchase")
+		return err
+	}
+
+	order := &Order{
+		ID:        purchase.OrderID,
+		UserID:    purchase.UserID,
+		Total:     total,
+		CreatedAt: purchase.Date,
+	}
+	if err := createPaidOrder(ctx, tx, order); err != nil {
+		return err
+	}
+
+	if err := insertPurchase(ctx, tx, purchase); err != nil {
+		return fmt.Errorf("failed to create purchase: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return err
+	return nil
 }
 
-func (r *PurchaseRepository) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+func (r *PurchaseRepository) Get(ctx context.Context, id uuid.UUID) (*Purchase, error) {
 	query := `
-		SELECT EXISTS(
-			SELECT 1
-			FROM purchases
-			WHERE id = $1
-		)`
-	var exists bool
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&exists)
-	return exists, err
+		SELECT id, order_id, user_id, product_id, quantity, created_at, wallet_usdt, cost, returned_quantity, refunded_amount, warranty_until
+		FROM purchases
+		WHERE id = $1`
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
+
+	purchase, err := scanPurchase(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPurchaseNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return purchase, nil
 }
 
-func (r *PurchaseRepository) CheckMeans(ctx context.Context, userID uuid.UUID, productID uuid.UUID) (*Purchase, error) {
-	const queryUserMoney = `SELECT wallet_usdt FROM users WHERE user_id = $1`
+func (r *PurchaseRepository) GetAll(ctx context.Context) ([]*Purchase, error) {
+	query := `
+		SELECT id, order_id, user_id, product_id, quantity, created_at, wallet_usdt, cost, returned_quantity, refunded_amount, warranty_until
+		FROM purchases`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	row := r.db.QueryRowContext(ctx, queryUserMoney, userID)
+	var purchases []*Purchase
+	for rows.Next() {
+		purchase, err := scanPurchase(rows)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, purchase)
+	}
 
-	var purchase *Purchase
-	err := row.Scan(&purchase.WalletUSDT)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
-	} else if err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	const queryProductCost = `SELECT cost FROM products WHERE product_id = $1`
+	return purchases, nil
+}
 
-	row = r.db.QueryRowContext(ctx, queryProductCost, productID)
+func (r *PurchaseRepository) GetRecent(ctx context.Context, limit int) ([]*Purchase, error) {
+	query := `
+		SELECT id, order_id, user_id, product_id, quantity, created_at, wallet_usdt, cost, returned_quantity, refunded_amount, warranty_until
+		FROM purchases
+		ORDER BY created_at DESC, id
+		LIMIT $1`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	err = row.Scan(&purchase.Cost)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
-	} else if err != nil {
+	var purchases []*Purchase
+	for rows.Next() {
+		purchase, err := scanPurchase(rows)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, purchase)
+	}
+
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return purchase, nil
+	return purchases, nil
+}
+
+func (r *PurchaseRepository) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM purchases
+			WHERE id = $1
+		)`
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&exists)
+	return exists, err
 }
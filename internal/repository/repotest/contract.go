@@ -0,0 +1,676 @@
+// Package repotest holds the behaviour every repository backend has to agree
+// on. Backends run it from their own tests through Run.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"vr-shope/internal/models"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+	"vr-shope/internal/service"
+
+	"github.com/google/uuid"
+)
+
+type Backend struct {
+	Users      service.UserRepository
+	Products   service.ProductRepository
+	Categories service.CategoryRepository
+	Purchases  service.PurchaseRepository
+
+	// Credit funds the wallet of a user, backends differ in how money gets
+	// there.
+	Credit func(t *testing.T, userID uuid.UUID, amount money.Amount)
+}
+
+// Run executes the contract against fresh, empty backends returned by open.
+func Run(t *testing.T, open func(t *testing.T) *Backend) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, open) })
+	t.Run("Products", func(t *testing.T) { testProducts(t, open) })
+	t.Run("Categories", func(t *testing.T) { testCategories(t, open) })
+	t.Run("Purchases", func(t *testing.T) { testPurchases(t, open) })
+}
+
+func newUser(t *testing.T, b *Backend) *repository.User {
+	t.Helper()
+
+	id := uuid.New()
+	user := &repository.User{
+		ID:          id,
+		Login:       "login-" + id.String()[:8],
+		Name:        "Name",
+		LastName:    "LastName",
+		PhoneNumber: "+10000000000",
+		Password:    "hash",
+		Email:       id.String()[:8] + "@example.com",
+		Role:        models.RoleCustomer,
+	}
+	if err := b.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	return user
+}
+
+func newProduct(t *testing.T, b *Backend, cost money.Amount, stock, warrantyDays int) *repository.Product {
+	t.Helper()
+
+	product := &repository.Product{
+		ID:            uuid.New(),
+		Name:          "product-" + uuid.NewString()[:8],
+		Cost:          cost,
+		QuantityStock: stock,
+		WarrantyDays:  warrantyDays,
+		Country:       "NL",
+	}
+	if err := b.Products.Create(context.Background(), product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	return product
+}
+
+func wantErr(t *testing.T, op string, err, want error) {
+	t.Helper()
+
+	if !errors.Is(err, want) {
+		t.Fatalf("%s: got error %v, want %v", op, err, want)
+	}
+}
+
+func testUsers(t *testing.T, open func(t *testing.T) *Backend) {
+	ctx := context.Background()
+
+	t.Run("CreateAndLookup", func(t *testing.T) {
+		b := open(t)
+		user := newUser(t, b)
+
+		got, err := b.Users.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+		if got.Login != user.Login || got.Email != user.Email || got.Role != user.Role {
+			t.Fatalf("get by id: got %+v, want %+v", got, user)
+		}
+		if got.WalletUSDT != 0 || got.NumberPurchases != 0 || got.EmailVerifiedAt.Valid {
+			t.Fatalf("get by id: new user has state %+v", got)
+		}
+
+		if got, err := b.Users.GetByEmail(ctx, user.Email); err != nil || got.ID != user.ID {
+			t.Fatalf("get by email: got %v, %v", got, err)
+		}
+		if got, err := b.Users.GetUserByLogin(ctx, user.Login); err != nil || got.ID != user.ID {
+			t.Fatalf("get by login: got %v, %v", got, err)
+		}
+		if exists, err := b.Users.ExistsByID(ctx, user.ID); err != nil || !exists {
+			t.Fatalf("exists by id: got %v, %v", exists, err)
+		}
+		if exists, err := b.Users.ExistsByEmail(ctx, user.Email); err != nil || !exists {
+			t.Fatalf("exists by email: got %v, %v", exists, err)
+		}
+	})
+
+	t.Run("UniqueEmail", func(t *testing.T) {
+		b := open(t)
+		first := newUser(t, b)
+		second := newUser(t, b)
+
+		duplicate := *second
+		duplicate.ID = uuid.New()
+		duplicate.Email = first.Email
+		wantErr(t, "create", b.Users.Create(ctx, &duplicate), repository.ErrEmailTaken)
+
+		second.Email = first.Email
+		wantErr(t, "update", b.Users.Update(ctx, second), repository.ErrEmailTaken)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := open(t)
+		missing := uuid.New()
+
+		_, err := b.Users.GetByID(ctx, missing)
+		wantErr(t, "get by id", err, repository.ErrUserNotFound)
+		_, err = b.Users.GetByEmail(ctx, "missing@example.com")
+		wantErr(t, "get by email", err, repository.ErrUserNotFound)
+		_, err = b.Users.GetUserByLogin(ctx, "missing")
+		wantErr(t, "get by login", err, repository.ErrUserNotFound)
+
+		wantErr(t, "update", b.Users.Update(ctx, &repository.User{ID: missing, Email: "missing@example.com"}), repository.ErrUserNotFound)
+		wantErr(t, "delete", b.Users.Delete(ctx, missing), repository.ErrUserNotFound)
+		wantErr(t, "set role", b.Users.SetRole(ctx, missing, models.RoleAdmin), repository.ErrUserNotFound)
+		wantErr(t, "update password", b.Users.UpdatePassword(ctx, missing, "hash", ""), repository.ErrUserNotFound)
+		wantErr(t, "mark verified", b.Users.MarkEmailVerified(ctx, missing, "missing@example.com"), repository.ErrUserNotFound)
+
+		if exists, err := b.Users.ExistsByID(ctx, missing); err != nil || exists {
+			t.Fatalf("exists by id: got %v, %v", exists, err)
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		b := open(t)
+		user := newUser(t, b)
+
+		if err := b.Users.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+			t.Fatalf("mark verified: %v", err)
+		}
+
+		user.Name = "Renamed"
+		user.Email = "renamed-" + user.Email
+		if err := b.Users.Update(ctx, user); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+
+		got, err := b.Users.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+		if got.Name != "Renamed" || got.Email != user.Email {
+			t.Fatalf("update: got %+v", got)
+		}
+		if got.EmailVerifiedAt.Valid {
+			t.Fatal("update: changing the email must clear verification")
+		}
+
+		if err := b.Users.SetRole(ctx, user.ID, models.RoleManager); err != nil {
+			t.Fatalf("set role: %v", err)
+		}
+		if got, _ := b.Users.GetByID(ctx, user.ID); got.Role != models.RoleManager {
+			t.Fatalf("set role: got %q", got.Role)
+		}
+
+		if err := b.Users.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		_, err = b.Users.GetByID(ctx, user.ID)
+		wantErr(t, "get after delete", err, repository.ErrUserNotFound)
+	})
+
+	t.Run("VerificationSlot", func(t *testing.T) {
+		b := open(t)
+		user := newUser(t, b)
+		notBefore := time.Now().Add(-time.Minute)
+
+		if sent, err := b.Users.MarkVerificationSent(ctx, user.ID, notBefore); err != nil || !sent {
+			t.Fatalf("first send: got %v, %v", sent, err)
+		}
+		if sent, err := b.Users.MarkVerificationSent(ctx, user.ID, notBefore); err != nil || sent {
+			t.Fatalf("resend within interval: got %v, %v", sent, err)
+		}
+
+		if err := b.Users.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+			t.Fatalf("mark verified: %v", err)
+		}
+		if sent, err := b.Users.MarkVerificationSent(ctx, user.ID, time.Now().Add(time.Hour)); err != nil || sent {
+			t.Fatalf("send after verification: got %v, %v", sent, err)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		b := open(t)
+		want := make(map[uuid.UUID]bool)
+		for range 5 {
+			want[newUser(t, b).ID] = true
+		}
+
+		seen := make(map[uuid.UUID]bool)
+		for offset, size := range map[int]int{0: 2, 2: 2, 4: 1} {
+			users, err := b.Users.GetUsers(ctx, offset, 2)
+			if err != nil {
+				t.Fatalf("get users at %d: %v", offset, err)
+			}
+			if len(users) != size {
+				t.Fatalf("get users at %d: got %d users, want %d", offset, len(users), size)
+			}
+			for _, user := range users {
+				if seen[user.ID] {
+					t.Fatalf("get users: %s returned on two pages", user.ID)
+				}
+				seen[user.ID] = true
+			}
+		}
+		if len(seen) != len(want) {
+			t.Fatalf("get users: pages cover %d of %d users", len(seen), len(want))
+		}
+
+		users, err := b.Users.GetUsers(ctx, 10, 2)
+		if err != nil || len(users) != 0 {
+			t.Fatalf("get users past the end: got %d users, %v", len(users), err)
+		}
+
+		all, err := b.Users.GetAll(ctx)
+		if err != nil || len(all) != len(want) {
+			t.Fatalf("get all: got %d users, %v", len(all), err)
+		}
+	})
+}
+
+func testProducts(t *testing.T, open func(t *testing.T) *Backend) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		b := open(t)
+		product := newProduct(t, b, 1500, 3, 30)
+
+		got, err := b.Products.Get(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if got.Name != product.Name || got.Cost != product.Cost || got.QuantityStock != 3 || got.WarrantyDays != 30 || got.Like != 0 {
+			t.Fatalf("get: got %+v, want %+v", got, product)
+		}
+
+		byName, err := b.Products.GetForName(ctx, product.Name)
+		if err != nil || len(byName) != 1 || byName[0].ID != product.ID {
+			t.Fatalf("get for name: got %v, %v", byName, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := open(t)
+		missing := uuid.New()
+
+		_, err := b.Products.Get(ctx, missing)
+		wantErr(t, "get", err, repository.ErrProductNotFound)
+		wantErr(t, "update", b.Products.Update(ctx, &repository.Product{ID: missing, Name: "missing"}), repository.ErrProductNotFound)
+		wantErr(t, "delete", b.Products.Delete(ctx, missing), repository.ErrProductNotFound)
+		wantErr(t, "add like", b.Products.AddLike(ctx, missing), repository.ErrProductNotFound)
+		wantErr(t, "remove like", b.Products.RemoveLike(ctx, missing), repository.ErrProductNotFound)
+
+		products, err := b.Products.GetForName(ctx, "missing")
+		if err != nil || len(products) != 0 {
+			t.Fatalf("get for name: got %d products, %v", len(products), err)
+		}
+	})
+
+	t.Run("SKU", func(t *testing.T) {
+		b := open(t)
+		product := &repository.Product{ID: uuid.New(), SKU: "VR-001", Name: "headset", Cost: 100, Country: "NL"}
+		if err := b.Products.Create(ctx, product); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		got, err := b.Products.GetBySKU(ctx, "VR-001")
+		if err != nil || got.ID != product.ID || got.SKU != "VR-001" {
+			t.Fatalf("get by sku: got %+v, %v", got, err)
+		}
+		_, err = b.Products.GetBySKU(ctx, "VR-404")
+		wantErr(t, "get by missing sku", err, repository.ErrProductNotFound)
+
+		duplicate := &repository.Product{ID: uuid.New(), SKU: "VR-001", Name: "copy", Country: "NL"}
+		wantErr(t, "create duplicate sku", b.Products.Create(ctx, duplicate), repository.ErrSKUTaken)
+
+		// products without a SKU never clash
+		first := newProduct(t, b, 100, 1, 0)
+		second := newProduct(t, b, 100, 1, 0)
+		if first.SKU != "" || second.SKU != "" {
+			t.Fatalf("new product: got skus %q and %q", first.SKU, second.SKU)
+		}
+
+		first.SKU = "VR-001"
+		wantErr(t, "update to taken sku", b.Products.Update(ctx, first), repository.ErrSKUTaken)
+		first.SKU = "VR-002"
+		if err := b.Products.Update(ctx, first); err != nil {
+			t.Fatalf("update sku: %v", err)
+		}
+		got, err = b.Products.Get(ctx, first.ID)
+		if err != nil || got.SKU != "VR-002" {
+			t.Fatalf("get: got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("UpdateLikesAndDelete", func(t *testing.T) {
+		b := open(t)
+		product := newProduct(t, b, 1000, 1, 0)
+
+		product.Cost = 2000
+		product.QuantityStock = 7
+		if err := b.Products.Update(ctx, product); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		for range 2 {
+			if err := b.Products.AddLike(ctx, product.ID); err != nil {
+				t.Fatalf("add like: %v", err)
+			}
+		}
+		if err := b.Products.RemoveLike(ctx, product.ID); err != nil {
+			t.Fatalf("remove like: %v", err)
+		}
+
+		got, err := b.Products.Get(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if got.Cost != 2000 || got.QuantityStock != 7 || got.Like != 1 {
+			t.Fatalf("get: got %+v", got)
+		}
+
+		if err := b.Products.Delete(ctx, product.ID); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		_, err = b.Products.Get(ctx, product.ID)
+		wantErr(t, "get after delete", err, repository.ErrProductNotFound)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		b := open(t)
+		for range 3 {
+			newProduct(t, b, 100, 1, 0)
+		}
+
+		first, err := b.Products.GetProducts(ctx, 0, 2)
+		if err != nil || len(first) != 2 {
+			t.Fatalf("first page: got %d products, %v", len(first), err)
+		}
+		second, err := b.Products.GetProducts(ctx, 2, 2)
+		if err != nil || len(second) != 1 {
+			t.Fatalf("second page: got %d products, %v", len(second), err)
+		}
+		for _, product := range first {
+			if product.ID == second[0].ID {
+				t.Fatalf("pagination: %s returned on two pages", product.ID)
+			}
+		}
+
+		all, err := b.Products.GetAll(ctx)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("get all: got %d products, %v", len(all), err)
+		}
+	})
+}
+
+func testCategories(t *testing.T, open func(t *testing.T) *Backend) {
+	ctx := context.Background()
+
+	newCategory := func(t *testing.T, b *Backend, parentID uuid.UUID, slug string, position int) *repository.Category {
+		t.Helper()
+
+		category := &repository.Category{
+			ID:       uuid.New(),
+			ParentID: parentID,
+			Name:     slug,
+			Slug:     slug,
+			Position: position,
+		}
+		if err := b.Categories.Create(ctx, category); err != nil {
+			t.Fatalf("create category: %v", err)
+		}
+
+		return category
+	}
+
+	t.Run("CreateAndLookup", func(t *testing.T) {
+		b := open(t)
+		headsets := newCategory(t, b, uuid.Nil, "headsets", 2)
+		games := newCategory(t, b, uuid.Nil, "games", 1)
+		standalone := newCategory(t, b, headsets.ID, "standalone", 0)
+
+		got, err := b.Categories.Get(ctx, standalone.ID)
+		if err != nil || got.ParentID != headsets.ID || got.Slug != "standalone" {
+			t.Fatalf("get: got %+v, %v", got, err)
+		}
+		got, err = b.Categories.GetBySlug(ctx, "games")
+		if err != nil || got.ID != games.ID || got.ParentID != uuid.Nil {
+			t.Fatalf("get by slug: got %+v, %v", got, err)
+		}
+
+		all, err := b.Categories.GetAll(ctx)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("get all: got %d categories, %v", len(all), err)
+		}
+		if all[0].ID != standalone.ID || all[1].ID != games.ID || all[2].ID != headsets.ID {
+			t.Fatalf("get all: got order %s, %s, %s", all[0].Slug, all[1].Slug, all[2].Slug)
+		}
+
+		duplicate := &repository.Category{ID: uuid.New(), Name: "Games", Slug: "games"}
+		wantErr(t, "create duplicate slug", b.Categories.Create(ctx, duplicate), repository.ErrCategorySlugTaken)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := open(t)
+		missing := uuid.New()
+
+		_, err := b.Categories.Get(ctx, missing)
+		wantErr(t, "get", err, repository.ErrCategoryNotFound)
+		_, err = b.Categories.GetBySlug(ctx, "missing")
+		wantErr(t, "get by slug", err, repository.ErrCategoryNotFound)
+		wantErr(t, "update", b.Categories.Update(ctx, &repository.Category{ID: missing, Name: "missing", Slug: "missing"}), repository.ErrCategoryNotFound)
+		wantErr(t, "delete", b.Categories.Delete(ctx, missing), repository.ErrCategoryNotFound)
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		b := open(t)
+		headsets := newCategory(t, b, uuid.Nil, "headsets", 0)
+		games := newCategory(t, b, uuid.Nil, "games", 0)
+		standalone := newCategory(t, b, headsets.ID, "standalone", 0)
+
+		standalone.Slug = "games"
+		wantErr(t, "update to taken slug", b.Categories.Update(ctx, standalone), repository.ErrCategorySlugTaken)
+
+		standalone.Slug = "standalone-games"
+		standalone.ParentID = games.ID
+		standalone.Position = 5
+		if err := b.Categories.Update(ctx, standalone); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		got, err := b.Categories.Get(ctx, standalone.ID)
+		if err != nil || got.ParentID != games.ID || got.Slug != "standalone-games" || got.Position != 5 {
+			t.Fatalf("get: got %+v, %v", got, err)
+		}
+
+		wantErr(t, "delete parent", b.Categories.Delete(ctx, games.ID), repository.ErrCategoryHasChildren)
+
+		// headsets lost its only child when standalone moved
+		if err := b.Categories.Delete(ctx, headsets.ID); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		_, err = b.Categories.Get(ctx, headsets.ID)
+		wantErr(t, "get after delete", err, repository.ErrCategoryNotFound)
+	})
+
+	t.Run("ProductCategories", func(t *testing.T) {
+		b := open(t)
+		headsets := newCategory(t, b, uuid.Nil, "headsets", 0)
+		accessories := newCategory(t, b, uuid.Nil, "accessories", 1)
+		product := newProduct(t, b, 100, 1, 0)
+
+		if err := b.Categories.SetProductCategories(ctx, product.ID, []uuid.UUID{headsets.ID, accessories.ID}); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+		got, err := b.Categories.GetProductCategories(ctx, product.ID)
+		if err != nil || len(got) != 2 || got[0].ID != headsets.ID || got[1].ID != accessories.ID {
+			t.Fatalf("get: got %d categories, %v", len(got), err)
+		}
+
+		// a new list replaces the old one
+		if err := b.Categories.SetProductCategories(ctx, product.ID, []uuid.UUID{accessories.ID}); err != nil {
+			t.Fatalf("replace: %v", err)
+		}
+		got, err = b.Categories.GetProductCategories(ctx, product.ID)
+		if err != nil || len(got) != 1 || got[0].ID != accessories.ID {
+			t.Fatalf("get after replace: got %d categories, %v", len(got), err)
+		}
+
+		// deleting a category drops its assignments, not the product
+		if err := b.Categories.Delete(ctx, accessories.ID); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		got, err = b.Categories.GetProductCategories(ctx, product.ID)
+		if err != nil || len(got) != 0 {
+			t.Fatalf("get after delete: got %d categories, %v", len(got), err)
+		}
+		if _, err := b.Products.Get(ctx, product.ID); err != nil {
+			t.Fatalf("get product: %v", err)
+		}
+
+		if err := b.Categories.SetProductCategories(ctx, product.ID, []uuid.UUID{headsets.ID}); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+		if err := b.Categories.SetProductCategories(ctx, product.ID, nil); err != nil {
+			t.Fatalf("clear: %v", err)
+		}
+		got, err = b.Categories.GetProductCategories(ctx, product.ID)
+		if err != nil || len(got) != 0 {
+			t.Fatalf("get after clear: got %d categories, %v", len(got), err)
+		}
+	})
+
+	t.Run("ProductsByCategory", func(t *testing.T) {
+		b := open(t)
+		headsets := newCategory(t, b, uuid.Nil, "headsets", 0)
+		standalone := newCategory(t, b, headsets.ID, "standalone", 0)
+		wireless := newCategory(t, b, standalone.ID, "wireless", 0)
+		games := newCategory(t, b, uuid.Nil, "games", 0)
+
+		assign := func(product *repository.Product, ids ...uuid.UUID) {
+			t.Helper()
+			if err := b.Categories.SetProductCategories(ctx, product.ID, ids); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+		}
+		assign(newProduct(t, b, 100, 1, 0), headsets.ID)
+		assign(newProduct(t, b, 100, 1, 0), wireless.ID)
+		// in two categories of the same tree, listed once
+		assign(newProduct(t, b, 100, 1, 0), headsets.ID, standalone.ID)
+		game := newProduct(t, b, 100, 1, 0)
+		assign(game, games.ID)
+		newProduct(t, b, 100, 1, 0)
+
+		all, err := b.Products.GetByCategory(ctx, headsets.ID, 0, 10)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("headsets: got %d products, %v", len(all), err)
+		}
+		for _, product := range all {
+			if product.ID == game.ID {
+				t.Fatalf("headsets: got product %s of another category", game.ID)
+			}
+		}
+
+		below, err := b.Products.GetByCategory(ctx, standalone.ID, 0, 10)
+		if err != nil || len(below) != 2 {
+			t.Fatalf("standalone: got %d products, %v", len(below), err)
+		}
+
+		first, err := b.Products.GetByCategory(ctx, headsets.ID, 0, 2)
+		if err != nil || len(first) != 2 {
+			t.Fatalf("first page: got %d products, %v", len(first), err)
+		}
+		second, err := b.Products.GetByCategory(ctx, headsets.ID, 2, 2)
+		if err != nil || len(second) != 1 {
+			t.Fatalf("second page: got %d products, %v", len(second), err)
+		}
+		for _, product := range first {
+			if product.ID == second[0].ID {
+				t.Fatalf("pagination: %s returned on two pages", product.ID)
+			}
+		}
+
+		none, err := b.Products.GetByCategory(ctx, uuid.New(), 0, 10)
+		if err != nil || len(none) != 0 {
+			t.Fatalf("missing category: got %d products, %v", len(none), err)
+		}
+	})
+}
+
+func testPurchases(t *testing.T, open func(t *testing.T) *Backend) {
+	ctx := context.Background()
+
+	buyer := func(t *testing.T, b *Backend, funds money.Amount) *repository.User {
+		t.Helper()
+
+		user := newUser(t, b)
+		if err := b.Users.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+			t.Fatalf("mark verified: %v", err)
+		}
+		if funds > 0 {
+			b.Credit(t, user.ID, funds)
+		}
+
+		return user
+	}
+
+	newPurchase := func(user *repository.User, product *repository.Product, quantity int) *repository.Purchase {
+		return &repository.Purchase{
+			ID:        uuid.New(),
+			OrderID:   uuid.New(),
+			UserID:    user.ID,
+			ProductID: product.ID,
+			Quantity:  quantity,
+			Date:      time.Now().UTC().Truncate(time.Second),
+		}
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		b := open(t)
+		user := buyer(t, b, 10000)
+		product := newProduct(t, b, 1500, 5, 30)
+
+		purchase := newPurchase(user, product, 2)
+		if err := b.Purchases.Create(ctx, purchase); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if purchase.Cost != 3000 || purchase.WalletUSDT != 7000 {
+			t.Fatalf("create: cost %d, wallet %d", purchase.Cost, purchase.WalletUSDT)
+		}
+		warranty := purchase.Date.AddDate(0, 0, 30)
+		if !purchase.WarrantyUntil.Valid || purchase.WarrantyUntil.Time.Sub(warranty).Abs() > time.Second {
+			t.Fatalf("create: warranty until %v, want %v", purchase.WarrantyUntil, warranty)
+		}
+
+		if got, _ := b.Products.Get(ctx, product.ID); got.QuantityStock != 3 {
+			t.Fatalf("stock after purchase: got %d, want 3", got.QuantityStock)
+		}
+		if got, _ := b.Users.GetByID(ctx, user.ID); got.WalletUSDT != 7000 || got.NumberPurchases != 1 {
+			t.Fatalf("user after purchase: wallet %d, purchases %d", got.WalletUSDT, got.NumberPurchases)
+		}
+
+		got, err := b.Purchases.Get(ctx, purchase.ID)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if got.UserID != user.ID || got.ProductID != product.ID || got.Quantity != 2 || got.Cost != 3000 {
+			t.Fatalf("get: got %+v", got)
+		}
+		if exists, err := b.Purchases.ExistsByID(ctx, purchase.ID); err != nil || !exists {
+			t.Fatalf("exists by id: got %v, %v", exists, err)
+		}
+		if all, err := b.Purchases.GetAll(ctx); err != nil || len(all) != 1 {
+			t.Fatalf("get all: got %d purchases, %v", len(all), err)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		b := open(t)
+		user := buyer(t, b, 1000)
+		product := newProduct(t, b, 600, 1, 0)
+
+		unverified := newUser(t, b)
+		wantErr(t, "unverified email", b.Purchases.Create(ctx, newPurchase(unverified, product, 1)), repository.ErrEmailNotVerified)
+		wantErr(t, "out of stock", b.Purchases.Create(ctx, newPurchase(user, product, 2)), repository.ErrOutOfStock)
+
+		missing := &repository.Product{ID: uuid.New()}
+		wantErr(t, "missing product", b.Purchases.Create(ctx, newPurchase(user, missing, 1)), repository.ErrProductNotFound)
+
+		pricey := newProduct(t, b, 5000, 1, 0)
+		wantErr(t, "insufficient funds", b.Purchases.Create(ctx, newPurchase(user, pricey, 1)), repository.ErrInsufficientFunds)
+
+		if got, _ := b.Products.Get(ctx, pricey.ID); got.QuantityStock != 1 {
+			t.Fatalf("stock after rejected purchase: got %d, want 1", got.QuantityStock)
+		}
+		if got, _ := b.Users.GetByID(ctx, user.ID); got.WalletUSDT != 1000 || got.NumberPurchases != 0 {
+			t.Fatalf("user after rejected purchase: wallet %d, purchases %d", got.WalletUSDT, got.NumberPurchases)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		b := open(t)
+
+		_, err := b.Purchases.Get(ctx, uuid.New())
+		wantErr(t, "get", err, repository.ErrPurchaseNotFound)
+		if exists, err := b.Purchases.ExistsByID(ctx, uuid.New()); err != nil || exists {
+			t.Fatalf("exists by id: got %v, %v", exists, err)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenExpired  = errors.New("refresh token expired")
+	ErrRefreshTokenRevoked  = errors.New("refresh token revoked")
+	ErrRefreshTokenReused   = errors.New("refresh token reuse detected")
+)
+
+type TokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenStorage(db *sql.DB) (*TokenRepository, error) {
+	return &TokenRepository{db: db}, nil
+}
+
+func insertRefreshToken(ctx context.Context, q queryer, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING created_at`
+
+	return q.QueryRowContext(
+		ctx,
+		query,
+		token.ID,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(&token.CreatedAt)
+}
+
+func revokeFamily(ctx context.Context, q queryer, familyID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := q.ExecContext(ctx, query, familyID); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	return insertRefreshToken(ctx, r.db, token)
+}
+
+func (r *TokenRepository) Rotate(ctx context.Context, tokenHash string, next *RefreshToken) (*RefreshToken, error) {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	const selectQuery = `
+		SELECT id, user_id, family_id, token_hash, expires_at, created_at, used_at, replaced_by, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE`
+
+	var current RefreshToken
+	var replacedBy uuid.NullUUID
+	err = tx.QueryRowContext(ctx, selectQuery, tokenHash).Scan(
+		&current.ID,
+		&current.UserID,
+		&current.FamilyID,
+		&current.TokenHash,
+		&current.ExpiresAt,
+		&current.CreatedAt,
+		&current.UsedAt,
+		&replacedBy,
+		&current.RevokedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefreshTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	current.ReplacedBy = replacedBy.UUID
+
+	switch {
+	case current.RevokedAt.Valid:
+		return nil, ErrRefreshTokenRevoked
+	case current.UsedAt.Valid:
+		// an already rotated token was presented again, so assume it leaked
+		if err := revokeFamily(ctx, tx, current.FamilyID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil, ErrRefreshTokenReused
+	case time.Now().After(current.ExpiresAt):
+		return nil, ErrRefreshTokenExpired
+	}
+
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+
+	const updateQuery = `UPDATE refresh_tokens SET used_at = NOW(), replaced_by = $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, updateQuery, current.ID, next.ID); err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &current, nil
+}
+
+func (r *TokenRepository) RevokeRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	var familyID uuid.UUID
+	query := `SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash, userID).Scan(&familyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRefreshTokenNotFound
+	} else if err != nil {
+		return err
+	}
+
+	return revokeFamily(ctx, r.db, familyID)
+}
+
+func (r *TokenRepository) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, userID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (token_id, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (token_id) DO NOTHING`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, tokenID, userID, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < NOW()`); err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) IsRevoked(ctx context.Context, tokenID string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	// iat only has second precision, so compare against the truncated
+	// revocation time to keep tokens issued right after a reset valid
+	query := `
+		SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE token_id = $1)
+			OR EXISTS(
+				SELECT 1
+				FROM users
+				WHERE id = $2 AND date_trunc('second', sessions_revoked_at) > $3
+			)`
+
+	var revoked bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenID, userID, issuedAt).Scan(&revoked)
+	return revoked, err
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type UserStorage struct {
@@ -18,10 +19,10 @@ func NewUserStorage(db *sql.DB) (*UserStorage, error) {
 }
 
 func (r *UserStorage) Create(ctx context.Context, userRepo *User) error {
-	query := `INSERT INTO users (id, login, name, last_name, phone_number, password, email, salt) 
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	query := `INSERT INTO users (id, login, name, last_name, phone_number, hashed_password, email, salt, role) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
 
-	err := r.db.QueryRowContext(ctx, query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		userRepo.ID,
 		userRepo.Login,
 		userRepo.Name,
@@ -30,7 +31,11 @@ func (r *UserStorage) Create(ctx context.Context, userRepo *User) error {
 		userRepo.Password,
 		userRepo.Email,
 		userRepo.Salt,
+		userRepo.Role,
 	).Scan(&userRepo.ID)
+	if isUniqueViolation(err, "users_email_key") {
+		return ErrEmailTaken
+	}
 	if err != nil {
 		return err
 	}
@@ -46,9 +51,12 @@ func (r *UserStorage) GetByID(ctx context.Context, id uuid.UUID) (*User, error)
 	    name, 
 	    last_name, 
 	    phone_number, 
-	    password, 
+	    hashed_password, 
 	    email, 
-	    wallet_usdt,  
+	    wallet_usdt,
+	    number_purchases,
+	    role,
+	    email_verified_at
 	FROM 
 		users 
 	WHERE 
@@ -56,7 +64,7 @@ func (r *UserStorage) GetByID(ctx context.Context, id uuid.UUID) (*User, error)
 	    `
 
 	user := &User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Login,
 		&user.Name,
@@ -65,9 +73,12 @@ func (r *UserStorage) GetByID(ctx context.Context, id uuid.UUID) (*User, error)
 		&user.Password,
 		&user.Email,
 		&user.WalletUSDT,
+		&user.NumberPurchases,
+		&user.Role,
+		&user.EmailVerifiedAt,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -77,9 +88,9 @@ func (r *UserStorage) GetByID(ctx context.Context, id uuid.UUID) (*User, error)
 }
 
 func (r *UserStorage) GetAll(ctx context.Context) ([]*User, error) {
-	query := `SELECT id, login, name, last_name, phone_number, password, email, wallet_usdt FROM users`
+	query := `SELECT id, login, name, last_name, phone_number, hashed_password, email, wallet_usdt, number_purchases, role, email_verified_at FROM users`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +108,9 @@ func (r *UserStorage) GetAll(ctx context.Context) ([]*User, error) {
 			&user.Password,
 			&user.Email,
 			&user.WalletUSDT,
+			&user.NumberPurchases,
+			&user.Role,
+			&user.EmailVerifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -111,13 +125,6 @@ func (r *UserStorage) GetAll(ctx context.Context) ([]*User, error) {
 }
 
 func (r *UserStorage) Update(ctx context.Context, userServ *User) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	defer tx.Rollback()
-
 	query := `
 	UPDATE
     	users 
@@ -126,48 +133,38 @@ func (r *UserStorage) Update(ctx context.Context, userServ *User) error {
 	    name = $2,
 	    last_name = $3,
 	    phone_number = $4,
-	    password = $5, 
-	    email = $6, 
-	    wallet_usdt = $7
+	    email = $5,
+	    email_verified_at = CASE WHEN email = $5 THEN email_verified_at END
 	WHERE 
-	    id = $8
+	    id = $6
+	RETURNING id
 	    `
 
-	err = r.db.QueryRowContext(ctx, query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		userServ.Login,
 		userServ.Name,
 		userServ.LastName,
 		userServ.PhoneNumber,
-		userServ.Password,
 		userServ.Email,
-		userServ.WalletUSDT,
 		userServ.ID,
 	).Scan(&userServ.ID)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil
+		return ErrUserNotFound
+	}
+	if isUniqueViolation(err, "users_email_key") {
+		return ErrEmailTaken
 	}
 	if err != nil {
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
 func (r *UserStorage) Delete(ctx context.Context, id uuid.UUID) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	defer tx.Rollback()
-
 	query := `DELETE FROM users WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -178,11 +175,7 @@ func (r *UserStorage) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return ErrUserNotFound
 	}
 
 	return nil
@@ -192,7 +185,7 @@ func (r *UserStorage) ExistsByEmail(ctx context.Context, email string) (bool, er
 	query := `SELECT 1 FROM users WHERE email = $1`
 
 	var exists int
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&exists)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(&exists)
 	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
 	}
@@ -207,7 +200,7 @@ func (r *UserStorage) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error
 	query := `SELECT 1 FROM users WHERE id = $1`
 
 	var exists uuid.UUID
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&exists)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&exists)
 	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
 	}
@@ -219,11 +212,11 @@ func (r *UserStorage) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error
 }
 
 func (r *UserStorage) GetByEmail(ctx context.Context, email string) (*User, error) {
-	query := `SELECT id, login, name, last_name, phone_number, password, email, wallet_usdt, 
-			  FROM users WHERE id = $1`
+	query := `SELECT id, login, name, last_name, phone_number, hashed_password, email, wallet_usdt, number_purchases, role, email_verified_at
+			  FROM users WHERE email = $1`
 
 	user := &User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Login,
 		&user.Name,
@@ -232,9 +225,12 @@ func (r *UserStorage) GetByEmail(ctx context.Context, email string) (*User, erro
 		&user.Password,
 		&user.Email,
 		&user.WalletUSDT,
+		&user.NumberPurchases,
+		&user.Role,
+		&user.EmailVerifiedAt,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -246,11 +242,11 @@ func (r *UserStorage) GetByEmail(ctx context.Context, email string) (*User, erro
 func (s *UserStorage) GetUserByLogin(ctx context.Context, login string) (*User, error) {
 	user := &User{}
 
-	const query = `SELECT id, login, password_hash, salt FROM users WHERE login = $1`
-	err := s.db.QueryRowContext(ctx, query, login).Scan(&user.ID, &user.Login, &user.Password, &user.Salt)
+	const query = `SELECT id, login, email, hashed_password, salt, role, totp_enabled_at FROM users WHERE login = $1`
+	err := conn(ctx, s.db).QueryRowContext(ctx, query, login).Scan(&user.ID, &user.Login, &user.Email, &user.Password, &user.Salt, &user.Role, &user.TOTPEnabledAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, err
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -259,17 +255,10 @@ func (s *UserStorage) GetUserByLogin(ctx context.Context, login string) (*User,
 }
 
 func (s *UserStorage) GetUsers(ctx context.Context, offset, limit int) ([]*User, error) {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	defer tx.Rollback()
-
 	const query = `
-        SELECT id, login, email FROM users OFFSET $1 LIMIT $2`
+        SELECT id, login, email, role FROM users ORDER BY id OFFSET $1 LIMIT $2`
 
-	rows, err := s.db.QueryContext(ctx, query, offset, limit)
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, offset, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -282,15 +271,102 @@ func (s *UserStorage) GetUsers(ctx context.Context, offset, limit int) ([]*User,
 			&user.ID,
 			&user.Login,
 			&user.Email,
+			&user.Role,
 		); err != nil {
 			return nil, err
 		}
 		users = append(users, user)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	return users, rows.Err()
+}
+
+func (r *UserStorage) SetRole(ctx context.Context, id uuid.UUID, role string) error {
+	query := `UPDATE users SET role = $2 WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, role)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
 
-	return users, rows.Err()
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *UserStorage) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword, salt string) error {
+	query := `UPDATE users SET hashed_password = $2, salt = $3 WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, hashedPassword, salt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *UserStorage) MarkVerificationSent(ctx context.Context, id uuid.UUID, notBefore time.Time) (bool, error) {
+	// claims the send slot atomically so concurrent resends can't both mail
+	query := `
+		UPDATE users
+		SET verification_sent_at = NOW()
+		WHERE id = $1
+			AND email_verified_at IS NULL
+			AND (verification_sent_at IS NULL OR verification_sent_at < $2)`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, notBefore)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *UserStorage) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	query := `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id = $1 AND email = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, email)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
 }
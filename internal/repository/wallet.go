@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"vr-shope/internal/money"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LedgerDeposit    = "deposit"
+	LedgerPurchase   = "purchase"
+	LedgerRefund     = "refund"
+	LedgerAdjustment = "adjustment"
+
+	AccountDeposits    = "external:deposits"
+	AccountSales       = "revenue:sales"
+	AccountAdjustments = "equity:adjustments"
+)
+
+var ErrUnbalancedTransaction = errors.New("ledger transaction is not balanced")
+
+func UserAccount(id uuid.UUID) string {
+	return "user:" + id.String()
+}
+
+type WalletRepository struct {
+	db *sql.DB
+}
+
+func NewWalletStorage(db *sql.DB) (*WalletRepository, error) {
+	return &WalletRepository{db: db}, nil
+}
+
+func lockWallet(ctx context.Context, tx queryer, userID uuid.UUID) (money.Amount, error) {
+	const query = `SELECT wallet_usdt FROM users WHERE id = $1 FOR UPDATE`
+
+	var wallet money.Amount
+	err := tx.QueryRowContext(ctx, query, userID).Scan(&wallet)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrUserNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to lock wallet: %w", err)
+	}
+
+	return wallet, nil
+}
+
+func postTransaction(ctx context.Context, tx queryer, txn *LedgerTransaction) error {
+	var sum money.Amount
+	for _, entry := range txn.Entries {
+		if entry.Amount == 0 {
+			return fmt.Errorf("%w: zero amount entry", ErrUnbalancedTransaction)
+		}
+		sum += entry.Amount
+	}
+	if len(txn.Entries) < 2 || sum != 0 {
+		return ErrUnbalancedTransaction
+	}
+
+	if txn.ID == uuid.Nil {
+		txn.ID = uuid.New()
+	}
+	if txn.CreatedAt.IsZero() {
+		txn.CreatedAt = time.Now()
+	}
+
+	const insertTransaction = `
+		INSERT INTO ledger_transactions (id, kind, reference_id, description, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := tx.ExecContext(
+		ctx,
+		insertTransaction,
+		txn.ID,
+		txn.Kind,
+		uuid.NullUUID{UUID: txn.ReferenceID, Valid: txn.ReferenceID != uuid.Nil},
+		txn.Description,
+		txn.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create ledger transaction: %w", err)
+	}
+
+	const insertEntry = `
+		INSERT INTO ledger_entries (transaction_id, account, user_id, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	const updateWallet = `UPDATE users SET wallet_usdt = wallet_usdt + $2 WHERE id = $1`
+
+	for _, entry := range txn.Entries {
+		entry.TransactionID = txn.ID
+		entry.CreatedAt = txn.CreatedAt
+
+		err := tx.QueryRowContext(
+			ctx,
+			insertEntry,
+			entry.TransactionID,
+			entry.Account,
+			uuid.NullUUID{UUID: entry.UserID, Valid: entry.UserID != uuid.Nil},
+			entry.Amount,
+			entry.CreatedAt,
+		).Scan(&entry.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create ledger entry: %w", err)
+		}
+
+		if entry.UserID == uuid.Nil {
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx, updateWallet, entry.UserID, entry.Amount)
+		if err != nil {
+			return fmt.Errorf("failed to update wallet: %w", err)
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return ErrUserNotFound
+		}
+	}
+
+	return nil
+}
+
+func creditRefund(ctx context.Context, tx queryer, userID, referenceID uuid.UUID, amount money.Amount, description string) error {
+	txn := &LedgerTransaction{
+		Kind:        LedgerRefund,
+		ReferenceID: referenceID,
+		Description: description,
+		Entries: []*LedgerEntry{
+			{Account: UserAccount(userID), UserID: userID, Amount: amount},
+			{Account: AccountSales, Amount: -amount},
+		},
+	}
+	if err := postTransaction(ctx, tx, txn); err != nil {
+		return fmt.Errorf("failed to credit refund: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WalletRepository) Post(ctx context.Context, txn *LedgerTransaction) error {
+	tx, err := beginTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	entries := make([]*LedgerEntry, 0, len(txn.Entries))
+	for _, entry := range txn.Entries {
+		if entry.UserID != uuid.Nil {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].UserID[:], entries[j].UserID[:]) < 0
+	})
+
+	for _, entry := range entries {
+		wallet, err := lockWallet(ctx, tx, entry.UserID)
+		if err != nil {
+			return err
+		}
+
+		if wallet+entry.Amount < 0 {
+			return ErrInsufficientFunds
+		}
+	}
+
+	if err := postTransaction(ctx, tx, txn); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WalletRepository) GetEntries(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*WalletEntry, error) {
+	const query = `
+		SELECT id, transaction_id, account, user_id, amount, created_at, kind, reference_id, description, balance
+		FROM (
+			SELECT
+				e.id,
+				e.transaction_id,
+				e.account,
+				e.user_id,
+				e.amount,
+				e.created_at,
+				t.kind,
+				t.reference_id,
+				t.description,
+				SUM(e.amount) OVER (ORDER BY e.id) AS balance
+			FROM ledger_entries e
+			JOIN ledger_transactions t ON t.id = e.transaction_id
+			WHERE e.account = $1
+		) history
+		ORDER BY id DESC
+		OFFSET $2 LIMIT $3`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, UserAccount(userID), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*WalletEntry
+	for rows.Next() {
+		var entry WalletEntry
+		var entryUserID, referenceID uuid.NullUUID
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.Account,
+			&entryUserID,
+			&entry.Amount,
+			&entry.CreatedAt,
+			&entry.Kind,
+			&referenceID,
+			&entry.Description,
+			&entry.Balance,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.UserID = entryUserID.UUID
+		entry.ReferenceID = referenceID.UUID
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *WalletRepository) GetBalance(ctx context.Context, userID uuid.UUID) (money.Amount, money.Amount, error) {
+	const query = `
+		SELECT
+			u.wallet_usdt,
+			COALESCE((SELECT SUM(amount) FROM ledger_entries WHERE account = $2), 0)
+		FROM users u
+		WHERE u.id = $1`
+
+	var cached, ledger money.Amount
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID, UserAccount(userID)).Scan(&cached, &ledger)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, ErrUserNotFound
+	} else if err != nil {
+		return 0, 0, err
+	}
+
+	return cached, ledger, nil
+}
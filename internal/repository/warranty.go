@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrClaimNotFound      = errors.New("warranty claim not found")
+	ErrClaimStatusChanged = errors.New("warranty claim status was changed concurrently")
+	ErrClaimOpen          = errors.New("purchase already has an open warranty claim")
+	ErrWarrantyExpired    = errors.New("warranty coverage has expired")
+	ErrNotCovered         = errors.New("purchase is not covered by warranty")
+)
+
+type WarrantyRepository struct {
+	db *sql.DB
+}
+
+func NewWarrantyStorage(db *sql.DB) (*WarrantyRepository, error) {
+	return &WarrantyRepository{db: db}, nil
+}
+
+func scanClaim(row interface{ Scan(dest ...any) error }) (*WarrantyClaim, error) {
+	var claim WarrantyClaim
+	err := row.Scan(
+		&claim.ID,
+		&claim.PurchaseID,
+		&claim.UserID,
+		&claim.Description,
+		&claim.Status,
+		&claim.CreatedAt,
+		&claim.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &claim, nil
+}
+
+func recordClaimTransition(ctx context.Context, q queryer, transition *ClaimTransition) error {
+	query := `
+		INSERT INTO warranty_claim_history (claim_id, from_status, to_status, actor_id, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, created_at`
+
+	return q.QueryRowContext(
+		ctx,
+		query,
+		transition.ClaimID,
+		sql.NullString{String: transition.FromStatus, Valid: transition.FromStatus != ""},
+		transition.ToStatus,
+		uuid.NullUUID{UUID: transition.ActorID, Valid: transition.ActorID != uuid.Nil},
+		transition.Note,
+	).Scan(&transition.ID, &transition.CreatedAt)
+}
+
+func (r *WarrantyRepository) Create(ctx context.Context, claim *WarrantyClaim) error {
+	tx, err := beginTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	purchase, err := lockPurchase(ctx, tx, claim.PurchaseID)
+	if err != nil {
+		return err
+	}
+	if purchase.UserID != claim.UserID {
+		return ErrPurchaseNotFound
+	}
+	if !purchase.WarrantyUntil.Valid || purchase.ReturnedQuantity >= purchase.Quantity {
+		return ErrNotCovered
+	}
+
+	var expired bool
+	if err := tx.QueryRowContext(ctx, `SELECT $1::timestamp < NOW()`, purchase.WarrantyUntil.Time).Scan(&expired); err != nil {
+		return fmt.Errorf("failed to check warranty coverage: %w", err)
+	}
+	if expired {
+		return ErrWarrantyExpired
+	}
+
+	const openQuery = `
+		SELECT EXISTS(
+			SELECT 1
+			FROM warranty_claims
+			WHERE purchase_id = $1 AND status IN ('submitted', 'in_review', 'approved')
+		)`
+
+	var open bool
+	if err := tx.QueryRowContext(ctx, openQuery, purchase.ID).Scan(&open); err != nil {
+		return fmt.Errorf("failed to check open claims: %w", err)
+	}
+	if open {
+		return ErrClaimOpen
+	}
+
+	claim.Status = "submitted"
+	query := `
+		INSERT INTO warranty_claims (id, purchase_id, user_id, description, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING created_at, updated_at`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		claim.ID,
+		claim.PurchaseID,
+		claim.UserID,
+		claim.Description,
+		claim.Status,
+	).Scan(&claim.CreatedAt, &claim.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create warranty claim: %w", err)
+	}
+
+	transition := &ClaimTransition{ClaimID: claim.ID, ToStatus: claim.Status, ActorID: claim.UserID}
+	if err := recordClaimTransition(ctx, tx, transition); err != nil {
+		return fmt.Errorf("failed to record claim status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WarrantyRepository) Get(ctx context.Context, id uuid.UUID) (*WarrantyClaim, error) {
+	query := `
+		SELECT id, purchase_id, user_id, description, status, created_at, updated_at
+		FROM warranty_claims
+		WHERE id = $1`
+
+	claim, err := scanClaim(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrClaimNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+func (r *WarrantyRepository) GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*WarrantyClaim, error) {
+	query := `
+		SELECT id, purchase_id, user_id, description, status, created_at, updated_at
+		FROM warranty_claims
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		OFFSET $2 LIMIT $3`
+
+	return r.list(ctx, query, userID, offset, limit)
+}
+
+func (r *WarrantyRepository) GetByStatus(ctx context.Context, status string, offset, limit int) ([]*WarrantyClaim, error) {
+	query := `
+		SELECT id, purchase_id, user_id, description, status, created_at, updated_at
+		FROM warranty_claims
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at
+		OFFSET $2 LIMIT $3`
+
+	return r.list(ctx, query, status, offset, limit)
+}
+
+func (r *WarrantyRepository) list(ctx context.Context, query string, args ...any) ([]*WarrantyClaim, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []*WarrantyClaim
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (r *WarrantyRepository) GetHistory(ctx context.Context, claimID uuid.UUID) ([]*ClaimTransition, error) {
+	query := `
+		SELECT id, claim_id, from_status, to_status, actor_id, note, created_at
+		FROM warranty_claim_history
+		WHERE claim_id = $1
+		ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, claimID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*ClaimTransition
+	for rows.Next() {
+		var transition ClaimTransition
+		var fromStatus sql.NullString
+		var actorID uuid.NullUUID
+		err := rows.Scan(
+			&transition.ID,
+			&transition.ClaimID,
+			&fromStatus,
+			&transition.ToStatus,
+			&actorID,
+			&transition.Note,
+			&transition.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transition.FromStatus = fromStatus.String
+		transition.ActorID = actorID.UUID
+		history = append(history, &transition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (r *WarrantyRepository) Transition(ctx context.Context, transition *ClaimTransition) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	const updateQuery = `
+		UPDATE warranty_claims
+		SET status = $3, updated_at = NOW()
+		WHERE id = $1 AND status = $2`
+
+	result, err := tx.ExecContext(ctx, updateQuery, transition.ClaimID, transition.FromStatus, transition.ToStatus)
+	if err != nil {
+		return fmt.Errorf("failed to update claim status: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrClaimStatusChanged
+	}
+
+	if err := recordClaimTransition(ctx, tx, transition); err != nil {
+		return fmt.Errorf("failed to record claim status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const apiKeyPrefix = "vrs_"
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *repository.APIKey) error
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*repository.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*repository.APIKey, error)
+	Revoke(ctx context.Context, userID, id uuid.UUID) error
+	Touch(ctx context.Context, id uuid.UUID) error
+}
+
+type APIKeyService struct {
+	repo APIKeyRepository
+}
+
+func NewAPIKeyService(repo APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func toAPIKey(key *repository.APIKey) *models.APIKey {
+	return &models.APIKey{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  nullTimePtr(key.ExpiresAt),
+		LastUsedAt: nullTimePtr(key.LastUsedAt),
+		RevokedAt:  nullTimePtr(key.RevokedAt),
+	}
+}
+
+func (s *APIKeyService) Create(ctx context.Context, actor models.Actor, request *models.APIKeyRequest) (*models.APIKey, string, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, "", models.ErrAPIKeyName
+	}
+	if len(request.Scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", models.ErrInvalidScope)
+	}
+
+	scopes := make([]string, 0, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		if !slices.Contains(models.APIKeyScopes, scope) {
+			return nil, "", fmt.Errorf("%w: %s", models.ErrInvalidScope, scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	secret, err := generateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the prefix is shown in listings so users can tell their keys apart
+	// without the secret ever being stored
+	id := uuid.New()
+	prefix := apiKeyPrefix + strings.ReplaceAll(id.String(), "-", "")[:8]
+	plain := prefix + "_" + secret
+
+	key := &repository.APIKey{
+		ID:      id,
+		UserID:  actor.UserID,
+		Name:    name,
+		Prefix:  prefix,
+		KeyHash: hashToken(plain),
+		Scopes:  scopes,
+	}
+	if request.ExpiresInDays > 0 {
+		key.ExpiresAt.Time = time.Now().AddDate(0, 0, request.ExpiresInDays)
+		key.ExpiresAt.Valid = true
+	}
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return toAPIKey(key), plain, nil
+}
+
+func (s *APIKeyService) GetAll(ctx context.Context, actor models.Actor) ([]*models.APIKey, error) {
+	keys, err := s.repo.GetByUser(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, toAPIKey(key))
+	}
+
+	return result, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, actor models.Actor, id string) error {
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("api key: %w", models.ErrNotFound)
+	}
+
+	err = s.repo.Revoke(ctx, actor.UserID, keyID)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return fmt.Errorf("api key: %w", models.ErrNotFound)
+	}
+
+	return err
+}
+
+func (s *APIKeyService) Authenticate(ctx context.Context, plain string) (*models.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByHash(ctx, hashToken(plain))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, models.ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt.Valid || (key.ExpiresAt.Valid && time.Now().After(key.ExpiresAt.Time)) {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	if err := s.repo.Touch(ctx, key.ID); err != nil {
+		return nil, err
+	}
+
+	return &models.APIKeyPrincipal{
+		KeyID:  key.ID.String(),
+		UserID: key.UserID,
+		Role:   key.Role,
+		Scopes: key.Scopes,
+	}, nil
+}
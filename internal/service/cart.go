@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type CartRepository interface {
+	AddItem(ctx context.Context, item *repository.CartItem) error
+	GetItems(ctx context.Context, userID uuid.UUID) ([]*repository.CartItem, error)
+	UpdateItem(ctx context.Context, item *repository.CartItem) error
+	DeleteItem(ctx context.Context, userID, id uuid.UUID) error
+	Checkout(ctx context.Context, order *repository.Order, purchases []*repository.Purchase) error
+}
+
+type CartService struct {
+	repo        CartRepository
+	productRepo ProductRepository
+	tx          Transactor
+}
+
+func NewCartService(repo CartRepository, productRepo ProductRepository, tx Transactor) *CartService {
+	return &CartService{
+		repo:        repo,
+		productRepo: productRepo,
+		tx:          tx,
+	}
+}
+
+func (s *CartService) AddItem(ctx context.Context, item *models.CartItem) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if item.Quantity < 1 {
+			return models.ErrInvalidQuantity
+		}
+
+		productID := item.ProductID
+		product, err := s.productRepo.Get(ctx, productID)
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return fmt.Errorf("product: %w", models.ErrNotFound)
+		}
+		if err != nil {
+			return err
+		}
+
+		repoItem := &repository.CartItem{
+			ID:        uuid.New(),
+			UserID:    item.UserID,
+			ProductID: productID,
+			Quantity:  item.Quantity,
+		}
+
+		err = s.repo.AddItem(ctx, repoItem)
+		if err != nil {
+			return err
+		}
+
+		item.ID = repoItem.ID
+		item.ProductName = product.Name
+		item.Cost = product.Cost
+		item.Quantity = repoItem.Quantity
+
+		return nil
+	})
+}
+
+func (s *CartService) GetItems(ctx context.Context, userID uuid.UUID) ([]*models.CartItem, error) {
+	repoItems, err := s.repo.GetItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*models.CartItem
+	for _, repoItem := range repoItems {
+		items = append(items, &models.CartItem{
+			ID:          repoItem.ID,
+			UserID:      repoItem.UserID,
+			ProductID:   repoItem.ProductID,
+			ProductName: repoItem.ProductName,
+			Cost:        repoItem.Cost,
+			Quantity:    repoItem.Quantity,
+		})
+	}
+
+	return items, nil
+}
+
+func (s *CartService) UpdateItem(ctx context.Context, item *models.CartItem) error {
+	if item.Quantity < 1 {
+		return models.ErrInvalidQuantity
+	}
+
+	repoItem := &repository.CartItem{
+		ID:       item.ID,
+		UserID:   item.UserID,
+		Quantity: item.Quantity,
+	}
+
+	err := s.repo.UpdateItem(ctx, repoItem)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("cart item: %w", models.ErrNotFound)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *CartService) DeleteItem(ctx context.Context, userID, id uuid.UUID) error {
+	err := s.repo.DeleteItem(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("cart item: %w", models.ErrNotFound)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *CartService) Checkout(ctx context.Context, userID uuid.UUID) (*models.Order, error) {
+	return withinTx(ctx, s.tx, func(ctx context.Context) (*models.Order, error) {
+		items, err := s.repo.GetItems(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return nil, models.ErrCartEmpty
+		}
+
+		now := time.Now()
+		order := &repository.Order{
+			ID:        uuid.New(),
+			UserID:    userID,
+			CreatedAt: now,
+		}
+
+		var purchases []*repository.Purchase
+		for _, item := range items {
+			purchases = append(purchases, &repository.Purchase{
+				ID:        uuid.New(),
+				OrderID:   order.ID,
+				UserID:    userID,
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				Date:      now,
+			})
+		}
+
+		err = s.repo.Checkout(ctx, order, purchases)
+		if err != nil {
+			if errors.Is(err, repository.ErrCartChanged) {
+				return nil, models.ErrCartChanged
+			}
+			return nil, purchaseError(err)
+		}
+
+		result := orderToModel(order)
+		for _, purchase := range purchases {
+			result.Purchases = append(result.Purchases, &models.Purchase{
+				ID:         purchase.ID,
+				OrderID:    result.ID,
+				UserID:     result.UserID,
+				ProductID:  purchase.ProductID,
+				Quantity:   purchase.Quantity,
+				Date:       purchase.Date,
+				WalletUSDT: purchase.WalletUSDT,
+				Cost:       purchase.Cost,
+			})
+		}
+
+		return result, nil
+	})
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *repository.Category) error
+	Get(ctx context.Context, id uuid.UUID) (*repository.Category, error)
+	GetBySlug(ctx context.Context, slug string) (*repository.Category, error)
+	GetAll(ctx context.Context) ([]*repository.Category, error)
+	Update(ctx context.Context, category *repository.Category) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	SetProductCategories(ctx context.Context, productID uuid.UUID, categoryIDs []uuid.UUID) error
+	GetProductCategories(ctx context.Context, productID uuid.UUID) ([]*repository.Category, error)
+}
+
+const maxCategoryNameLength = 255
+
+type CategoryService struct {
+	repo     CategoryRepository
+	products ProductRepository
+	tx       Transactor
+}
+
+func NewCategoryService(repo CategoryRepository, products ProductRepository, tx Transactor) *CategoryService {
+	return &CategoryService{
+		repo:     repo,
+		products: products,
+		tx:       tx,
+	}
+}
+
+func categoryToModel(category *repository.Category) *models.Category {
+	return &models.Category{
+		ID:       category.ID,
+		ParentID: optionalID(category.ParentID),
+		Name:     category.Name,
+		Slug:     category.Slug,
+		Position: category.Position,
+	}
+}
+
+// slugify lowercases s and joins its runs of ASCII letters and digits with
+// dashes, "VR Headsets & Glasses" becomes "vr-headsets-glasses".
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	return b.String()
+}
+
+func validateCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	category.Slug = slugify(category.Slug)
+
+	switch {
+	case category.Name == "":
+		return fmt.Errorf("%w: name is required", models.ErrInvalidCategory)
+	case len(category.Name) > maxCategoryNameLength:
+		return fmt.Errorf("%w: name must be at most %d characters", models.ErrInvalidCategory, maxCategoryNameLength)
+	case category.Slug == "":
+		return fmt.Errorf("%w: slug needs at least one letter or digit", models.ErrInvalidCategory)
+	case len(category.Slug) > maxCategoryNameLength:
+		return fmt.Errorf("%w: slug must be at most %d characters", models.ErrInvalidCategory, maxCategoryNameLength)
+	}
+
+	return nil
+}
+
+func categoryError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		return fmt.Errorf("category: %w", models.ErrNotFound)
+	case errors.Is(err, repository.ErrCategorySlugTaken):
+		return models.ErrCategorySlugTaken
+	case errors.Is(err, repository.ErrCategoryHasChildren):
+		return models.ErrCategoryHasChildren
+	}
+	return err
+}
+
+// checkParent makes sure parentID exists and, for an existing category id,
+// that it doesn't sit below id in the tree.
+func (s *CategoryService) checkParent(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) error {
+	if parentID == nil {
+		return nil
+	}
+
+	parent, err := s.repo.Get(ctx, *parentID)
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		return fmt.Errorf("%w: parent category %s not found", models.ErrInvalidCategory, *parentID)
+	}
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for !seen[parent.ID] {
+		if parent.ID == id {
+			return models.ErrCategoryCycle
+		}
+		if parent.ParentID == uuid.Nil {
+			return nil
+		}
+		seen[parent.ID] = true
+
+		if parent, err = s.repo.Get(ctx, parent.ParentID); err != nil {
+			return err
+		}
+	}
+
+	return models.ErrCategoryCycle
+}
+
+func (s *CategoryService) Create(ctx context.Context, category *models.Category) error {
+	if category.Slug == "" {
+		category.Slug = category.Name
+	}
+	if err := validateCategory(category); err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.checkParent(ctx, uuid.Nil, category.ParentID); err != nil {
+			return err
+		}
+
+		repoCategory := &repository.Category{
+			ID:       uuid.New(),
+			Name:     category.Name,
+			Slug:     category.Slug,
+			Position: category.Position,
+		}
+		if category.ParentID != nil {
+			repoCategory.ParentID = *category.ParentID
+		}
+
+		if err := s.repo.Create(ctx, repoCategory); err != nil {
+			return categoryError(err)
+		}
+		category.ID = repoCategory.ID
+
+		return nil
+	})
+}
+
+// Update replaces the fields of a category, moving it under another parent
+// when ParentID changes. An empty slug keeps the current one.
+func (s *CategoryService) Update(ctx context.Context, category *models.Category) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.Get(ctx, category.ID)
+		if err != nil {
+			return categoryError(err)
+		}
+
+		if category.Slug == "" {
+			category.Slug = existing.Slug
+		}
+		if err := validateCategory(category); err != nil {
+			return err
+		}
+		if err := s.checkParent(ctx, category.ID, category.ParentID); err != nil {
+			return err
+		}
+
+		existing.Name = category.Name
+		existing.Slug = category.Slug
+		existing.Position = category.Position
+		existing.ParentID = uuid.Nil
+		if category.ParentID != nil {
+			existing.ParentID = *category.ParentID
+		}
+
+		return categoryError(s.repo.Update(ctx, existing))
+	})
+}
+
+func (s *CategoryService) Delete(ctx context.Context, id uuid.UUID) error {
+	return categoryError(s.repo.Delete(ctx, id))
+}
+
+// tree links categories to their parents and returns them by id together
+// with the top level ones, both keeping the order of categories.
+func tree(categories []*repository.Category) (map[uuid.UUID]*models.Category, []*models.Category) {
+	nodes := make(map[uuid.UUID]*models.Category, len(categories))
+	for _, category := range categories {
+		node := categoryToModel(category)
+		node.Children = []*models.Category{}
+		nodes[category.ID] = node
+	}
+
+	roots := []*models.Category{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if parent, ok := nodes[category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+
+	return nodes, roots
+}
+
+// GetTree returns the top level categories with their subcategories nested
+// below them.
+func (s *CategoryService) GetTree(ctx context.Context) ([]*models.Category, error) {
+	categories, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, roots := tree(categories)
+	return roots, nil
+}
+
+// Get returns a category with its subcategories nested below it.
+func (s *CategoryService) Get(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	categories, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, _ := tree(categories)
+	category, ok := nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("category: %w", models.ErrNotFound)
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	category, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	return s.Get(ctx, category.ID)
+}
+
+// GetProducts lists the products of a category and of all categories below
+// it.
+func (s *CategoryService) GetProducts(ctx context.Context, id uuid.UUID, limit, offset string) ([]*models.Product, error) {
+	limitInt, offsetInt, err := parsePagination(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		return nil, categoryError(err)
+	}
+
+	repoProducts, err := s.products.GetByCategory(ctx, id, offsetInt, limitInt)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*models.Product, 0, len(repoProducts))
+	for _, repoProduct := range repoProducts {
+		products = append(products, &models.Product{
+			ID:            repoProduct.ID,
+			SKU:           repoProduct.SKU,
+			Name:          repoProduct.Name,
+			Cost:          repoProduct.Cost,
+			QuantityStock: repoProduct.QuantityStock,
+			WarrantyDays:  repoProduct.WarrantyDays,
+			Country:       repoProduct.Country,
+			Like:          repoProduct.Like,
+		})
+	}
+
+	return products, nil
+}
+
+func (s *CategoryService) GetProductCategories(ctx context.Context, productID uuid.UUID) ([]*models.Category, error) {
+	if _, err := s.products.Get(ctx, productID); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, fmt.Errorf("product: %w", models.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	repoCategories, err := s.repo.GetProductCategories(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make([]*models.Category, 0, len(repoCategories))
+	for _, category := range repoCategories {
+		categories = append(categories, categoryToModel(category))
+	}
+
+	return categories, nil
+}
+
+// SetProductCategories replaces the categories of a product. An empty list
+// takes the product out of every category.
+func (s *CategoryService) SetProductCategories(ctx context.Context, productID uuid.UUID, categoryIDs []uuid.UUID) ([]*models.Category, error) {
+	return withinTx(ctx, s.tx, func(ctx context.Context) ([]*models.Category, error) {
+		if _, err := s.products.Get(ctx, productID); err != nil {
+			if errors.Is(err, repository.ErrProductNotFound) {
+				return nil, fmt.Errorf("product: %w", models.ErrNotFound)
+			}
+			return nil, err
+		}
+
+		seen := make(map[uuid.UUID]bool, len(categoryIDs))
+		ids := make([]uuid.UUID, 0, len(categoryIDs))
+		for _, id := range categoryIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			if _, err := s.repo.Get(ctx, id); err != nil {
+				if errors.Is(err, repository.ErrCategoryNotFound) {
+					return nil, fmt.Errorf("%w: category %s not found", models.ErrInvalidCategory, id)
+				}
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+
+		if err := s.repo.SetProductCategories(ctx, productID, ids); err != nil {
+			return nil, err
+		}
+
+		return s.GetProductCategories(ctx, productID)
+	})
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vr-shope/internal/models"
+	"vr-shope/internal/payment"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type DepositRepository interface {
+	Create(ctx context.Context, deposit *repository.Deposit) error
+	Get(ctx context.Context, id uuid.UUID) (*repository.Deposit, error)
+	GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.Deposit, error)
+	SetStatus(ctx context.Context, id uuid.UUID, status string, settledBy uuid.UUID) error
+	Confirm(ctx context.Context, id uuid.UUID, settledBy uuid.UUID) error
+}
+
+type DepositService struct {
+	repo     DepositRepository
+	provider payment.Provider
+}
+
+func NewDepositService(repo DepositRepository, provider payment.Provider) *DepositService {
+	return &DepositService{
+		repo:     repo,
+		provider: provider,
+	}
+}
+
+func depositToModel(deposit *repository.Deposit) *models.Deposit {
+	result := &models.Deposit{
+		ID:         deposit.ID,
+		UserID:     deposit.UserID,
+		Amount:     deposit.Amount,
+		Status:     deposit.Status,
+		Provider:   deposit.Provider,
+		Reference:  deposit.ProviderRef,
+		PayAddress: deposit.PayAddress,
+		ExpiresAt:  deposit.ExpiresAt,
+		CreatedAt:  deposit.CreatedAt,
+		SettledBy:  optionalID(deposit.SettledBy),
+	}
+	if deposit.ConfirmedAt.Valid {
+		confirmedAt := deposit.ConfirmedAt.Time
+		result.ConfirmedAt = &confirmedAt
+	}
+
+	return result
+}
+
+func (s *DepositService) Create(ctx context.Context, deposit *models.Deposit) error {
+	if deposit.Amount <= 0 {
+		return models.ErrInvalidAmount
+	}
+
+	intent, err := s.provider.CreateIntent(ctx, deposit.Amount)
+	if err != nil {
+		return fmt.Errorf("failed to create deposit intent: %w", err)
+	}
+
+	repoDeposit := &repository.Deposit{
+		ID:          uuid.New(),
+		UserID:      deposit.UserID,
+		Amount:      deposit.Amount,
+		Status:      repository.DepositPending,
+		Provider:    s.provider.Name(),
+		ProviderRef: intent.Reference,
+		PayAddress:  intent.Address,
+		ExpiresAt:   intent.ExpiresAt,
+	}
+
+	err = s.repo.Create(ctx, repoDeposit)
+	if err != nil {
+		return err
+	}
+
+	*deposit = *depositToModel(repoDeposit)
+
+	return nil
+}
+
+func (s *DepositService) Get(ctx context.Context, userID, id uuid.UUID) (*models.Deposit, error) {
+	deposit, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrDepositNotFound) {
+			return nil, fmt.Errorf("deposit: %w", models.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	if deposit.UserID != userID {
+		return nil, fmt.Errorf("deposit: %w", models.ErrNotFound)
+	}
+
+	deposit, err = s.refresh(ctx, deposit)
+	if err != nil {
+		return nil, err
+	}
+
+	return depositToModel(deposit), nil
+}
+
+func (s *DepositService) GetAll(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.Deposit, error) {
+	limitInt, offsetInt, err := parsePagination(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	repoDeposits, err := s.repo.GetByUser(ctx, userID, offsetInt, limitInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var deposits []*models.Deposit
+	for _, repoDeposit := range repoDeposits {
+		repoDeposit, err = s.refresh(ctx, repoDeposit)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, depositToModel(repoDeposit))
+	}
+
+	return deposits, nil
+}
+
+func (s *DepositService) refresh(ctx context.Context, deposit *repository.Deposit) (*repository.Deposit, error) {
+	if deposit.Status != repository.DepositPending || deposit.Provider != s.provider.Name() {
+		return deposit, nil
+	}
+
+	// an intent the provider no longer knows, e.g. a simulated one lost on
+	// restart, can never be paid
+	status := payment.StatusFailed
+	intent, err := s.provider.GetIntent(ctx, deposit.ProviderRef)
+	switch {
+	case err == nil:
+		status = intent.Status
+	case !errors.Is(err, payment.ErrIntentNotFound):
+		return nil, fmt.Errorf("failed to check deposit intent: %w", err)
+	}
+
+	if status == payment.StatusPending && time.Now().After(deposit.ExpiresAt) {
+		status = payment.StatusExpired
+	}
+
+	switch status {
+	case payment.StatusConfirmed:
+		if intent.Amount != deposit.Amount {
+			err = s.repo.SetStatus(ctx, deposit.ID, repository.DepositFailed, uuid.Nil)
+		} else {
+			err = s.repo.Confirm(ctx, deposit.ID, uuid.Nil)
+		}
+	case payment.StatusFailed:
+		err = s.repo.SetStatus(ctx, deposit.ID, repository.DepositFailed, uuid.Nil)
+	case payment.StatusExpired:
+		err = s.repo.SetStatus(ctx, deposit.ID, repository.DepositExpired, uuid.Nil)
+	default:
+		return deposit, nil
+	}
+	if err != nil && !errors.Is(err, repository.ErrDepositNotPending) {
+		return nil, err
+	}
+
+	return s.repo.Get(ctx, deposit.ID)
+}
+
+// Confirm settles a pending deposit as paid by hand. Only providers that
+// implement payment.Settler support it, and staff can't settle their own
+// deposits.
+func (s *DepositService) Confirm(ctx context.Context, actorID, id uuid.UUID) (*models.Deposit, error) {
+	return s.settle(ctx, actorID, id, true)
+}
+
+// Fail settles a pending deposit as not paid by hand.
+func (s *DepositService) Fail(ctx context.Context, actorID, id uuid.UUID) (*models.Deposit, error) {
+	return s.settle(ctx, actorID, id, false)
+}
+
+func (s *DepositService) settle(ctx context.Context, actorID, id uuid.UUID, confirm bool) (*models.Deposit, error) {
+	settler, ok := s.provider.(payment.Settler)
+	if !ok {
+		return nil, models.ErrSettlementNotAllowed
+	}
+
+	deposit, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrDepositNotFound) {
+			return nil, fmt.Errorf("deposit: %w", models.ErrNotFound)
+		}
+		return nil, err
+	}
+	if deposit.Provider != s.provider.Name() {
+		return nil, models.ErrSettlementNotAllowed
+	}
+	if deposit.UserID == actorID {
+		return nil, fmt.Errorf("%w: can not settle your own deposit", models.ErrForbidden)
+	}
+
+	// picks up expiry and lost intents first
+	deposit, err = s.refresh(ctx, deposit)
+	if err != nil {
+		return nil, err
+	}
+	if deposit.Status != repository.DepositPending {
+		return nil, models.ErrDepositNotPending
+	}
+
+	if confirm {
+		err = settler.Confirm(deposit.ProviderRef)
+	} else {
+		err = settler.Fail(deposit.ProviderRef)
+	}
+	if err != nil {
+		if errors.Is(err, payment.ErrIntentSettled) {
+			return nil, models.ErrDepositNotPending
+		}
+		return nil, fmt.Errorf("failed to settle deposit intent: %w", err)
+	}
+
+	if confirm {
+		err = s.repo.Confirm(ctx, id, actorID)
+	} else {
+		err = s.repo.SetStatus(ctx, id, repository.DepositFailed, actorID)
+	}
+	if errors.Is(err, repository.ErrDepositNotPending) {
+		return nil, models.ErrDepositNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deposit, err = s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return depositToModel(deposit), nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type LegacyIDRepository interface {
+	Backfill(ctx context.Context) error
+	Resolve(ctx context.Context, legacyID uint64) (uuid.UUID, error)
+}
+
+type LegacyIDService struct {
+	repo LegacyIDRepository
+}
+
+func NewLegacyIDService(repo LegacyIDRepository) *LegacyIDService {
+	return &LegacyIDService{repo: repo}
+}
+
+func (s *LegacyIDService) Backfill(ctx context.Context) error {
+	return s.repo.Backfill(ctx)
+}
+
+func (s *LegacyIDService) Resolve(ctx context.Context, legacyID uint64) (uuid.UUID, error) {
+	id, err := s.repo.Resolve(ctx, legacyID)
+	if err != nil {
+		if errors.Is(err, repository.ErrLegacyIDNotFound) {
+			return uuid.Nil, fmt.Errorf("legacy id %d: %w", legacyID, models.ErrNotFound)
+		}
+		return uuid.Nil, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vr-shope/internal/mailer"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type LockoutPolicy struct {
+	MaxAttempts   int
+	IPMaxAttempts int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	Duration      time.Duration
+	Window        time.Duration
+}
+
+var defaultLockoutPolicy = LockoutPolicy{
+	MaxAttempts:   5,
+	IPMaxAttempts: 50,
+	BaseDelay:     time.Second,
+	MaxDelay:      time.Minute,
+	Duration:      15 * time.Minute,
+	Window:        time.Hour,
+}
+
+func (p LockoutPolicy) withDefaults() LockoutPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultLockoutPolicy.MaxAttempts
+	}
+	if p.IPMaxAttempts <= 0 {
+		p.IPMaxAttempts = defaultLockoutPolicy.IPMaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultLockoutPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultLockoutPolicy.MaxDelay
+	}
+	if p.Duration <= 0 {
+		p.Duration = defaultLockoutPolicy.Duration
+	}
+	if p.Window <= 0 {
+		p.Window = defaultLockoutPolicy.Window
+	}
+	return p
+}
+
+// delay returns how long the subject has to wait after its n-th failure: the
+// first failure is free, then the wait doubles up to MaxDelay until maxAttempts
+// is reached and the full lockout applies.
+func (p LockoutPolicy) delay(failures, maxAttempts int) time.Duration {
+	if failures >= maxAttempts {
+		return p.Duration
+	}
+	if failures < 2 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 2; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+type LockoutRepository interface {
+	RecordAttempt(ctx context.Context, scope, subject string, window time.Duration) (int, time.Time, error)
+	Forgive(ctx context.Context, scope, subject string) error
+	Lock(ctx context.Context, scope, subject string, until time.Time) error
+	Reset(ctx context.Context, scope, subject string) (bool, error)
+	GetLocked(ctx context.Context) ([]*repository.LoginThrottle, error)
+}
+
+type LockoutService struct {
+	repo     LockoutRepository
+	userRepo UserRepository
+	mailer   mailer.Mailer
+	from     string
+	policy   LockoutPolicy
+}
+
+func NewLockoutService(repo LockoutRepository, userRepo UserRepository, mail mailer.Mailer, from string, policy LockoutPolicy) *LockoutService {
+	return &LockoutService{
+		repo:     repo,
+		userRepo: userRepo,
+		mailer:   mail,
+		from:     from,
+		policy:   policy.withDefaults(),
+	}
+}
+
+// LoginAttempt is an attempt counted by Attempt, to be settled with Failure or
+// Success once the credentials are checked.
+type LoginAttempt struct {
+	Login         string
+	IP            string
+	loginAttempts int
+	ipAttempts    int
+}
+
+// count records one attempt for subject and refuses it while the subject is
+// locked or once it went over maxAttempts in the current window.
+func (s *LockoutService) count(ctx context.Context, scope, subject string, maxAttempts int) (int, error) {
+	attempts, lockedUntil, err := s.repo.RecordAttempt(ctx, scope, subject, s.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	if lockedUntil.After(time.Now()) {
+		return 0, &models.LockedError{Until: lockedUntil}
+	}
+	if attempts > maxAttempts {
+		until := time.Now().Add(s.policy.Duration)
+		if err := s.repo.Lock(ctx, scope, subject, until); err != nil {
+			return 0, err
+		}
+		return 0, &models.LockedError{Until: until}
+	}
+
+	return attempts, nil
+}
+
+// Attempt counts a login attempt for both the login and the client address
+// before the credentials are verified, so parallel guesses can't all slip in
+// under the limit before the first failure is written.
+func (s *LockoutService) Attempt(ctx context.Context, login, ip string) (*LoginAttempt, error) {
+	attempt := &LoginAttempt{Login: login, IP: ip}
+
+	var err error
+	if ip != "" {
+		if attempt.ipAttempts, err = s.count(ctx, repository.ThrottleIP, ip, s.policy.IPMaxAttempts); err != nil {
+			return nil, err
+		}
+	}
+	if attempt.loginAttempts, err = s.count(ctx, repository.ThrottleLogin, login, s.policy.MaxAttempts); err != nil {
+		return nil, err
+	}
+
+	return attempt, nil
+}
+
+func (s *LockoutService) delay(ctx context.Context, scope, subject string, attempts, maxAttempts int) (time.Time, error) {
+	delay := s.policy.delay(attempts, maxAttempts)
+	if delay == 0 {
+		return time.Time{}, nil
+	}
+
+	until := time.Now().Add(delay)
+	if err := s.repo.Lock(ctx, scope, subject, until); err != nil {
+		return time.Time{}, err
+	}
+
+	return until, nil
+}
+
+// Failure applies the backoff for a failed attempt. user is nil when the login
+// does not exist; the counters are kept anyway so unknown logins behave
+// exactly like known ones.
+func (s *LockoutService) Failure(ctx context.Context, attempt *LoginAttempt, user *repository.User) error {
+	if attempt.IP != "" {
+		if _, err := s.delay(ctx, repository.ThrottleIP, attempt.IP, attempt.ipAttempts, s.policy.IPMaxAttempts); err != nil {
+			return err
+		}
+	}
+
+	until, err := s.delay(ctx, repository.ThrottleLogin, attempt.Login, attempt.loginAttempts, s.policy.MaxAttempts)
+	if err != nil {
+		return err
+	}
+
+	// notify once, when the account crosses into a full lockout
+	if user != nil && user.Email != "" && attempt.loginAttempts == s.policy.MaxAttempts {
+		return s.notify(ctx, user, attempt.loginAttempts, until)
+	}
+
+	return nil
+}
+
+func (s *LockoutService) notify(ctx context.Context, user *repository.User, failures int, until time.Time) error {
+	body := fmt.Sprintf(
+		"Hi %s,\n\nafter %d failed sign-in attempts your account has been locked until %s.\n\n"+
+			"If this wasn't you, someone may be trying to guess your password; "+
+			"consider resetting it and enabling two-factor authentication.\n",
+		user.Login,
+		failures,
+		until.Format(time.RFC1123),
+	)
+
+	err := s.mailer.Send(ctx, mailer.Message{
+		From:    s.from,
+		To:      user.Email,
+		Subject: "Your account has been temporarily locked",
+		Body:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send lockout notification: %w", err)
+	}
+
+	return nil
+}
+
+func (s *LockoutService) Success(ctx context.Context, attempt *LoginAttempt) error {
+	// the address counter only takes back this attempt and otherwise decays
+	// with the window, so a single valid account can't be used to reset it
+	if attempt.IP != "" {
+		if err := s.repo.Forgive(ctx, repository.ThrottleIP, attempt.IP); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.repo.Reset(ctx, repository.ThrottleLogin, attempt.Login)
+	return err
+}
+
+func (s *LockoutService) UnlockUser(ctx context.Context, id uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("user: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.Reset(ctx, repository.ThrottleLogin, user.Login)
+	return err
+}
+
+func (s *LockoutService) UnlockIP(ctx context.Context, ip string) error {
+	_, err := s.repo.Reset(ctx, repository.ThrottleIP, ip)
+	return err
+}
+
+func (s *LockoutService) GetLocked(ctx context.Context) ([]*models.Lockout, error) {
+	throttles, err := s.repo.GetLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lockouts := make([]*models.Lockout, 0, len(throttles))
+	for _, throttle := range throttles {
+		lockouts = append(lockouts, &models.Lockout{
+			Scope:         throttle.Scope,
+			Subject:       throttle.Subject,
+			Failures:      throttle.Failures,
+			LastFailureAt: throttle.LastFailureAt,
+			LockedUntil:   throttle.LockedUntil.Time,
+		})
+	}
+
+	return lockouts, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var orderTransitions = map[string][]string{
+	models.OrderCreated:   {models.OrderPaid, models.OrderCancelled},
+	models.OrderPaid:      {models.OrderFulfilled, models.OrderCancelled},
+	models.OrderFulfilled: {models.OrderShipped, models.OrderCancelled},
+	models.OrderShipped:   {models.OrderDelivered},
+	models.OrderDelivered: {models.OrderRefunded},
+	models.OrderCancelled: {},
+	models.OrderRefunded:  {},
+}
+
+func CanTransitionOrder(from, to string) bool {
+	return slices.Contains(orderTransitions[from], to)
+}
+
+type OrderRepository interface {
+	Get(ctx context.Context, id uuid.UUID) (*repository.Order, error)
+	GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.Order, error)
+	GetPurchases(ctx context.Context, orderID uuid.UUID) ([]*repository.Purchase, error)
+	GetHistory(ctx context.Context, orderID uuid.UUID) ([]*repository.OrderTransition, error)
+	Transition(ctx context.Context, transition *repository.OrderTransition, refund bool) error
+}
+
+type OrderService struct {
+	repo OrderRepository
+	tx   Transactor
+}
+
+func NewOrderService(repo OrderRepository, tx Transactor) *OrderService {
+	return &OrderService{
+		repo: repo,
+		tx:   tx,
+	}
+}
+
+func orderToModel(order *repository.Order) *models.Order {
+	return &models.Order{
+		ID:        order.ID,
+		UserID:    order.UserID,
+		Status:    order.Status,
+		Total:     order.Total,
+		Date:      order.CreatedAt,
+		UpdatedAt: order.UpdatedAt,
+	}
+}
+
+func (s *OrderService) Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.Order, error) {
+	order, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.CanAccess(order.UserID) {
+		return nil, models.ErrForbidden
+	}
+
+	return order, nil
+}
+
+func (s *OrderService) get(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	repoOrder, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, fmt.Errorf("order: %w", models.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	order := orderToModel(repoOrder)
+
+	purchases, err := s.repo.GetPurchases(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, purchase := range purchases {
+		order.Purchases = append(order.Purchases, &models.Purchase{
+			ID:         purchase.ID,
+			OrderID:    order.ID,
+			UserID:     purchase.UserID,
+			ProductID:  purchase.ProductID,
+			Quantity:   purchase.Quantity,
+			Date:       purchase.Date,
+			WalletUSDT: purchase.WalletUSDT,
+			Cost:       purchase.Cost,
+		})
+	}
+
+	history, err := s.repo.GetHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, transition := range history {
+		order.History = append(order.History, &models.OrderTransition{
+			From:    transition.FromStatus,
+			To:      transition.ToStatus,
+			ActorID: optionalID(transition.ActorID),
+			Note:    transition.Note,
+			Date:    transition.CreatedAt,
+		})
+	}
+
+	return order, nil
+}
+
+func (s *OrderService) GetByUser(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.Order, error) {
+	limitInt, offsetInt, err := parsePagination(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	repoOrders, err := s.repo.GetByUser(ctx, userID, offsetInt, limitInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []*models.Order
+	for _, repoOrder := range repoOrders {
+		orders = append(orders, orderToModel(repoOrder))
+	}
+
+	return orders, nil
+}
+
+func (s *OrderService) Transition(ctx context.Context, id, actorID uuid.UUID, status, note string) (*models.Order, error) {
+	return withinTx(ctx, s.tx, func(ctx context.Context) (*models.Order, error) {
+		if _, ok := orderTransitions[status]; !ok {
+			return nil, fmt.Errorf("%w: %q", models.ErrInvalidStatus, status)
+		}
+		if status == models.OrderPaid {
+			return nil, fmt.Errorf("%w: orders are paid at checkout", models.ErrInvalidTransition)
+		}
+
+		order, err := s.repo.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrOrderNotFound) {
+				return nil, fmt.Errorf("order: %w", models.ErrNotFound)
+			}
+			return nil, err
+		}
+
+		if !CanTransitionOrder(order.Status, status) {
+			return nil, fmt.Errorf("%w: %s -> %s", models.ErrInvalidTransition, order.Status, status)
+		}
+
+		refund := status == models.OrderRefunded ||
+			(status == models.OrderCancelled && order.Status != models.OrderCreated)
+
+		transition := &repository.OrderTransition{
+			OrderID:    id,
+			FromStatus: order.Status,
+			ToStatus:   status,
+			ActorID:    actorID,
+			Note:       note,
+		}
+
+		err = s.repo.Transition(ctx, transition, refund)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrOrderNotFound):
+				return nil, fmt.Errorf("order: %w", models.ErrNotFound)
+			case errors.Is(err, repository.ErrOrderStatusChanged):
+				return nil, models.ErrConcurrentUpdate
+			}
+			return nil, err
+		}
+
+		return s.get(ctx, id)
+	})
+}
+
+func (s *OrderService) Cancel(ctx context.Context, actor models.Actor, id uuid.UUID, note string) (*models.Order, error) {
+	return withinTx(ctx, s.tx, func(ctx context.Context) (*models.Order, error) {
+		order, err := s.repo.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrOrderNotFound) {
+				return nil, fmt.Errorf("order: %w", models.ErrNotFound)
+			}
+			return nil, err
+		}
+
+		if !actor.CanAccess(order.UserID) {
+			return nil, models.ErrForbidden
+		}
+
+		return s.Transition(ctx, id, actor.UserID, models.OrderCancelled, note)
+	})
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 2
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+var errInvalidHash = errors.New("invalid password hash format")
+
+// dummyHash is checked against when a login is unknown, so the response takes
+// as long as for a wrong password and doesn't tell which logins exist.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("dummy password")
+	return hash
+})
+
+type argonParams struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		argonMemory,
+		argonTime,
+		argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func decodeArgonHash(encoded string) (*argonParams, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	var params argonParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	return &params, salt, key, nil
+}
+
+func isArgonHash(storedHash string) bool {
+	return strings.HasPrefix(storedHash, "$argon2id$")
+}
+
+func CheckPassword(password, storedHash, storedSalt string) (bool, error) {
+	if isArgonHash(storedHash) {
+		params, salt, key, err := decodeArgonHash(storedHash)
+		if err != nil {
+			return false, err
+		}
+
+		computed := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(computed, key) == 1, nil
+	}
+
+	// legacy accounts: a single round of salted SHA-256, hex encoded
+	storedHashBytes, err := hex.DecodeString(storedHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode stored hash: %w", err)
+	}
+
+	storedSaltBytes, err := hex.DecodeString(storedSalt)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode stored salt: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write(storedSaltBytes)
+	hash.Write([]byte(password))
+	computedHash := hash.Sum(nil)
+
+	return subtle.ConstantTimeCompare(computedHash, storedHashBytes) == 1, nil
+}
+
+func NeedsRehash(storedHash string) bool {
+	if !isArgonHash(storedHash) {
+		return true
+	}
+
+	params, _, key, err := decodeArgonHash(storedHash)
+	if err != nil {
+		return true
+	}
+
+	return params.memory != argonMemory ||
+		params.time != argonTime ||
+		params.threads != argonThreads ||
+		len(key) != argonKeyLen
+}
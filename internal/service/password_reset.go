@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"vr-shope/internal/mailer"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const defaultPasswordResetTTL = 30 * time.Minute
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *repository.PasswordResetToken) error
+	Reset(ctx context.Context, tokenHash, hashedPassword, salt string) error
+	SetPassword(ctx context.Context, userID uuid.UUID, hashedPassword, salt string) error
+}
+
+type PasswordResetService struct {
+	repo     PasswordResetRepository
+	userRepo UserRepository
+	mailer   mailer.Mailer
+	from     string
+	resetURL string
+	ttl      time.Duration
+}
+
+func NewPasswordResetService(repo PasswordResetRepository, userRepo UserRepository, mail mailer.Mailer, from, resetURL string, ttl time.Duration) *PasswordResetService {
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTTL
+	}
+
+	return &PasswordResetService{
+		repo:     repo,
+		userRepo: userRepo,
+		mailer:   mail,
+		from:     from,
+		resetURL: resetURL,
+		ttl:      ttl,
+	}
+}
+
+func (s *PasswordResetService) resetLink(token string) (string, error) {
+	link, err := url.Parse(s.resetURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid password reset url: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
+
+func (s *PasswordResetService) Forgot(ctx context.Context, email string) error {
+	if !IsValidEmail(email) {
+		return models.ErrInvalidEmail
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// answer the same way for unknown emails so accounts can't be probed
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	err = s.repo.Create(ctx, &repository.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := s.resetLink(token)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nsomeone asked to reset the password of your account. "+
+			"Follow the link below to choose a new one:\n\n%s\n\n"+
+			"The link can be used once and expires at %s. "+
+			"If you did not ask for a reset you can ignore this email.\n",
+		user.Login,
+		link,
+		expiresAt.Format(time.RFC1123),
+	)
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		From:    s.from,
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send reset email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PasswordResetService) Reset(ctx context.Context, token, password string) error {
+	if token == "" {
+		return models.ErrInvalidToken
+	}
+	if password == "" {
+		return models.ErrInvalidPassword
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Reset(ctx, hashToken(token), hashedPassword, "")
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrResetTokenNotFound),
+			errors.Is(err, repository.ErrResetTokenExpired),
+			errors.Is(err, repository.ErrResetTokenUsed),
+			errors.Is(err, repository.ErrUserNotFound):
+			return fmt.Errorf("%w: %v", models.ErrInvalidToken, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetPassword lets an operator replace a password without a reset link. All
+// sessions of the user end, as with a regular reset.
+func (s *PasswordResetService) SetPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	if password == "" {
+		return models.ErrInvalidPassword
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.SetPassword(ctx, userID, hashedPassword, "")
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return fmt.Errorf("user: %w", models.ErrNotFound)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -8,55 +8,102 @@ import (
 	"strconv"
 	"vr-shope/internal/models"
 	"vr-shope/internal/repository"
-	"vr-shope/internal/uuids"
 
 	"github.com/google/uuid"
 )
 
+type ProductRepository interface {
+	Create(ctx context.Context, product *repository.Product) error
+	Get(ctx context.Context, id uuid.UUID) (*repository.Product, error)
+	GetAll(ctx context.Context) ([]*repository.Product, error)
+	Update(ctx context.Context, product *repository.Product) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	AddLike(ctx context.Context, id uuid.UUID) error
+	RemoveLike(ctx context.Context, id uuid.UUID) error
+	GetBySKU(ctx context.Context, sku string) (*repository.Product, error)
+	GetForName(ctx context.Context, name string) ([]*repository.Product, error)
+	GetProducts(ctx context.Context, offset, limit int) ([]*repository.Product, error)
+	GetByCategory(ctx context.Context, categoryID uuid.UUID, offset, limit int) ([]*repository.Product, error)
+}
+
 type ProductService struct {
-	repo *repository.ProductRepository
+	repo ProductRepository
+	tx   Transactor
 }
 
-func NewProductService(repo *repository.ProductRepository) *ProductService {
-	return &ProductService{repo}
+func NewProductService(repo ProductRepository, tx Transactor) *ProductService {
+	return &ProductService{
+		repo: repo,
+		tx:   tx,
+	}
+}
+
+const (
+	maxSKULength    = 64
+	exportBatchSize = 500
+)
+
+func validateProduct(product *models.Product) error {
+	switch {
+	case product.Name == "":
+		return fmt.Errorf("%w: product name is required", models.ErrInvalidProduct)
+	case len(product.SKU) > maxSKULength:
+		return fmt.Errorf("%w: sku must be at most %d characters", models.ErrInvalidProduct, maxSKULength)
+	case product.Cost < 0:
+		return fmt.Errorf("%w: cost must not be negative", models.ErrInvalidProduct)
+	case product.QuantityStock < 0:
+		return fmt.Errorf("%w: stock must not be negative", models.ErrInvalidProduct)
+	case product.WarrantyDays < 0:
+		return fmt.Errorf("%w: warranty days must not be negative", models.ErrInvalidProduct)
+	}
+
+	return nil
 }
 
 func (s *ProductService) Create(ctx context.Context, product *models.Product) error {
-	if product.Name == "" {
-		return fmt.Errorf("product name is required")
+	if err := validateProduct(product); err != nil {
+		return err
 	}
 
-	productID := uuid.New()
 	repoProduct := &repository.Product{
-		ID:            productID,
+		ID:            uuid.New(),
+		SKU:           product.SKU,
 		Name:          product.Name,
 		Cost:          product.Cost,
 		QuantityStock: product.QuantityStock,
-		Guarantees:    product.Guarantees,
+		WarrantyDays:  product.WarrantyDays,
 		Country:       product.Country,
 		Like:          product.Like,
 	}
 
 	err := s.repo.Create(ctx, repoProduct)
+	if errors.Is(err, repository.ErrSKUTaken) {
+		return fmt.Errorf("product %s: %w", product.SKU, models.ErrSKUTaken)
+	}
 	if err != nil {
 		return err
 	}
+	product.ID = repoProduct.ID
 
 	return nil
 }
 
-func (s *ProductService) Get(ctx context.Context, id int) (*models.Product, error) {
-	repoProduct, err := s.repo.Get(ctx, uuids.IntToUUID(int64(id)))
+func (s *ProductService) Get(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	repoProduct, err := s.repo.Get(ctx, id)
 	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, fmt.Errorf("product: %w", models.ErrNotFound)
+		}
 		return nil, err
 	}
 
 	return &models.Product{
-		ID:            uuids.UUIDToInt(repoProduct.ID),
+		ID:            repoProduct.ID,
+		SKU:           repoProduct.SKU,
 		Name:          repoProduct.Name,
 		Cost:          repoProduct.Cost,
 		QuantityStock: repoProduct.QuantityStock,
-		Guarantees:    repoProduct.Guarantees,
+		WarrantyDays:  repoProduct.WarrantyDays,
 		Country:       repoProduct.Country,
 		Like:          repoProduct.Like,
 	}, nil
@@ -71,11 +118,12 @@ func (s *ProductService) GetAll(ctx context.Context) ([]*models.Product, error)
 	var products []*models.Product
 	for _, repoProduct := range repoProducts {
 		products = append(products, &models.Product{
-			ID:            uuids.UUIDToInt(repoProduct.ID),
+			ID:            repoProduct.ID,
+			SKU:           repoProduct.SKU,
 			Name:          repoProduct.Name,
 			Cost:          repoProduct.Cost,
 			QuantityStock: repoProduct.QuantityStock,
-			Guarantees:    repoProduct.Guarantees,
+			WarrantyDays:  repoProduct.WarrantyDays,
 			Country:       repoProduct.Country,
 			Like:          repoProduct.Like,
 		})
@@ -85,21 +133,25 @@ func (s *ProductService) GetAll(ctx context.Context) ([]*models.Product, error)
 }
 
 func (s *ProductService) Update(ctx context.Context, product *models.Product) error {
-	if product.Name == "" {
-		return fmt.Errorf("product name is required")
+	if err := validateProduct(product); err != nil {
+		return err
 	}
 
 	repoProduct := &repository.Product{
-		ID:            uuids.IntToUUID(int64(product.ID)),
+		ID:            product.ID,
+		SKU:           product.SKU,
 		Name:          product.Name,
 		Cost:          product.Cost,
 		QuantityStock: product.QuantityStock,
-		Guarantees:    product.Guarantees,
+		WarrantyDays:  product.WarrantyDays,
 		Country:       product.Country,
 		Like:          product.Like,
 	}
 
 	err := s.repo.Update(ctx, repoProduct)
+	if errors.Is(err, repository.ErrSKUTaken) {
+		return fmt.Errorf("product %s: %w", product.SKU, models.ErrSKUTaken)
+	}
 	if err != nil {
 		return err
 	}
@@ -107,15 +159,15 @@ func (s *ProductService) Update(ctx context.Context, product *models.Product) er
 	return nil
 }
 
-func (s *ProductService) Delete(ctx context.Context, id int) error {
-	return s.repo.Delete(ctx, uuids.IntToUUID(int64(id)))
+func (s *ProductService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
 }
 
-func (s *ProductService) AddLike(ctx context.Context, id int) error {
-	err := s.repo.AddLike(ctx, uuids.IntToUUID(int64(id)))
+func (s *ProductService) AddLike(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.AddLike(ctx, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return sql.ErrNoRows
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return fmt.Errorf("product: %w", models.ErrNotFound)
 		}
 
 		return err
@@ -124,11 +176,11 @@ func (s *ProductService) AddLike(ctx context.Context, id int) error {
 	return nil
 }
 
-func (s *ProductService) RemoveLike(ctx context.Context, id int) error {
-	err := s.repo.RemoveLike(ctx, uuids.IntToUUID(int64(id)))
+func (s *ProductService) RemoveLike(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.RemoveLike(ctx, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return sql.ErrNoRows
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return fmt.Errorf("product: %w", models.ErrNotFound)
 		}
 
 		return err
@@ -149,11 +201,12 @@ func (s *ProductService) GetProductByName(ctx context.Context, name string) ([]*
 	var products []*models.Product
 	for _, repoProduct := range repoProducts {
 		product := &models.Product{
-			ID:            uuids.UUIDToInt(repoProduct.ID),
+			ID:            repoProduct.ID,
+			SKU:           repoProduct.SKU,
 			Name:          repoProduct.Name,
 			Cost:          repoProduct.Cost,
 			QuantityStock: repoProduct.QuantityStock,
-			Guarantees:    repoProduct.Guarantees,
+			WarrantyDays:  repoProduct.WarrantyDays,
 			Country:       repoProduct.Country,
 			Like:          repoProduct.Like,
 		}
@@ -182,11 +235,12 @@ func (s *ProductService) GetProductsWithPagination(ctx context.Context, limit, o
 	var products []*models.Product
 	for _, repoProduct := range repoProducts {
 		product := &models.Product{
-			ID:            uint64(uuids.UUIDToInt(repoProduct.ID)),
+			ID:            repoProduct.ID,
+			SKU:           repoProduct.SKU,
 			Name:          repoProduct.Name,
 			Cost:          repoProduct.Cost,
 			QuantityStock: repoProduct.QuantityStock,
-			Guarantees:    repoProduct.Guarantees,
+			WarrantyDays:  repoProduct.WarrantyDays,
 			Country:       repoProduct.Country,
 			Like:          repoProduct.Like,
 		}
@@ -195,3 +249,186 @@ func (s *ProductService) GetProductsWithPagination(ctx context.Context, limit, o
 
 	return products, nil
 }
+
+// UpdateStock applies all updates or none of them.
+func (s *ProductService) UpdateStock(ctx context.Context, updates []models.StockUpdate) ([]*models.Product, error) {
+	var products []*models.Product
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		products = products[:0]
+		for _, update := range updates {
+			repoProduct, err := s.repo.Get(ctx, update.ProductID)
+			if err != nil {
+				if errors.Is(err, repository.ErrProductNotFound) {
+					return fmt.Errorf("product %s: %w", update.ProductID, models.ErrNotFound)
+				}
+				return err
+			}
+
+			stock := update.Quantity
+			if update.Relative {
+				stock += repoProduct.QuantityStock
+			}
+			if stock < 0 {
+				return fmt.Errorf("product %s: stock must not be negative", update.ProductID)
+			}
+
+			repoProduct.QuantityStock = stock
+			if err := s.repo.Update(ctx, repoProduct); err != nil {
+				return err
+			}
+
+			products = append(products, &models.Product{
+				ID:            repoProduct.ID,
+				SKU:           repoProduct.SKU,
+				Name:          repoProduct.Name,
+				Cost:          repoProduct.Cost,
+				QuantityStock: repoProduct.QuantityStock,
+				WarrantyDays:  repoProduct.WarrantyDays,
+				Country:       repoProduct.Country,
+				Like:          repoProduct.Like,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// ExportProducts hands every product to fn, a page at a time so the catalog
+// never has to fit in memory.
+func (s *ProductService) ExportProducts(ctx context.Context, fn func(product *models.Product) error) error {
+	for offset := 0; ; offset += exportBatchSize {
+		repoProducts, err := s.repo.GetProducts(ctx, offset, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, repoProduct := range repoProducts {
+			err := fn(&models.Product{
+				ID:            repoProduct.ID,
+				SKU:           repoProduct.SKU,
+				Name:          repoProduct.Name,
+				Cost:          repoProduct.Cost,
+				QuantityStock: repoProduct.QuantityStock,
+				WarrantyDays:  repoProduct.WarrantyDays,
+				Country:       repoProduct.Country,
+				Like:          repoProduct.Like,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(repoProducts) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// ImportProducts validates every row first and writes them in one
+// transaction, so a file with a single bad row changes nothing. A row whose
+// SKU is already in the catalog updates that product when opts.Upsert is set
+// and fails otherwise; rows without a SKU always create a product.
+func (s *ProductService) ImportProducts(ctx context.Context, rows []models.ProductImportRow, opts models.ImportOptions) (*models.ImportReport, error) {
+	var report *models.ImportReport
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		report = &models.ImportReport{DryRun: opts.DryRun, Rows: len(rows), Errors: []models.ImportRowError{}}
+		fail := func(row models.ImportRowError, err error) {
+			row.Error = err.Error()
+			report.Errors = append(report.Errors, row)
+		}
+
+		var creates, updates []*repository.Product
+		seen := make(map[string]int)
+		for _, row := range rows {
+			rowErr := models.ImportRowError{Line: row.Line, SKU: row.Product.SKU}
+			if row.Err != nil {
+				fail(rowErr, row.Err)
+				continue
+			}
+
+			product := &models.Product{
+				SKU:           row.Product.SKU,
+				Name:          row.Product.Name,
+				Cost:          row.Product.Cost,
+				QuantityStock: row.Product.QuantityStock,
+				WarrantyDays:  row.Product.WarrantyDays,
+				Country:       row.Product.Country,
+			}
+			if err := validateProduct(product); err != nil {
+				fail(rowErr, err)
+				continue
+			}
+
+			repoProduct := &repository.Product{
+				ID:            uuid.New(),
+				SKU:           product.SKU,
+				Name:          product.Name,
+				Cost:          product.Cost,
+				QuantityStock: product.QuantityStock,
+				WarrantyDays:  product.WarrantyDays,
+				Country:       product.Country,
+			}
+
+			if product.SKU == "" {
+				creates = append(creates, repoProduct)
+				continue
+			}
+
+			if line, ok := seen[product.SKU]; ok {
+				fail(rowErr, fmt.Errorf("sku is repeated from line %d", line))
+				continue
+			}
+			seen[product.SKU] = row.Line
+
+			existing, err := s.repo.GetBySKU(ctx, product.SKU)
+			switch {
+			case errors.Is(err, repository.ErrProductNotFound):
+				creates = append(creates, repoProduct)
+			case err != nil:
+				return err
+			case !opts.Upsert:
+				fail(rowErr, models.ErrSKUTaken)
+			default:
+				// likes come from customers, a catalog file doesn't reset them
+				repoProduct.ID = existing.ID
+				repoProduct.Like = existing.Like
+				updates = append(updates, repoProduct)
+			}
+		}
+
+		if len(report.Errors) > 0 {
+			return nil
+		}
+
+		report.Created, report.Updated = len(creates), len(updates)
+		if opts.DryRun {
+			return nil
+		}
+
+		for _, repoProduct := range creates {
+			if err := s.repo.Create(ctx, repoProduct); err != nil {
+				return err
+			}
+		}
+		for _, repoProduct := range updates {
+			if err := s.repo.Update(ctx, repoProduct); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, repository.ErrSKUTaken) {
+		return nil, fmt.Errorf("import: %w", models.ErrSKUTaken)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -7,47 +7,98 @@ import (
 	"time"
 	"vr-shope/internal/models"
 	"vr-shope/internal/repository"
-	"vr-shope/internal/uuids"
+
+	"github.com/google/uuid"
 )
 
-type PurchaseService struct {
-	repo *repository.PurchaseRepository
+type PurchaseRepository interface {
+	Create(ctx context.Context, purchase *repository.Purchase) error
+	Get(ctx context.Context, id uuid.UUID) (*repository.Purchase, error)
+	GetAll(ctx context.Context) ([]*repository.Purchase, error)
+	GetRecent(ctx context.Context, limit int) ([]*repository.Purchase, error)
+	ExistsByID(ctx context.Context, id uuid.UUID) (bool, error)
 }
 
-func NewPurchaseService(repo *repository.PurchaseRepository) *PurchaseService {
-	return &PurchaseService{repo}
+type PurchaseService struct {
+	repo PurchaseRepository
+	tx   Transactor
 }
 
-func (s *PurchaseService) Create(ctx context.Context, purchase *models.Purchase) error {
-	purchaseServ := repository.Purchase{
-		UserID:    uuids.IntToUUID(int64(purchase.UserID)),
-		ProductID: uuids.IntToUUID(int64(purchase.ProductID)),
-		Date:      purchase.Date,
-	}
-
-	purchases, err := s.repo.CheckMeans(ctx, purchaseServ.UserID, purchaseServ.ProductID)
-	if err != nil {
-		return err
+func NewPurchaseService(repo PurchaseRepository, tx Transactor) *PurchaseService {
+	return &PurchaseService{
+		repo: repo,
+		tx:   tx,
 	}
+}
 
-	err = s.repo.Create(ctx, purchases)
-	if err != nil {
+func purchaseError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		return models.ErrInsufficientFunds
+	case errors.Is(err, repository.ErrOutOfStock):
+		return models.ErrOutOfStock
+	case errors.Is(err, repository.ErrEmailNotVerified):
+		return models.ErrEmailNotVerified
+	case errors.Is(err, repository.ErrUserNotFound):
+		return fmt.Errorf("user: %w", models.ErrNotFound)
+	case errors.Is(err, repository.ErrProductNotFound):
+		return fmt.Errorf("product: %w", models.ErrNotFound)
+	default:
 		return err
 	}
+}
 
-	return nil
+func (s *PurchaseService) Create(ctx context.Context, actor models.Actor, purchase *models.Purchase) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if !actor.CanAccess(purchase.UserID) {
+			return models.ErrForbidden
+		}
+		if purchase.Quantity < 1 {
+			return models.ErrInvalidQuantity
+		}
+
+		purchaseRepo := &repository.Purchase{
+			ID:        uuid.New(),
+			OrderID:   uuid.New(),
+			UserID:    purchase.UserID,
+			ProductID: purchase.ProductID,
+			Quantity:  purchase.Quantity,
+			Date:      purchase.Date,
+		}
+
+		err := s.repo.Create(ctx, purchaseRepo)
+		if err != nil {
+			return purchaseError(err)
+		}
+
+		purchase.ID = purchaseRepo.ID
+		purchase.OrderID = purchaseRepo.OrderID
+		purchase.WalletUSDT = purchaseRepo.WalletUSDT
+		purchase.Cost = purchaseRepo.Cost
+
+		return nil
+	})
 }
 
-func (s *PurchaseService) Get(ctx context.Context, id int64) (*models.Purchase, error) {
-	purchaseRepo, err := s.repo.Get(ctx, uuids.IntToUUID(id))
+func (s *PurchaseService) Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.Purchase, error) {
+	purchaseRepo, err := s.repo.Get(ctx, id)
 	if err != nil {
+		if errors.Is(err, repository.ErrPurchaseNotFound) {
+			return nil, fmt.Errorf("purchase: %w", models.ErrNotFound)
+		}
 		return nil, err
 	}
 
+	if !actor.CanAccess(purchaseRepo.UserID) {
+		return nil, models.ErrForbidden
+	}
+
 	return &models.Purchase{
-		ID:         uuids.UUIDToInt(purchaseRepo.ID),
-		UserID:     uuids.UUIDToInt(purchaseRepo.UserID),
-		ProductID:  uuids.UUIDToInt(purchaseRepo.ProductID),
+		ID:         purchaseRepo.ID,
+		OrderID:    purchaseRepo.OrderID,
+		UserID:     purchaseRepo.UserID,
+		ProductID:  purchaseRepo.ProductID,
+		Quantity:   purchaseRepo.Quantity,
 		Date:       purchaseRepo.Date,
 		WalletUSDT: purchaseRepo.WalletUSDT,
 		Cost:       purchaseRepo.Cost,
@@ -63,9 +114,11 @@ func (s *PurchaseService) GetAll(ctx context.Context) ([]*models.Purchase, error
 	var purchases []*models.Purchase
 	for _, purchase := range purchasesRepo {
 		purchases = append(purchases, &models.Purchase{
-			ID:         uuids.UUIDToInt(purchase.ID),
-			UserID:     uuids.UUIDToInt(purchase.UserID),
-			ProductID:  uuids.UUIDToInt(purchase.ProductID),
+			ID:         purchase.ID,
+			OrderID:    purchase.OrderID,
+			UserID:     purchase.UserID,
+			ProductID:  purchase.ProductID,
+			Quantity:   purchase.Quantity,
 			Date:       time.Now(),
 			WalletUSDT: purchase.WalletUSDT,
 			Cost:       purchase.Cost,
@@ -75,45 +128,30 @@ func (s *PurchaseService) GetAll(ctx context.Context) ([]*models.Purchase, error
 	return purchases, nil
 }
 
-func (s *PurchaseService) Update(ctx context.Context, purchase *models.Purchase) error {
-	exists, err := s.repo.ExistsByID(ctx, uuids.IntToUUID(int64(purchase.ID)))
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return fmt.Errorf("purchase not exist")
-	}
-
-	purchaseRepo := &repository.Purchase{
-		ID:         uuids.IntToUUID(int64(purchase.ID)),
-		UserID:     uuids.IntToUUID(int64(purchase.UserID)),
-		ProductID:  uuids.IntToUUID(int64(purchase.ProductID)),
-		Date:       time.Now(),
-		WalletUSDT: purchase.WalletUSDT,
-		Cost:       purchase.Cost,
+// GetRecent returns the latest purchases of all users, newest first.
+func (s *PurchaseService) GetRecent(ctx context.Context, limit int) ([]*models.Purchase, error) {
+	if limit < 1 {
+		return nil, models.ErrInvalidPagination
 	}
 
-	err = s.repo.Update(ctx, purchaseRepo)
+	purchasesRepo, err := s.repo.GetRecent(ctx, limit)
 	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *PurchaseService) Delete(ctx context.Context, id int64) error {
-	exists, err := s.repo.ExistsByID(ctx, uuids.IntToUUID(id))
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return errors.New("purchase not found")
+		return nil, err
 	}
 
-	err = s.repo.Delete(ctx, uuids.IntToUUID(id))
-	if err != nil {
-		return err
+	var purchases []*models.Purchase
+	for _, purchase := range purchasesRepo {
+		purchases = append(purchases, &models.Purchase{
+			ID:         purchase.ID,
+			OrderID:    purchase.OrderID,
+			UserID:     purchase.UserID,
+			ProductID:  purchase.ProductID,
+			Quantity:   purchase.Quantity,
+			Date:       purchase.Date,
+			WalletUSDT: purchase.WalletUSDT,
+			Cost:       purchase.Cost,
+		})
 	}
 
-	return nil
+	return purchases, nil
 }
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"vr-shope/internal/models"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type ReturnRepository interface {
+	Create(ctx context.Context, ret *repository.ReturnRequest) error
+	Get(ctx context.Context, id uuid.UUID) (*repository.ReturnRequest, error)
+	GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.ReturnRequest, error)
+	GetByStatus(ctx context.Context, status string, offset, limit int) ([]*repository.ReturnRequest, error)
+	GetEvents(ctx context.Context, returnID uuid.UUID) ([]*repository.ReturnEvent, error)
+	Approve(ctx context.Context, id, actorID uuid.UUID, refundAmount *money.Amount, restockItems bool, note string) error
+	Reject(ctx context.Context, id, actorID uuid.UUID, note string) error
+}
+
+type ReturnService struct {
+	repo ReturnRepository
+	tx   Transactor
+}
+
+func NewReturnService(repo ReturnRepository, tx Transactor) *ReturnService {
+	return &ReturnService{
+		repo: repo,
+		tx:   tx,
+	}
+}
+
+func returnToModel(ret *repository.ReturnRequest) *models.Return {
+	model := &models.Return{
+		ID:           ret.ID,
+		PurchaseID:   ret.PurchaseID,
+		UserID:       ret.UserID,
+		Quantity:     ret.Quantity,
+		Reason:       ret.Reason,
+		Status:       ret.Status,
+		RefundAmount: ret.RefundAmount,
+		Restocked:    ret.Restocked,
+		ResolvedBy:   optionalID(ret.ResolvedBy),
+		Date:         ret.CreatedAt,
+		UpdatedAt:    ret.UpdatedAt,
+	}
+	if ret.ResolvedAt.Valid {
+		resolvedAt := ret.ResolvedAt.Time
+		model.ResolvedAt = &resolvedAt
+	}
+
+	return model
+}
+
+func returnError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrReturnNotFound):
+		return fmt.Errorf("return: %w", models.ErrNotFound)
+	case errors.Is(err, repository.ErrPurchaseNotFound):
+		return fmt.Errorf("purchase: %w", models.ErrNotFound)
+	case errors.Is(err, repository.ErrReturnQuantity):
+		return models.ErrReturnQuantity
+	case errors.Is(err, repository.ErrRefundAmount):
+		return models.ErrRefundAmount
+	case errors.Is(err, repository.ErrInvalidRefundAmount):
+		return fmt.Errorf("%w: refund amount must not be negative", models.ErrInvalidReturnInput)
+	case errors.Is(err, repository.ErrOrderNotReturnable):
+		return fmt.Errorf("%w: %v", models.ErrNotReturnable, err)
+	case errors.Is(err, repository.ErrReturnResolved):
+		return models.ErrReturnResolved
+	}
+	return err
+}
+
+func (s *ReturnService) Create(ctx context.Context, userID uuid.UUID, request *models.ReturnRequest) (*models.Return, error) {
+	if request.Quantity < 1 {
+		return nil, models.ErrInvalidQuantity
+	}
+	if strings.TrimSpace(request.Reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", models.ErrInvalidReturnInput)
+	}
+
+	ret := &repository.ReturnRequest{
+		ID:         uuid.New(),
+		PurchaseID: request.PurchaseID,
+		UserID:     userID,
+		Quantity:   request.Quantity,
+		Reason:     request.Reason,
+	}
+
+	if err := s.repo.Create(ctx, ret); err != nil {
+		return nil, returnError(err)
+	}
+
+	return s.get(ctx, ret.ID)
+}
+
+func (s *ReturnService) get(ctx context.Context, id uuid.UUID) (*models.Return, error) {
+	ret, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, returnError(err)
+	}
+
+	model := returnToModel(ret)
+
+	events, err := s.repo.GetEvents(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		model.Events = append(model.Events, &models.ReturnEvent{
+			ActorID: optionalID(event.ActorID),
+			Action:  event.Action,
+			Note:    event.Note,
+			Date:    event.CreatedAt,
+		})
+	}
+
+	return model, nil
+}
+
+func (s *ReturnService) Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.Return, error) {
+	ret, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.CanAccess(ret.UserID) {
+		return nil, models.ErrForbidden
+	}
+
+	return ret, nil
+}
+
+func (s *ReturnService) GetByUser(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.Return, error) {
+	limitInt, offsetInt, err := parsePagination(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	repoReturns, err := s.repo.GetByUser(ctx, userID, offsetInt, limitInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var returns []*models.Return
+	for _, ret := range repoReturns {
+		returns = append(returns, returnToModel(ret))
+	}
+
+	return returns, nil
+}
+
+func (s *ReturnService) GetByStatus(ctx context.Context, status, limit, offset string) ([]*models.Return, error) {
+	switch status {
+	case "", models.ReturnRequested, models.ReturnApproved, models.ReturnRejected:
+	default:
+		return nil, fmt.Errorf("%w: %q", models.ErrInvalidStatus, status)
+	}
+
+	limitInt, offsetInt, err := parsePagination(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	repoReturns, err := s.repo.GetByStatus(ctx, status, offsetInt, limitInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var returns []*models.Return
+	for _, ret := range repoReturns {
+		returns = append(returns, returnToModel(ret))
+	}
+
+	return returns, nil
+}
+
+func (s *ReturnService) Approve(ctx context.Context, id, actorID uuid.UUID, request *models.ReturnApproveRequest) (*models.Return, error) {
+	return withinTx(ctx, s.tx, func(ctx context.Context) (*models.Return, error) {
+		restock := true
+		if request.Restock != nil {
+			restock = *request.Restock
+		}
+
+		err := s.repo.Approve(ctx, id, actorID, request.RefundAmount, restock, request.Note)
+		if err != nil {
+			return nil, returnError(err)
+		}
+
+		return s.get(ctx, id)
+	})
+}
+
+func (s *ReturnService) Reject(ctx context.Context, id, actorID uuid.UUID, note string) (*models.Return, error) {
+	return withinTx(ctx, s.tx, func(ctx context.Context) (*models.Return, error) {
+		if err := s.repo.Reject(ctx, id, actorID, note); err != nil {
+			return nil, returnError(err)
+		}
+
+		return s.get(ctx, id)
+	})
+}
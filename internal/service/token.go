@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"vr-shope/internal/issuer"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *repository.RefreshToken) error
+	Rotate(ctx context.Context, tokenHash string, next *repository.RefreshToken) (*repository.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string) error
+	RevokeAccessToken(ctx context.Context, tokenID string, userID uuid.UUID, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string, userID uuid.UUID, issuedAt time.Time) (bool, error)
+}
+
+type TokenService struct {
+	issuer     *issuer.Issuer
+	repo       TokenRepository
+	userRepo   UserRepository
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenService(tokenIssuer *issuer.Issuer, repo TokenRepository, userRepo UserRepository, accessTTL, refreshTTL time.Duration) *TokenService {
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+
+	return &TokenService{
+		issuer:     tokenIssuer,
+		repo:       repo,
+		userRepo:   userRepo,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *TokenService) GenerateToken(userID uuid.UUID, role string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(s.accessTTL)
+	claims := jwt.MapClaims{
+		"typ":     models.TokenTypeAccess,
+		"user_id": userID.String(),
+		"role":    role,
+		"jti":     uuid.NewString(),
+		"iat":     time.Now().Unix(),
+		"exp":     expiresAt.Unix(),
+	}
+
+	signed, err := s.issuer.Sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}
+
+func (s *TokenService) Issue(ctx context.Context, userID uuid.UUID, role string) (*models.TokenPair, error) {
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateRefreshToken(ctx, &repository.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  uuid.New(),
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	accessToken, expiresAt, err := s.GenerateToken(userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, models.ErrInvalidToken
+	}
+
+	nextToken, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	next := &repository.RefreshToken{
+		ID:        uuid.New(),
+		TokenHash: hashToken(nextToken),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+
+	current, err := s.repo.Rotate(ctx, hashToken(refreshToken), next)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRefreshTokenNotFound),
+			errors.Is(err, repository.ErrRefreshTokenExpired),
+			errors.Is(err, repository.ErrRefreshTokenRevoked),
+			errors.Is(err, repository.ErrRefreshTokenReused):
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidToken, err)
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, models.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, expiresAt, err := s.GenerateToken(user.ID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: nextToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+func (s *TokenService) Logout(ctx context.Context, userID uuid.UUID, tokenID string, expiresAt time.Time, refreshToken string) error {
+	if tokenID != "" {
+		if err := s.repo.RevokeAccessToken(ctx, tokenID, userID, expiresAt); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken != "" {
+		err := s.repo.RevokeRefreshToken(ctx, userID, hashToken(refreshToken))
+		if err != nil && !errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *TokenService) IsRevoked(ctx context.Context, userID uuid.UUID, tokenID string, issuedAt time.Time) (bool, error) {
+	return s.repo.IsRevoked(ctx, tokenID, userID, issuedAt)
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vr-shope/internal/issuer"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+	"vr-shope/internal/totp"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaTokenTTL       = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+type TOTPRepository interface {
+	Get(ctx context.Context, userID uuid.UUID) (*repository.TOTPState, error)
+	SetPendingSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	Enable(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error
+	Disable(ctx context.Context, userID uuid.UUID) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+}
+
+type TwoFactorService struct {
+	issuer   *issuer.Issuer
+	repo     TOTPRepository
+	userRepo UserRepository
+	tokens   *TokenService
+	lockout  *LockoutService
+	name     string
+}
+
+func NewTwoFactorService(tokenIssuer *issuer.Issuer, repo TOTPRepository, userRepo UserRepository, tokens *TokenService, lockout *LockoutService, name string) *TwoFactorService {
+	return &TwoFactorService{
+		issuer:   tokenIssuer,
+		repo:     repo,
+		userRepo: userRepo,
+		tokens:   tokens,
+		lockout:  lockout,
+		name:     name,
+	}
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(buf))
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func (s *TwoFactorService) state(ctx context.Context, userID uuid.UUID) (*repository.TOTPState, error) {
+	state, err := s.repo.Get(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, fmt.Errorf("user: %w", models.ErrNotFound)
+	}
+
+	return state, err
+}
+
+// verifyCode accepts either a current TOTP code or an unused recovery code
+// and burns whichever one matched.
+func (s *TwoFactorService) verifyCode(ctx context.Context, state *repository.TOTPState, code string) error {
+	if !state.EnabledAt.Valid || !state.Secret.Valid {
+		return models.ErrTwoFactorNotEnabled
+	}
+
+	step, ok, err := totp.Validate(state.Secret.String, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if ok {
+		fresh, err := s.repo.UseStep(ctx, state.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return models.ErrInvalidTOTPCode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, state.UserID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return models.ErrInvalidTOTPCode
+	}
+
+	return nil
+}
+
+func (s *TwoFactorService) Enroll(ctx context.Context, actor models.Actor) (*models.TOTPEnrollment, error) {
+	userID := actor.UserID
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, fmt.Errorf("user: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.SetPendingSecret(ctx, userID, secret)
+	if errors.Is(err, repository.ErrTOTPAlreadyEnabled) {
+		return nil, models.ErrTwoFactorEnabled
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.name, user.Email, secret),
+	}, nil
+}
+
+func (s *TwoFactorService) Confirm(ctx context.Context, actor models.Actor, code string) ([]string, error) {
+	state, err := s.state(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.EnabledAt.Valid {
+		return nil, models.ErrTwoFactorEnabled
+	}
+	if !state.Secret.Valid {
+		return nil, models.ErrTwoFactorNotEnrolled
+	}
+
+	step, ok, err := totp.Validate(state.Secret.String, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, models.ErrInvalidTOTPCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Enable(ctx, state.UserID, step, hashes)
+	if errors.Is(err, repository.ErrTOTPNotEnrolled) {
+		return nil, models.ErrTwoFactorNotEnrolled
+	} else if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *TwoFactorService) Disable(ctx context.Context, actor models.Actor, code string) error {
+	state, err := s.state(ctx, actor.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.verifyCode(ctx, state, code); err != nil {
+		return err
+	}
+
+	err = s.repo.Disable(ctx, state.UserID)
+	if errors.Is(err, repository.ErrTOTPNotEnabled) {
+		return models.ErrTwoFactorNotEnabled
+	}
+
+	return err
+}
+
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, actor models.Actor, code string) ([]string, error) {
+	state, err := s.state(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(ctx, state, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, state.UserID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *TwoFactorService) Challenge(userID uuid.UUID) (string, time.Time, error) {
+	expiresAt := time.Now().Add(mfaTokenTTL)
+	token, err := s.issuer.Sign(jwt.MapClaims{
+		"sub": userID.String(),
+		"typ": models.TokenTypeMFA,
+		"jti": uuid.NewString(),
+		"iat": time.Now().Unix(),
+		"exp": expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+func (s *TwoFactorService) Login(ctx context.Context, mfaToken, code, clientIP string) (*models.TokenPair, error) {
+	if mfaToken == "" {
+		return nil, models.ErrInvalidToken
+	}
+
+	claims, err := s.issuer.Parse(mfaToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidToken, err)
+	}
+
+	if typ, _ := claims["typ"].(string); typ != models.TokenTypeMFA {
+		return nil, models.ErrInvalidToken
+	}
+
+	subject, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, models.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attempt, err := s.lockout.Attempt(ctx, user.Login, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.state(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(ctx, state, code); err != nil {
+		switch {
+		case errors.Is(err, models.ErrTwoFactorNotEnabled):
+			return nil, models.ErrInvalidToken
+		case errors.Is(err, models.ErrInvalidTOTPCode):
+			if err := s.lockout.Failure(ctx, attempt, user); err != nil {
+				return nil, fmt.Errorf("%w: %v", models.ErrInvalidTOTPCode, err)
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.lockout.Success(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	return s.tokens.Issue(ctx, user.ID, user.Role)
+}
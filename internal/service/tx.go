@@ -0,0 +1,20 @@
+package service
+
+import "context"
+
+// Transactor runs fn as one unit of work, repository calls made with the ctx
+// passed to fn share a single transaction.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func withinTx[T any](ctx context.Context, tx Transactor, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+
+	return result, err
+}
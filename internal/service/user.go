@@ -1,84 +1,53 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"regexp"
 	"strconv"
 	"time"
 	"vr-shope/internal/models"
 	"vr-shope/internal/repository"
-	"vr-shope/internal/uuids"
 
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
-type UserService struct {
-	repo *repository.UserStorage
-}
-
-func NewUserService(repo *repository.UserStorage) *UserService {
-	return &UserService{repo}
+type UserRepository interface {
+	Create(ctx context.Context, user *repository.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*repository.User, error)
+	GetAll(ctx context.Context) ([]*repository.User, error)
+	Update(ctx context.Context, user *repository.User) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	ExistsByID(ctx context.Context, id uuid.UUID) (bool, error)
+	GetByEmail(ctx context.Context, email string) (*repository.User, error)
+	GetUserByLogin(ctx context.Context, login string) (*repository.User, error)
+	GetUsers(ctx context.Context, offset, limit int) ([]*repository.User, error)
+	SetRole(ctx context.Context, id uuid.UUID, role string) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword, salt string) error
+	MarkVerificationSent(ctx context.Context, id uuid.UUID, notBefore time.Time) (bool, error)
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error
 }
 
-var secretKey = []byte("sfbwm37c7gd7c")
-
-func GenerateToken(userID int) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secretKey)
-}
-
-func generateSalt() ([]byte, error) {
-	salt := make([]byte, 16)
-	_, err := io.ReadFull(rand.Reader, salt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate salt: %w", err)
-	}
-	return salt, nil
+type UserService struct {
+	repo         UserRepository
+	tokens       *TokenService
+	verification *EmailVerificationService
+	twoFactor    *TwoFactorService
+	lockout      *LockoutService
+	tx           Transactor
 }
 
-func HashPassword(password string) (string, string, error) {
-	salt, err := generateSalt()
-	if err != nil {
-		return "", "", err
+func NewUserService(repo UserRepository, tokens *TokenService, verification *EmailVerificationService, twoFactor *TwoFactorService, lockout *LockoutService, tx Transactor) *UserService {
+	return &UserService{
+		repo:         repo,
+		tokens:       tokens,
+		verification: verification,
+		twoFactor:    twoFactor,
+		lockout:      lockout,
+		tx:           tx,
 	}
-
-	hash := sha256.New()
-	hash.Write(salt)
-	hash.Write([]byte(password))
-	hashedPassword := hash.Sum(nil)
-
-	return hex.EncodeToString(hashedPassword), hex.EncodeToString(salt), nil
-}
-
-func CheckPassword(password, storedHash, storedSalt string) (bool, error) {
-	storedHashBytes, err := hex.DecodeString(storedHash)
-	if err != nil {
-		return false, fmt.Errorf("failed to decode stored hash: %w", err)
-	}
-
-	storedSaltBytes, err := hex.DecodeString(storedSalt)
-	if err != nil {
-		return false, fmt.Errorf("failed to decode stored salt: %w", err)
-	}
-
-	hash := sha256.New()
-	hash.Write(storedSaltBytes)
-	hash.Write([]byte(password))
-	computedHash := hash.Sum(nil)
-
-	return bytes.Equal(computedHash, storedHashBytes), nil
 }
 
 func IsValidEmail(email string) bool {
@@ -97,54 +66,107 @@ func ValidateUser(user *models.User) error {
 	return nil
 }
 
-func (s *UserService) CreateUser(ctx context.Context, userServ *models.User) error {
+func (s *UserService) newUser(ctx context.Context, userServ *models.User, role string) (*repository.User, error) {
 	err := ValidateUser(userServ)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var email string = userServ.Email
 	em := IsValidEmail(email)
 	if !em {
-		return fmt.Errorf("invalid email: %s", email)
+		return nil, fmt.Errorf("invalid email: %s", email)
 	}
 
 	exists, err := s.repo.ExistsByEmail(ctx, userServ.Email)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if exists {
-		return fmt.Errorf("user with this email already exists")
+		return nil, fmt.Errorf("user with this email already exists")
 	}
 
-	hashedPassword, salt, err := HashPassword(userServ.Password)
+	hashedPassword, err := HashPassword(userServ.Password)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	userServ.Password = hashedPassword
 
-	userRepo := repository.User{
-		ID:          uuids.IntToUUID(int64(userServ.ID)),
+	return &repository.User{
+		ID:          uuid.New(),
 		Login:       userServ.Login,
 		Name:        userServ.Name,
 		LastName:    userServ.LastName,
 		PhoneNumber: userServ.PhoneNumber,
 		Password:    userServ.Password,
 		Email:       userServ.Email,
-		Salt:        salt,
+		Role:        role,
+	}, nil
+}
+
+func (s *UserService) CreateUser(ctx context.Context, userServ *models.User) error {
+	userRepo, err := s.newUser(ctx, userServ, models.RoleCustomer)
+	if err != nil {
+		return err
 	}
 
-	err = s.repo.Create(ctx, &userRepo)
+	err = s.repo.Create(ctx, userRepo)
+	if errors.Is(err, repository.ErrEmailTaken) {
+		return fmt.Errorf("user with this email already exists")
+	}
 	if err != nil {
 		return err
 	}
+	userServ.ID = userRepo.ID
+
+	// the account exists at this point; a failed email can be retried via resend
+	if err := s.verification.Send(ctx, userRepo.ID); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrVerificationNotSent, err)
+	}
 
 	return nil
 }
 
-func (s *UserService) Get(ctx context.Context, id int) (*models.User, error) {
-	exists, err := s.repo.ExistsByID(ctx, uuids.IntToUUID(int64(id)))
+// CreateStaff creates a manager or admin account. The operator vouches for the
+// email address, so it is marked verified instead of being mailed a link.
+func (s *UserService) CreateStaff(ctx context.Context, userServ *models.User, role string) error {
+	if role != models.RoleManager && role != models.RoleAdmin {
+		return fmt.Errorf("%w: %q", models.ErrInvalidRole, role)
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		userRepo, err := s.newUser(ctx, userServ, role)
+		if err != nil {
+			return err
+		}
+
+		err = s.repo.Create(ctx, userRepo)
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return fmt.Errorf("user with this email already exists")
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.MarkEmailVerified(ctx, userRepo.ID, userRepo.Email); err != nil {
+			return err
+		}
+
+		userServ.ID = userRepo.ID
+		userServ.Role = role
+		userServ.EmailVerified = true
+
+		return nil
+	})
+}
+
+func (s *UserService) Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.User, error) {
+	if !actor.CanAccess(id) {
+		return nil, models.ErrForbidden
+	}
+
+	exists, err := s.repo.ExistsByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -152,13 +174,13 @@ func (s *UserService) Get(ctx context.Context, id int) (*models.User, error) {
 		return nil, fmt.Errorf("user not found")
 	}
 
-	user, err := s.repo.GetByID(ctx, uuids.IntToUUID(int64(id)))
+	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	userServ := models.User{
-		ID:              uuids.UUIDToInt(user.ID),
+		ID:              user.ID,
 		Login:           user.Login,
 		Name:            user.Name,
 		LastName:        user.LastName,
@@ -166,6 +188,8 @@ func (s *UserService) Get(ctx context.Context, id int) (*models.User, error) {
 		Email:           user.Email,
 		WalletUSDT:      user.WalletUSDT,
 		NumberPurchases: user.NumberPurchases,
+		Role:            user.Role,
+		EmailVerified:   user.EmailVerifiedAt.Valid,
 	}
 
 	return &userServ, nil
@@ -180,7 +204,7 @@ func (s *UserService) GetAll(ctx context.Context) ([]*models.User, error) {
 	var usersServ []*models.User
 	for _, user := range usersRepo {
 		userServ := models.User{
-			ID:              uuids.UUIDToInt(user.ID),
+			ID:              user.ID,
 			Login:           user.Login,
 			Name:            user.Name,
 			LastName:        user.LastName,
@@ -188,6 +212,8 @@ func (s *UserService) GetAll(ctx context.Context) ([]*models.User, error) {
 			Email:           user.Email,
 			WalletUSDT:      user.WalletUSDT,
 			NumberPurchases: user.NumberPurchases,
+			Role:            user.Role,
+			EmailVerified:   user.EmailVerifiedAt.Valid,
 		}
 
 		usersServ = append(usersServ, &userServ)
@@ -196,60 +222,70 @@ func (s *UserService) GetAll(ctx context.Context) ([]*models.User, error) {
 	return usersServ, nil
 }
 
-func (s *UserService) Update(ctx context.Context, userServ *models.User) error {
-	exists, err := s.repo.ExistsByID(ctx, uuids.IntToUUID(int64(userServ.ID)))
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return fmt.Errorf("user not found")
-	}
+func (s *UserService) Update(ctx context.Context, actor models.Actor, userServ *models.User) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if !actor.CanAccess(userServ.ID) {
+			return models.ErrForbidden
+		}
 
-	err = ValidateUser(userServ)
-	if err != nil {
-		return err
-	}
+		exists, err := s.repo.ExistsByID(ctx, userServ.ID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("user not found")
+		}
 
-	email := userServ.Email
-	em := IsValidEmail(email)
-	if !em {
-		return fmt.Errorf("invalid email: %s", email)
-	}
+		err = ValidateUser(userServ)
+		if err != nil {
+			return err
+		}
 
-	userRepo := repository.User{
-		ID:              uuids.IntToUUID(int64(userServ.ID)),
-		Login:           userServ.Login,
-		Name:            userServ.Name,
-		LastName:        userServ.LastName,
-		PhoneNumber:     userServ.PhoneNumber,
-		Email:           userServ.Email,
-		WalletUSDT:      userServ.WalletUSDT,
-		NumberPurchases: userServ.NumberPurchases,
-	}
+		email := userServ.Email
+		em := IsValidEmail(email)
+		if !em {
+			return fmt.Errorf("invalid email: %s", email)
+		}
 
-	err = s.repo.Update(ctx, &userRepo)
-	if err != nil {
-		return err
-	}
+		userRepo := repository.User{
+			ID:          userServ.ID,
+			Login:       userServ.Login,
+			Name:        userServ.Name,
+			LastName:    userServ.LastName,
+			PhoneNumber: userServ.PhoneNumber,
+			Email:       userServ.Email,
+		}
 
-	return nil
+		err = s.repo.Update(ctx, &userRepo)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
 }
 
-func (s *UserService) Delete(ctx context.Context, id int) error {
-	exists, err := s.repo.ExistsByID(ctx, uuids.IntToUUID(int64(id)))
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return fmt.Errorf("user not found")
-	}
+func (s *UserService) Delete(ctx context.Context, actor models.Actor, id uuid.UUID) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if !actor.CanAccess(id) {
+			return models.ErrForbidden
+		}
 
-	err = s.repo.Delete(ctx, uuids.IntToUUID(int64(id)))
-	if err != nil {
-		return err
-	}
+		exists, err := s.repo.ExistsByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("user not found")
+		}
 
-	return nil
+		err = s.repo.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
 }
 
 func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
@@ -276,7 +312,7 @@ func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.Use
 	}
 
 	userServ := models.User{
-		ID:              uuids.UUIDToInt(user.ID),
+		ID:              user.ID,
 		Login:           user.Login,
 		Name:            user.Name,
 		LastName:        user.LastName,
@@ -284,32 +320,79 @@ func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.Use
 		Email:           user.Email,
 		WalletUSDT:      user.WalletUSDT,
 		NumberPurchases: user.NumberPurchases,
+		Role:            user.Role,
+		EmailVerified:   user.EmailVerifiedAt.Valid,
 	}
 
 	return &userServ, nil
 }
 
-func (s *UserService) GetToken(ctx context.Context, login string, password string) (string, error) {
+func (s *UserService) GetToken(ctx context.Context, login, password, clientIP string) (*models.LoginResult, error) {
 	if login == "" || password == "" {
-		return "", fmt.Errorf("invalid login or password")
+		return nil, models.ErrInvalidCredentials
 	}
 
-	user, err := s.repo.GetUserByLogin(ctx, login)
+	attempt, err := s.lockout.Attempt(ctx, login, clientIP)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	isValidPassword, err := CheckPassword(password, user.Password, user.Salt)
-	if err != nil || !isValidPassword {
-		return "", fmt.Errorf("invalid password")
+	user, err := s.repo.GetUserByLogin(ctx, login)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
 	}
 
-	token, err := GenerateToken(int(uuids.UUIDToInt(user.ID)))
+	isValidPassword := false
+	if user != nil {
+		isValidPassword, _ = CheckPassword(password, user.Password, user.Salt)
+	} else {
+		_, _ = CheckPassword(password, dummyHash(), "")
+	}
+
+	if !isValidPassword {
+		if err := s.lockout.Failure(ctx, attempt, user); err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidCredentials, err)
+		}
+		return nil, models.ErrInvalidCredentials
+	}
+
+	// upgrade legacy or outdated hashes while the plaintext is at hand; a
+	// failure here must not block the login, the next one will retry
+	if NeedsRehash(user.Password) {
+		if hashedPassword, err := HashPassword(password); err == nil {
+			_ = s.repo.UpdatePassword(ctx, user.ID, hashedPassword, "")
+		}
+	}
+
+	// the failure counter stays until the second factor is passed as well,
+	// so a known password can't be used to reset it between code guesses
+	if user.TOTPEnabledAt.Valid {
+		mfaToken, expiresAt, err := s.twoFactor.Challenge(user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.LoginResult{MFAToken: mfaToken, MFAExpiresAt: expiresAt}, nil
+	}
+
+	if err := s.lockout.Success(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.tokens.Issue(ctx, user.ID, user.Role)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return token, nil
+	return &models.LoginResult{Tokens: tokens}, nil
+}
+
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	return s.tokens.Refresh(ctx, refreshToken)
+}
+
+func (s *UserService) Logout(ctx context.Context, userID uuid.UUID, tokenID string, expiresAt time.Time, refreshToken string) error {
+	return s.tokens.Logout(ctx, userID, tokenID, expiresAt, refreshToken)
 }
 
 func (s *UserService) GetUsersWithPagination(ctx context.Context, limit, offset string) ([]*models.User, error) {
@@ -331,7 +414,7 @@ func (s *UserService) GetUsersWithPagination(ctx context.Context, limit, offset
 	var users []*models.User
 	for _, repoUser := range repoUsers {
 		user := &models.User{
-			ID:              uuids.UUIDToInt(repoUser.ID),
+			ID:              repoUser.ID,
 			Login:           repoUser.Login,
 			Name:            repoUser.Name,
 			LastName:        repoUser.LastName,
@@ -339,9 +422,35 @@ func (s *UserService) GetUsersWithPagination(ctx context.Context, limit, offset
 			Email:           repoUser.Email,
 			WalletUSDT:      repoUser.WalletUSDT,
 			NumberPurchases: repoUser.NumberPurchases,
+			Role:            repoUser.Role,
 		}
 		users = append(users, user)
 	}
 
 	return users, nil
 }
+
+func IsValidRole(role string) bool {
+	switch role {
+	case models.RoleCustomer, models.RoleManager, models.RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *UserService) SetRole(ctx context.Context, id uuid.UUID, role string) error {
+	if !IsValidRole(role) {
+		return fmt.Errorf("%w: %q", models.ErrInvalidRole, role)
+	}
+
+	err := s.repo.SetRole(ctx, id, role)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return fmt.Errorf("user: %w", models.ErrNotFound)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"vr-shope/internal/issuer"
+	"vr-shope/internal/mailer"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultVerificationTTL     = 48 * time.Hour
+	verificationResendInterval = time.Minute
+)
+
+type EmailVerificationService struct {
+	issuer    *issuer.Issuer
+	userRepo  UserRepository
+	mailer    mailer.Mailer
+	from      string
+	verifyURL string
+	ttl       time.Duration
+}
+
+func NewEmailVerificationService(tokenIssuer *issuer.Issuer, userRepo UserRepository, mail mailer.Mailer, from, verifyURL string, ttl time.Duration) *EmailVerificationService {
+	if ttl <= 0 {
+		ttl = defaultVerificationTTL
+	}
+
+	return &EmailVerificationService{
+		issuer:    tokenIssuer,
+		userRepo:  userRepo,
+		mailer:    mail,
+		from:      from,
+		verifyURL: verifyURL,
+		ttl:       ttl,
+	}
+}
+
+func (s *EmailVerificationService) verificationLink(token string) (string, error) {
+	link, err := url.Parse(s.verifyURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid verification url: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
+
+func (s *EmailVerificationService) send(ctx context.Context, user *repository.User) error {
+	sent, err := s.userRepo.MarkVerificationSent(ctx, user.ID, time.Now().Add(-verificationResendInterval))
+	if err != nil {
+		return err
+	}
+	if !sent {
+		// already verified or mailed a moment ago
+		return nil
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	token, err := s.issuer.Sign(jwt.MapClaims{
+		"sub":   user.ID.String(),
+		"email": user.Email,
+		"typ":   models.TokenTypeVerify,
+		"jti":   uuid.NewString(),
+		"iat":   time.Now().Unix(),
+		"exp":   expiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := s.verificationLink(token)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nplease confirm your email address by following the link below:\n\n%s\n\n"+
+			"The link expires at %s. Purchases are enabled once the address is confirmed.\n",
+		user.Login,
+		link,
+		expiresAt.Format(time.RFC1123),
+	)
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		From:    s.from,
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *EmailVerificationService) Send(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("user: %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, user)
+}
+
+func (s *EmailVerificationService) Resend(ctx context.Context, email string) error {
+	if !IsValidEmail(email) {
+		return models.ErrInvalidEmail
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return s.send(ctx, user)
+}
+
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) error {
+	if token == "" {
+		return models.ErrInvalidToken
+	}
+
+	claims, err := s.issuer.Parse(token)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return models.ErrVerificationExpired
+		}
+		return fmt.Errorf("%w: %v", models.ErrInvalidToken, err)
+	}
+
+	if typ, _ := claims["typ"].(string); typ != models.TokenTypeVerify {
+		return models.ErrInvalidToken
+	}
+
+	subject, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return models.ErrInvalidToken
+	}
+
+	email, _ := claims["email"].(string)
+
+	// the link is bound to the address it was sent to, so changing the
+	// email invalidates links sent to the old one
+	err = s.userRepo.MarkEmailVerified(ctx, userID, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return models.ErrInvalidToken
+	}
+
+	return err
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"vr-shope/internal/models"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type WalletRepository interface {
+	Post(ctx context.Context, txn *repository.LedgerTransaction) error
+	GetEntries(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.WalletEntry, error)
+	GetBalance(ctx context.Context, userID uuid.UUID) (money.Amount, money.Amount, error)
+}
+
+type WalletService struct {
+	repo WalletRepository
+}
+
+func NewWalletService(repo WalletRepository) *WalletService {
+	return &WalletService{repo}
+}
+
+func parsePagination(limit, offset string) (int, int, error) {
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt < 1 {
+		return 0, 0, models.ErrInvalidPagination
+	}
+
+	offsetInt, err := strconv.Atoi(offset)
+	if err != nil || offsetInt < 0 {
+		return 0, 0, models.ErrInvalidPagination
+	}
+
+	return limitInt, offsetInt, nil
+}
+
+func optionalID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+func (s *WalletService) GetBalance(ctx context.Context, userID uuid.UUID) (*models.WalletBalance, error) {
+	cached, ledger, err := s.repo.GetBalance(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, fmt.Errorf("user: %w", models.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	return &models.WalletBalance{
+		UserID:        userID,
+		Balance:       cached,
+		LedgerBalance: ledger,
+		Reconciled:    cached == ledger,
+	}, nil
+}
+
+func (s *WalletService) GetTransactions(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.WalletTransaction, error) {
+	limitInt, offsetInt, err := parsePagination(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.GetEntries(ctx, userID, offsetInt, limitInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []*models.WalletTransaction
+	for _, entry := range entries {
+		transactions = append(transactions, &models.WalletTransaction{
+			ID:            entry.ID,
+			TransactionID: entry.TransactionID,
+			Kind:          entry.Kind,
+			ReferenceID:   optionalID(entry.ReferenceID),
+			Description:   entry.Description,
+			Amount:        entry.Amount,
+			Balance:       entry.Balance,
+			Date:          entry.CreatedAt,
+		})
+	}
+
+	return transactions, nil
+}
+
+// Adjust corrects a wallet by hand, crediting a positive amount and debiting a
+// negative one. The reason ends up in the ledger next to the entry.
+func (s *WalletService) Adjust(ctx context.Context, userID uuid.UUID, amount money.Amount, reason string) (*models.WalletBalance, error) {
+	if amount == 0 {
+		return nil, fmt.Errorf("adjustment amount must not be zero")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("adjustment reason is required")
+	}
+
+	txn := &repository.LedgerTransaction{
+		Kind:        repository.LedgerAdjustment,
+		Description: reason,
+		Entries: []*repository.LedgerEntry{
+			{Account: repository.UserAccount(userID), UserID: userID, Amount: amount},
+			{Account: repository.AccountAdjustments, Amount: -amount},
+		},
+	}
+
+	err := s.repo.Post(ctx, txn)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			return nil, fmt.Errorf("user: %w", models.ErrNotFound)
+		case errors.Is(err, repository.ErrInsufficientFunds):
+			return nil, models.ErrInsufficientFunds
+		}
+		return nil, err
+	}
+
+	return s.GetBalance(ctx, userID)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"vr-shope/internal/models"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var claimTransitions = map[string][]string{
+	models.ClaimSubmitted: {models.ClaimInReview, models.ClaimApproved, models.ClaimRejected},
+	models.ClaimInReview:  {models.ClaimApproved, models.ClaimRejected},
+	models.ClaimApproved:  {models.ClaimResolved},
+	models.ClaimRejected:  {},
+	models.ClaimResolved:  {},
+}
+
+type WarrantyRepository interface {
+	Create(ctx context.Context, claim *repository.WarrantyClaim) error
+	Get(ctx context.Context, id uuid.UUID) (*repository.WarrantyClaim, error)
+	GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.WarrantyClaim, error)
+	GetByStatus(ctx context.Context, status string, offset, limit int) ([]*repository.WarrantyClaim, error)
+	GetHistory(ctx context.Context, claimID uuid.UUID) ([]*repository.ClaimTransition, error)
+	Transition(ctx context.Context, transition *repository.ClaimTransition) error
+}
+
+type WarrantyService struct {
+	repo         WarrantyRepository
+	purchaseRepo PurchaseRepository
+	tx           Transactor
+}
+
+func NewWarrantyService(repo WarrantyRepository, purchaseRepo PurchaseRepository, tx Transactor) *WarrantyService {
+	return &WarrantyService{
+		repo:         repo,
+		purchaseRepo: purchaseRepo,
+		tx:           tx,
+	}
+}
+
+func claimToModel(claim *repository.WarrantyClaim) *models.WarrantyClaim {
+	return &models.WarrantyClaim{
+		ID:          claim.ID,
+		PurchaseID:  claim.PurchaseID,
+		UserID:      claim.UserID,
+		Description: claim.Description,
+		Status:      claim.Status,
+		Date:        claim.CreatedAt,
+		UpdatedAt:   claim.UpdatedAt,
+	}
+}
+
+func claimError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrClaimNotFound):
+		return fmt.Errorf("warranty claim: %w", models.ErrNotFound)
+	case errors.Is(err, repository.ErrPurchaseNotFound):
+		return fmt.Errorf("purchase: %w", models.ErrNotFound)
+	case errors.Is(err, repository.ErrWarrantyExpired):
+		return models.ErrWarrantyExpired
+	case errors.Is(err, repository.ErrNotCovered):
+		return models.ErrNotCovered
+	case errors.Is(err, repository.ErrClaimOpen):
+		return models.ErrClaimOpen
+	case errors.Is(err, repository.ErrClaimStatusChanged):
+		return models.ErrConcurrentUpdate
+	}
+	return err
+}
+
+func (s *WarrantyService) Coverage(ctx context.Context, actor models.Actor, purchaseID uuid.UUID) (*models.WarrantyCoverage, error) {
+	purchase, err := s.purchaseRepo.Get(ctx, purchaseID)
+	if err != nil {
+		return nil, claimError(err)
+	}
+
+	if !actor.CanAccess(purchase.UserID) {
+		return nil, models.ErrForbidden
+	}
+
+	coverage := &models.WarrantyCoverage{
+		PurchaseID:  purchase.ID,
+		ProductID:   optionalID(purchase.ProductID),
+		PurchasedAt: purchase.Date,
+	}
+	if purchase.WarrantyUntil.Valid {
+		coveredUntil := purchase.WarrantyUntil.Time
+		coverage.CoveredUntil = &coveredUntil
+		coverage.Active = time.Now().Before(coveredUntil) && purchase.ReturnedQuantity < purchase.Quantity
+	}
+
+	return coverage, nil
+}
+
+func (s *WarrantyService) Create(ctx context.Context, userID uuid.UUID, request *models.WarrantyClaimRequest) (*models.WarrantyClaim, error) {
+	if strings.TrimSpace(request.Description) == "" {
+		return nil, fmt.Errorf("%w: description is required", models.ErrInvalidClaim)
+	}
+
+	claim := &repository.WarrantyClaim{
+		ID:          uuid.New(),
+		PurchaseID:  request.PurchaseID,
+		UserID:      userID,
+		Description: request.Description,
+	}
+
+	if err := s.repo.Create(ctx, claim); err != nil {
+		return nil, claimError(err)
+	}
+
+	return s.get(ctx, claim.ID)
+}
+
+func (s *WarrantyService) get(ctx context.Context, id uuid.UUID) (*models.WarrantyClaim, error) {
+	repoClaim, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, claimError(err)
+	}
+
+	claim := claimToModel(repoClaim)
+
+	history, err := s.repo.GetHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, transition := range history {
+		claim.History = append(claim.History, &models.ClaimTransition{
+			From:    transition.FromStatus,
+			To:      transition.ToStatus,
+			ActorID: optionalID(transition.ActorID),
+			Note:    transition.Note,
+			Date:    transition.CreatedAt,
+		})
+	}
+
+	return claim, nil
+}
+
+func (s *WarrantyService) Get(ctx context.Context, actor models.Actor, id uuid.UUID) (*models.WarrantyClaim, error) {
+	claim, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.CanAccess(claim.UserID) {
+		return nil, models.ErrForbidden
+	}
+
+	return claim, nil
+}
+
+func (s *WarrantyService) GetByUser(ctx context.Context, userID uuid.UUID, limit, offset string) ([]*models.WarrantyClaim, error) {
+	limitInt, offsetInt, err := parsePagination(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	repoClaims, err := s.repo.GetByUser(ctx, userID, offsetInt, limitInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims []*models.WarrantyClaim
+	for _, repoClaim := range repoClaims {
+		claims = append(claims, claimToModel(repoClaim))
+	}
+
+	return claims, nil
+}
+
+func (s *WarrantyService) GetByStatus(ctx context.Context, status, limit, offset string) ([]*models.WarrantyClaim, error) {
+	if _, ok := claimTransitions[status]; !ok && status != "" {
+		return nil, fmt.Errorf("%w: %q", models.ErrInvalidStatus, status)
+	}
+
+	limitInt, offsetInt, err := parsePagination(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	repoClaims, err := s.repo.GetByStatus(ctx, status, offsetInt, limitInt)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims []*models.WarrantyClaim
+	for _, repoClaim := range repoClaims {
+		claims = append(claims, claimToModel(repoClaim))
+	}
+
+	return claims, nil
+}
+
+func (s *WarrantyService) Transition(ctx context.Context, id, actorID uuid.UUID, status, note string) (*models.WarrantyClaim, error) {
+	return withinTx(ctx, s.tx, func(ctx context.Context) (*models.WarrantyClaim, error) {
+		if _, ok := claimTransitions[status]; !ok {
+			return nil, fmt.Errorf("%w: %q", models.ErrInvalidStatus, status)
+		}
+
+		claim, err := s.repo.Get(ctx, id)
+		if err != nil {
+			return nil, claimError(err)
+		}
+
+		if !slices.Contains(claimTransitions[claim.Status], status) {
+			return nil, fmt.Errorf("%w: %s -> %s", models.ErrInvalidTransition, claim.Status, status)
+		}
+
+		transition := &repository.ClaimTransition{
+			ClaimID:    id,
+			FromStatus: claim.Status,
+			ToStatus:   status,
+			ActorID:    actorID,
+			Note:       note,
+		}
+
+		if err := s.repo.Transition(ctx, transition); err != nil {
+			return nil, claimError(err)
+		}
+
+		return s.get(ctx, id)
+	})
+}
@@ -6,7 +6,6 @@ import (
 	"vr-shope/internal/config"
 
 	_ "github.com/lib/pq"
-	"github.com/pressly/goose/v3"
 )
 
 func OpenConnection(cfg *config.DBConfig) (*sql.DB, error) {
@@ -26,13 +25,9 @@ func OpenConnection(cfg *config.DBConfig) (*sql.DB, error) {
 	}
 
 	if err = db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	err = goose.Up(db, "db\\migrations")
-	if err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
-	}
-
 	return db, nil
 }
@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyStorage(db *sql.DB) (*APIKeyRepository, error) {
+	return &APIKeyRepository{db: db}, nil
+}
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }, extra ...any) (*repository.APIKey, error) {
+	var key repository.APIKey
+	var scopes string
+	dest := []any{
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Fields(scopes)
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *repository.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	key.CreatedAt = now()
+
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		key.ID,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		strings.Join(key.Scopes, " "),
+		sql.NullTime{Time: key.ExpiresAt.Time.UTC(), Valid: key.ExpiresAt.Valid},
+		key.CreatedAt,
+	)
+	return err
+}
+
+func (r *APIKeyRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*repository.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*repository.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*repository.APIKey, error) {
+	query := `
+		SELECT k.id, k.user_id, k.name, k.prefix, k.scopes, k.created_at, k.expires_at, k.last_used_at, k.revoked_at, u.role
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1`
+
+	var role string
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, keyHash), &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrAPIKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	key.Role = role
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, $3)
+		WHERE id = $1 AND user_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, userID, now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return requireRow(result, repository.ErrAPIKeyNotFound)
+}
+
+func (r *APIKeyRepository) Touch(ctx context.Context, id uuid.UUID) error {
+	// a busy script would otherwise write on every request
+	query := `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)`
+
+	usedAt := now()
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, usedAt, usedAt.Add(-time.Minute)); err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type CartRepository struct {
+	db *sql.DB
+}
+
+func NewCartStorage(db *sql.DB) (*CartRepository, error) {
+	return &CartRepository{db: db}, nil
+}
+
+func (r *CartRepository) AddItem(ctx context.Context, item *repository.CartItem) error {
+	query := `
+		INSERT INTO cart_items (id, user_id, product_id, quantity, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (user_id, product_id)
+		DO UPDATE SET quantity = cart_items.quantity + excluded.quantity, updated_at = excluded.updated_at
+		RETURNING id, quantity`
+
+	return conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		item.ID,
+		item.UserID,
+		item.ProductID,
+		item.Quantity,
+		now(),
+	).Scan(&item.ID, &item.Quantity)
+}
+
+func (r *CartRepository) GetItems(ctx context.Context, userID uuid.UUID) ([]*repository.CartItem, error) {
+	query := `
+		SELECT c.id, c.user_id, c.product_id, p.name, p.cost, c.quantity, c.created_at, c.updated_at
+		FROM cart_items c
+		JOIN products p ON p.id = c.product_id
+		WHERE c.user_id = $1
+		ORDER BY c.created_at`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*repository.CartItem
+	for rows.Next() {
+		var item repository.CartItem
+		err := rows.Scan(
+			&item.ID,
+			&item.UserID,
+			&item.ProductID,
+			&item.ProductName,
+			&item.Cost,
+			&item.Quantity,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *CartRepository) UpdateItem(ctx context.Context, item *repository.CartItem) error {
+	query := `
+		UPDATE cart_items
+		SET quantity = $3, updated_at = $4
+		WHERE id = $1 AND user_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, item.ID, item.UserID, item.Quantity, now())
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, sql.ErrNoRows)
+}
+
+func (r *CartRepository) DeleteItem(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM cart_items WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, sql.ErrNoRows)
+}
+
+func (r *CartRepository) Checkout(ctx context.Context, order *repository.Order, purchases []*repository.Purchase) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	order.Total, err = chargePurchases(ctx, tx, order.UserID, order.ID, purchases)
+	if err != nil {
+		return err
+	}
+
+	if err := createPaidOrder(ctx, tx, order); err != nil {
+		return err
+	}
+
+	for _, purchase := range purchases {
+		if err := insertPurchase(ctx, tx, purchase); err != nil {
+			return fmt.Errorf("failed to create purchase: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM cart_items WHERE user_id = $1`, order.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if int(n) != len(purchases) {
+		return repository.ErrCartChanged
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const categoryColumns = `id, parent_id, name, slug, position, created_at`
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryStorage(db *sql.DB) (*CategoryRepository, error) {
+	return &CategoryRepository{db: db}, nil
+}
+
+func scanCategory(row interface{ Scan(dest ...any) error }) (*repository.Category, error) {
+	var category repository.Category
+	var parentID uuid.NullUUID
+	err := row.Scan(
+		&category.ID,
+		&parentID,
+		&category.Name,
+		&category.Slug,
+		&category.Position,
+		&category.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	category.ParentID = parentID.UUID
+
+	return &category, nil
+}
+
+func (r *CategoryRepository) list(ctx context.Context, query string, args ...any) ([]*repository.Category, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*repository.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) get(ctx context.Context, where string, arg any) (*repository.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE ` + where + ` = $1`
+
+	category, err := scanCategory(conn(ctx, r.db).QueryRowContext(ctx, query, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *repository.Category) error {
+	query := `
+		INSERT INTO categories (id, parent_id, name, slug, position, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	createdAt := now()
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		category.ID,
+		uuid.NullUUID{UUID: category.ParentID, Valid: category.ParentID != uuid.Nil},
+		category.Name,
+		category.Slug,
+		category.Position,
+		createdAt,
+	)
+	if isUniqueViolation(err, "categories.slug") {
+		return repository.ErrCategorySlugTaken
+	}
+	if err != nil {
+		return err
+	}
+	category.CreatedAt = createdAt
+
+	return nil
+}
+
+func (r *CategoryRepository) Get(ctx context.Context, id uuid.UUID) (*repository.Category, error) {
+	return r.get(ctx, "id", id)
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*repository.Category, error) {
+	return r.get(ctx, "slug", slug)
+}
+
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]*repository.Category, error) {
+	return r.list(ctx, `SELECT `+categoryColumns+` FROM categories ORDER BY position, name, id`)
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *repository.Category) error {
+	query := `
+		UPDATE categories
+		SET parent_id = $2, name = $3, slug = $4, position = $5
+		WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		category.ID,
+		uuid.NullUUID{UUID: category.ParentID, Valid: category.ParentID != uuid.Nil},
+		category.Name,
+		category.Slug,
+		category.Position,
+	)
+	if isUniqueViolation(err, "categories.slug") {
+		return repository.ErrCategorySlugTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrCategoryNotFound)
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	var hasChildren bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)`, id).Scan(&hasChildren)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return repository.ErrCategoryHasChildren
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if err := requireRow(result, repository.ErrCategoryNotFound); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CategoryRepository) SetProductCategories(ctx context.Context, productID uuid.UUID, categoryIDs []uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_categories WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+
+	const query = `INSERT OR IGNORE INTO product_categories (product_id, category_id) VALUES ($1, $2)`
+	for _, categoryID := range categoryIDs {
+		if _, err := tx.ExecContext(ctx, query, productID, categoryID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CategoryRepository) GetProductCategories(ctx context.Context, productID uuid.UUID) ([]*repository.Category, error) {
+	query := `
+		SELECT c.id, c.parent_id, c.name, c.slug, c.position, c.created_at
+		FROM categories c
+		JOIN product_categories pc ON pc.category_id = c.id
+		WHERE pc.product_id = $1
+		ORDER BY c.position, c.name, c.id`
+
+	return r.list(ctx, query, productID)
+}
@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const depositColumns = `id, user_id, amount, status, provider, provider_ref, pay_address, expires_at, created_at, updated_at, confirmed_at, settled_by`
+
+type DepositRepository struct {
+	db *sql.DB
+}
+
+func NewDepositStorage(db *sql.DB) (*DepositRepository, error) {
+	return &DepositRepository{db: db}, nil
+}
+
+func scanDeposit(row interface{ Scan(dest ...any) error }) (*repository.Deposit, error) {
+	var deposit repository.Deposit
+	var settledBy uuid.NullUUID
+	err := row.Scan(
+		&deposit.ID,
+		&deposit.UserID,
+		&deposit.Amount,
+		&deposit.Status,
+		&deposit.Provider,
+		&deposit.ProviderRef,
+		&deposit.PayAddress,
+		&deposit.ExpiresAt,
+		&deposit.CreatedAt,
+		&deposit.UpdatedAt,
+		&deposit.ConfirmedAt,
+		&settledBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	deposit.SettledBy = settledBy.UUID
+
+	return &deposit, nil
+}
+
+func getDeposit(ctx context.Context, q queryer, id uuid.UUID) (*repository.Deposit, error) {
+	query := `SELECT ` + depositColumns + ` FROM deposits WHERE id = $1`
+
+	deposit, err := scanDeposit(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrDepositNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+func (r *DepositRepository) Create(ctx context.Context, deposit *repository.Deposit) error {
+	query := `
+		INSERT INTO deposits (id, user_id, amount, status, provider, provider_ref, pay_address, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)`
+
+	createdAt := now()
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		deposit.ID,
+		deposit.UserID,
+		deposit.Amount,
+		deposit.Status,
+		deposit.Provider,
+		deposit.ProviderRef,
+		deposit.PayAddress,
+		deposit.ExpiresAt.UTC(),
+		createdAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	deposit.CreatedAt = createdAt
+	deposit.UpdatedAt = createdAt
+
+	return nil
+}
+
+func (r *DepositRepository) Get(ctx context.Context, id uuid.UUID) (*repository.Deposit, error) {
+	return getDeposit(ctx, conn(ctx, r.db), id)
+}
+
+func (r *DepositRepository) GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.Deposit, error) {
+	query := `
+		SELECT ` + depositColumns + `
+		FROM deposits
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deposits []*repository.Deposit
+	for rows.Next() {
+		deposit, err := scanDeposit(rows)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, deposit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deposits, nil
+}
+
+func (r *DepositRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, settledBy uuid.UUID) error {
+	query := `
+		UPDATE deposits
+		SET status = $2, updated_at = $3, settled_by = $4
+		WHERE id = $1 AND status = 'pending'`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, status, now(), uuid.NullUUID{UUID: settledBy, Valid: settledBy != uuid.Nil})
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrDepositNotPending)
+}
+
+func (r *DepositRepository) Confirm(ctx context.Context, id uuid.UUID, settledBy uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	deposit, err := getDeposit(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if deposit.Status != repository.DepositPending {
+		return repository.ErrDepositNotPending
+	}
+
+	const updateQuery = `
+		UPDATE deposits
+		SET status = 'confirmed', confirmed_at = $2, updated_at = $2, settled_by = $3
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, updateQuery, id, now(), uuid.NullUUID{UUID: settledBy, Valid: settledBy != uuid.Nil}); err != nil {
+		return fmt.Errorf("failed to confirm deposit: %w", err)
+	}
+
+	txn := &repository.LedgerTransaction{
+		Kind:        repository.LedgerDeposit,
+		ReferenceID: deposit.ID,
+		Description: fmt.Sprintf("%s deposit %s", deposit.Provider, deposit.ProviderRef),
+		Entries: []*repository.LedgerEntry{
+			{Account: repository.UserAccount(deposit.UserID), UserID: deposit.UserID, Amount: deposit.Amount},
+			{Account: repository.AccountDeposits, Amount: -deposit.Amount},
+		},
+	}
+	if err := postTransaction(ctx, tx, txn); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package sqlite
+
+// The driver is a large pure Go build of SQLite, servers running on Postgres
+// leave it out.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"context"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// LegacyIDRepository resolves nothing: a SQLite database is created with
+// uuid keys and never had integer ids to backfill.
+type LegacyIDRepository struct{}
+
+func NewLegacyIDStorage() (*LegacyIDRepository, error) {
+	return &LegacyIDRepository{}, nil
+}
+
+func (r *LegacyIDRepository) Backfill(ctx context.Context) error {
+	return nil
+}
+
+func (r *LegacyIDRepository) Resolve(ctx context.Context, legacyID uint64) (uuid.UUID, error) {
+	return uuid.Nil, repository.ErrLegacyIDNotFound
+}
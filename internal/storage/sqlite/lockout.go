@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"vr-shope/internal/repository"
+)
+
+type LockoutRepository struct {
+	db *sql.DB
+}
+
+func NewLockoutStorage(db *sql.DB) (*LockoutRepository, error) {
+	return &LockoutRepository{db: db}, nil
+}
+
+// RecordAttempt counts an attempt before the credentials are checked and
+// returns the attempts in the current window together with any lock already in
+// place. The counter starts over when the previous attempt is older than
+// window.
+func (r *LockoutRepository) RecordAttempt(ctx context.Context, scope, subject string, window time.Duration) (int, time.Time, error) {
+	query := `
+		INSERT INTO login_throttles (scope, subject, failures, last_failure_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (scope, subject) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < $4 THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = $3
+		RETURNING failures, locked_until`
+
+	attemptedAt := now()
+
+	var failures int
+	var lockedUntil sql.NullTime
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, scope, subject, attemptedAt, attemptedAt.Add(-window)).Scan(&failures, &lockedUntil)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return failures, lockedUntil.Time, nil
+}
+
+func (r *LockoutRepository) Forgive(ctx context.Context, scope, subject string) error {
+	query := `
+		UPDATE login_throttles
+		SET failures = failures - 1
+		WHERE scope = $1 AND subject = $2 AND failures > 0`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, scope, subject); err != nil {
+		return fmt.Errorf("failed to forgive %s %s: %w", scope, subject, err)
+	}
+
+	return nil
+}
+
+func (r *LockoutRepository) Lock(ctx context.Context, scope, subject string, until time.Time) error {
+	query := `
+		UPDATE login_throttles
+		SET locked_until = CASE WHEN locked_until IS NULL OR locked_until < $3 THEN $3 ELSE locked_until END
+		WHERE scope = $1 AND subject = $2`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, scope, subject, until.UTC()); err != nil {
+		return fmt.Errorf("failed to lock %s %s: %w", scope, subject, err)
+	}
+
+	return nil
+}
+
+func (r *LockoutRepository) Reset(ctx context.Context, scope, subject string) (bool, error) {
+	query := `DELETE FROM login_throttles WHERE scope = $1 AND subject = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, scope, subject)
+	if err != nil {
+		return false, err
+	}
+
+	return affected(result)
+}
+
+func (r *LockoutRepository) GetLocked(ctx context.Context) ([]*repository.LoginThrottle, error) {
+	query := `
+		SELECT scope, subject, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE locked_until > $1
+		ORDER BY locked_until DESC`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var throttles []*repository.LoginThrottle
+	for rows.Next() {
+		var throttle repository.LoginThrottle
+		if err := rows.Scan(
+			&throttle.Scope,
+			&throttle.Subject,
+			&throttle.Failures,
+			&throttle.LastFailureAt,
+			&throttle.LockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, &throttle)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return throttles, nil
+}
@@ -0,0 +1,255 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const orderColumns = `id, user_id, status, total, created_at, updated_at`
+
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func NewOrderStorage(db *sql.DB) (*OrderRepository, error) {
+	return &OrderRepository{db: db}, nil
+}
+
+func recordTransition(ctx context.Context, q queryer, transition *repository.OrderTransition) error {
+	query := `
+		INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	transition.CreatedAt = now()
+
+	return q.QueryRowContext(
+		ctx,
+		query,
+		transition.OrderID,
+		sql.NullString{String: transition.FromStatus, Valid: transition.FromStatus != ""},
+		transition.ToStatus,
+		uuid.NullUUID{UUID: transition.ActorID, Valid: transition.ActorID != uuid.Nil},
+		transition.Note,
+		transition.CreatedAt,
+	).Scan(&transition.ID)
+}
+
+func createPaidOrder(ctx context.Context, tx queryer, order *repository.Order) error {
+	order.Status = "paid"
+	order.UpdatedAt = order.CreatedAt
+
+	query := `
+		INSERT INTO orders (id, user_id, status, total, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)`
+
+	_, err := tx.ExecContext(ctx, query, order.ID, order.UserID, order.Status, order.Total, order.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+
+	for _, transition := range []*repository.OrderTransition{
+		{OrderID: order.ID, ToStatus: "created", ActorID: order.UserID},
+		{OrderID: order.ID, FromStatus: "created", ToStatus: "paid", ActorID: order.UserID, Note: "paid from wallet"},
+	} {
+		if err := recordTransition(ctx, tx, transition); err != nil {
+			return fmt.Errorf("failed to record order status: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func scanOrder(row interface{ Scan(dest ...any) error }) (*repository.Order, error) {
+	var order repository.Order
+	err := row.Scan(
+		&order.ID,
+		&order.UserID,
+		&order.Status,
+		&order.Total,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func getOrder(ctx context.Context, q queryer, id uuid.UUID) (*repository.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1`
+
+	order, err := scanOrder(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrOrderNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (r *OrderRepository) Get(ctx context.Context, id uuid.UUID) (*repository.Order, error) {
+	return getOrder(ctx, conn(ctx, r.db), id)
+}
+
+func (r *OrderRepository) GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*repository.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (r *OrderRepository) GetPurchases(ctx context.Context, orderID uuid.UUID) ([]*repository.Purchase, error) {
+	query := `SELECT ` + purchaseColumns + ` FROM purchases WHERE order_id = $1 ORDER BY created_at, id`
+
+	return listPurchases(ctx, conn(ctx, r.db), query, orderID)
+}
+
+func (r *OrderRepository) GetHistory(ctx context.Context, orderID uuid.UUID) ([]*repository.OrderTransition, error) {
+	query := `
+		SELECT id, order_id, from_status, to_status, actor_id, note, created_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*repository.OrderTransition
+	for rows.Next() {
+		var transition repository.OrderTransition
+		var fromStatus sql.NullString
+		var actorID uuid.NullUUID
+		err := rows.Scan(
+			&transition.ID,
+			&transition.OrderID,
+			&fromStatus,
+			&transition.ToStatus,
+			&actorID,
+			&transition.Note,
+			&transition.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transition.FromStatus = fromStatus.String
+		transition.ActorID = actorID.UUID
+		history = append(history, &transition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (r *OrderRepository) Transition(ctx context.Context, transition *repository.OrderTransition, refund bool) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	order, err := getOrder(ctx, tx, transition.OrderID)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != transition.FromStatus {
+		return repository.ErrOrderStatusChanged
+	}
+
+	const updateQuery = `UPDATE orders SET status = $2, updated_at = $3 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, updateQuery, order.ID, transition.ToStatus, now()); err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	if refund {
+		if err := refundOrder(ctx, tx, order); err != nil {
+			return err
+		}
+	}
+
+	if err := recordTransition(ctx, tx, transition); err != nil {
+		return fmt.Errorf("failed to record order status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func orderPurchases(ctx context.Context, tx queryer, orderID uuid.UUID) ([]*repository.Purchase, error) {
+	query := `SELECT ` + purchaseColumns + ` FROM purchases WHERE order_id = $1 ORDER BY id`
+
+	return listPurchases(ctx, tx, query, orderID)
+}
+
+func refundOrder(ctx context.Context, tx queryer, order *repository.Order) error {
+	purchases, err := orderPurchases(ctx, tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	// goods only go back on the shelf when the order is cancelled before it
+	// ships; a delivered order is with the customer and comes back through
+	// an approved return instead
+	restock := order.Status != "delivered"
+
+	var total money.Amount
+	for _, purchase := range purchases {
+		quantity := purchase.Quantity - purchase.ReturnedQuantity
+		amount := purchase.Cost - purchase.RefundedAmount
+		if err := settleReturn(ctx, tx, purchase, quantity, amount, restock); err != nil {
+			return err
+		}
+		total += amount
+	}
+
+	if total > 0 {
+		description := fmt.Sprintf("refund for order %s", order.ID)
+		if err := creditRefund(ctx, tx, order.UserID, order.ID, total, description); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type PasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetStorage(db *sql.DB) (*PasswordResetRepository, error) {
+	return &PasswordResetRepository{db: db}, nil
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, token *repository.PasswordResetToken) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	token.CreatedAt = now()
+
+	// only the most recently requested link stays usable
+	const expireQuery = `UPDATE password_reset_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL`
+	if _, err := tx.ExecContext(ctx, expireQuery, token.UserID, token.CreatedAt); err != nil {
+		return fmt.Errorf("failed to expire previous reset tokens: %w", err)
+	}
+
+	const insertQuery = `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = tx.ExecContext(ctx, insertQuery, token.ID, token.UserID, token.TokenHash, token.ExpiresAt.UTC(), token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PasswordResetRepository) Reset(ctx context.Context, tokenHash, hashedPassword, salt string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	const selectQuery = `
+		SELECT id, user_id, token_hash, expires_at, created_at, used_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1`
+
+	var token repository.PasswordResetToken
+	err = tx.QueryRowContext(ctx, selectQuery, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UsedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrResetTokenNotFound
+	} else if err != nil {
+		return err
+	}
+
+	switch {
+	case token.UsedAt.Valid:
+		return repository.ErrResetTokenUsed
+	case time.Now().After(token.ExpiresAt):
+		return repository.ErrResetTokenExpired
+	}
+
+	const useQuery = `UPDATE password_reset_tokens SET used_at = $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, useQuery, token.ID, now()); err != nil {
+		return fmt.Errorf("failed to use reset token: %w", err)
+	}
+
+	if err := setPassword(ctx, tx, token.UserID, hashedPassword, salt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PasswordResetRepository) SetPassword(ctx context.Context, userID uuid.UUID, hashedPassword, salt string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if err := setPassword(ctx, tx, userID, hashedPassword, salt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func setPassword(ctx context.Context, tx queryer, userID uuid.UUID, hashedPassword, salt string) error {
+	revokedAt := now()
+
+	const passwordQuery = `
+		UPDATE users
+		SET hashed_password = $2, salt = $3, sessions_revoked_at = $4
+		WHERE id = $1`
+	result, err := tx.ExecContext(ctx, passwordQuery, userID, hashedPassword, salt, revokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := requireRow(result, repository.ErrUserNotFound); err != nil {
+		return err
+	}
+
+	const revokeQuery = `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, revokeQuery, userID, revokedAt); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
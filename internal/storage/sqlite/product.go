@@ -0,0 +1,194 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const productColumns = `id, COALESCE(sku, ''), name, cost, quantity_stock, warranty_days, country, likes`
+
+type ProductRepository struct {
+	db *sql.DB
+}
+
+func NewProductStorage(db *sql.DB) (*ProductRepository, error) {
+	return &ProductRepository{db: db}, nil
+}
+
+func scanProduct(row interface{ Scan(dest ...any) error }) (*repository.Product, error) {
+	var product repository.Product
+	err := row.Scan(
+		&product.ID,
+		&product.SKU,
+		&product.Name,
+		&product.Cost,
+		&product.QuantityStock,
+		&product.WarrantyDays,
+		&product.Country,
+		&product.Like,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *ProductRepository) list(ctx context.Context, query string, args ...any) ([]*repository.Product, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*repository.Product
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *repository.Product) error {
+	query := `
+		INSERT INTO products (id, name, cost, quantity_stock, warranty_days, country, sku)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))`
+
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		product.ID,
+		product.Name,
+		product.Cost,
+		product.QuantityStock,
+		product.WarrantyDays,
+		product.Country,
+		product.SKU,
+	)
+	if isUniqueViolation(err, "products.sku") {
+		return repository.ErrSKUTaken
+	}
+
+	return err
+}
+
+func (r *ProductRepository) Get(ctx context.Context, id uuid.UUID) (*repository.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = $1`
+
+	product, err := scanProduct(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (r *ProductRepository) GetAll(ctx context.Context) ([]*repository.Product, error) {
+	return r.list(ctx, `SELECT `+productColumns+` FROM products`)
+}
+
+func (r *ProductRepository) Update(ctx context.Context, product *repository.Product) error {
+	query := `
+		UPDATE products
+		SET name = $2, cost = $3, quantity_stock = $4, warranty_days = $5, country = $6, likes = $7, sku = NULLIF($8, '')
+		WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		product.ID,
+		product.Name,
+		product.Cost,
+		product.QuantityStock,
+		product.WarrantyDays,
+		product.Country,
+		product.Like,
+		product.SKU,
+	)
+	if isUniqueViolation(err, "products.sku") {
+		return repository.ErrSKUTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrProductNotFound)
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM products WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrProductNotFound)
+}
+
+func (r *ProductRepository) AddLike(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE products SET likes = likes + 1 WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrProductNotFound)
+}
+
+func (r *ProductRepository) RemoveLike(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE products SET likes = likes - 1 WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrProductNotFound)
+}
+
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*repository.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE sku = $1`
+
+	product, err := scanProduct(conn(ctx, r.db).QueryRowContext(ctx, query, sku))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (r *ProductRepository) GetForName(ctx context.Context, name string) ([]*repository.Product, error) {
+	return r.list(ctx, `SELECT `+productColumns+` FROM products WHERE name = $1`, name)
+}
+
+func (r *ProductRepository) GetProducts(ctx context.Context, offset, limit int) ([]*repository.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products ORDER BY id LIMIT $2 OFFSET $1`
+
+	return r.list(ctx, query, offset, limit)
+}
+
+func (r *ProductRepository) GetByCategory(ctx context.Context, categoryID uuid.UUID, offset, limit int) ([]*repository.Product, error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION
+			SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		)
+		SELECT ` + productColumns + `
+		FROM products
+		WHERE id IN (SELECT product_id FROM product_categories WHERE category_id IN (SELECT id FROM tree))
+		ORDER BY id
+		LIMIT $3 OFFSET $2`
+
+	return r.list(ctx, query, categoryID, offset, limit)
+}
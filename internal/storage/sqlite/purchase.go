@@ -0,0 +1,282 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const purchaseColumns = `id, order_id, user_id, product_id, quantity, created_at, wallet_usdt, cost, returned_quantity, refunded_amount, warranty_until`
+
+type PurchaseRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseStorage(db *sql.DB) (*PurchaseRepository, error) {
+	return &PurchaseRepository{db: db}, nil
+}
+
+func insertPurchase(ctx context.Context, q queryer, purchase *repository.Purchase) error {
+	var warrantyDays int
+	err := q.QueryRowContext(ctx, `SELECT warranty_days FROM products WHERE id = $1`, purchase.ProductID).Scan(&warrantyDays)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	purchase.WarrantyUntil = sql.NullTime{}
+	if warrantyDays > 0 {
+		purchase.WarrantyUntil = sql.NullTime{Time: purchase.Date.UTC().AddDate(0, 0, warrantyDays), Valid: true}
+	}
+
+	query := `
+		INSERT INTO purchases (id, order_id, user_id, product_id, quantity, created_at, wallet_usdt, cost, warranty_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err = q.ExecContext(
+		ctx,
+		query,
+		purchase.ID,
+		uuid.NullUUID{UUID: purchase.OrderID, Valid: purchase.OrderID != uuid.Nil},
+		purchase.UserID,
+		purchase.ProductID,
+		purchase.Quantity,
+		purchase.Date.UTC(),
+		purchase.WalletUSDT,
+		purchase.Cost,
+		purchase.WarrantyUntil,
+	)
+
+	return err
+}
+
+func scanPurchase(row interface{ Scan(dest ...any) error }) (*repository.Purchase, error) {
+	var purchase repository.Purchase
+	var orderID, productID uuid.NullUUID
+	err := row.Scan(
+		&purchase.ID,
+		&orderID,
+		&purchase.UserID,
+		&productID,
+		&purchase.Quantity,
+		&purchase.Date,
+		&purchase.WalletUSDT,
+		&purchase.Cost,
+		&purchase.ReturnedQuantity,
+		&purchase.RefundedAmount,
+		&purchase.WarrantyUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	purchase.OrderID = orderID.UUID
+	purchase.ProductID = productID.UUID
+
+	return &purchase, nil
+}
+
+func listPurchases(ctx context.Context, q queryer, query string, args ...any) ([]*repository.Purchase, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purchases []*repository.Purchase
+	for rows.Next() {
+		purchase, err := scanPurchase(rows)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, purchase)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return purchases, nil
+}
+
+func getPurchase(ctx context.Context, q queryer, id uuid.UUID) (*repository.Purchase, error) {
+	query := `SELECT ` + purchaseColumns + ` FROM purchases WHERE id = $1`
+
+	purchase, err := scanPurchase(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrPurchaseNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+func reserveStock(ctx context.Context, tx queryer, productID uuid.UUID, quantity int) (money.Amount, error) {
+	var cost money.Amount
+	var stock int
+	err := tx.QueryRowContext(ctx, `SELECT cost, quantity_stock FROM products WHERE id = $1`, productID).Scan(&cost, &stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.ErrProductNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read product: %w", err)
+	}
+
+	if stock < quantity {
+		return 0, repository.ErrOutOfStock
+	}
+
+	const update = `UPDATE products SET quantity_stock = quantity_stock - $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, update, productID, quantity); err != nil {
+		return 0, fmt.Errorf("failed to decrement stock: %w", err)
+	}
+
+	return cost.Mul(quantity), nil
+}
+
+func restock(ctx context.Context, tx queryer, productID uuid.UUID, quantity int) error {
+	if productID == uuid.Nil || quantity == 0 {
+		return nil
+	}
+
+	const query = `UPDATE products SET quantity_stock = quantity_stock + $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, productID, quantity); err != nil {
+		return fmt.Errorf("failed to restock product: %w", err)
+	}
+
+	return nil
+}
+
+func settleReturn(ctx context.Context, tx queryer, purchase *repository.Purchase, quantity int, amount money.Amount, restockItems bool) error {
+	if quantity == 0 && amount == 0 {
+		return nil
+	}
+
+	const query = `
+		UPDATE purchases
+		SET returned_quantity = returned_quantity + $2, refunded_amount = refunded_amount + $3
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, purchase.ID, quantity, amount); err != nil {
+		return fmt.Errorf("failed to update purchase: %w", err)
+	}
+	purchase.ReturnedQuantity += quantity
+	purchase.RefundedAmount += amount
+
+	if restockItems {
+		return restock(ctx, tx, purchase.ProductID, quantity)
+	}
+
+	return nil
+}
+
+func chargePurchases(ctx context.Context, tx queryer, userID, referenceID uuid.UUID, purchases []*repository.Purchase) (money.Amount, error) {
+	var verified bool
+	var wallet money.Amount
+	err := tx.QueryRowContext(ctx, `SELECT email_verified_at IS NOT NULL, wallet_usdt FROM users WHERE id = $1`, userID).Scan(&verified, &wallet)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.ErrUserNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read wallet: %w", err)
+	}
+
+	if !verified {
+		return 0, repository.ErrEmailNotVerified
+	}
+
+	var total money.Amount
+	for _, purchase := range purchases {
+		cost, err := reserveStock(ctx, tx, purchase.ProductID, purchase.Quantity)
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+		purchase.Cost = cost
+	}
+
+	if wallet < total {
+		return 0, repository.ErrInsufficientFunds
+	}
+
+	if total > 0 {
+		txn := &repository.LedgerTransaction{
+			Kind:        repository.LedgerPurchase,
+			ReferenceID: referenceID,
+			Description: fmt.Sprintf("purchase of %d item(s)", len(purchases)),
+			Entries: []*repository.LedgerEntry{
+				{Account: repository.UserAccount(userID), UserID: userID, Amount: -total},
+				{Account: repository.AccountSales, Amount: total},
+			},
+		}
+		if err := postTransaction(ctx, tx, txn); err != nil {
+			return 0, fmt.Errorf("failed to debit wallet: %w", err)
+		}
+	}
+
+	const query = `UPDATE users SET number_purchases = number_purchases + $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, userID, len(purchases)); err != nil {
+		return 0, fmt.Errorf("failed to update purchase counter: %w", err)
+	}
+
+	for _, purchase := range purchases {
+		purchase.WalletUSDT = wallet - total
+	}
+
+	return total, nil
+}
+
+func (r *PurchaseRepository) Create(ctx context.Context, purchase *repository.Purchase) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	total, err := chargePurchases(ctx, tx, purchase.UserID, purchase.OrderID, []*repository.Purchase{purchase})
+	if err != nil {
+		return err
+	}
+
+	order := &repository.Order{
+		ID:        purchase.OrderID,
+		UserID:    purchase.UserID,
+		Total:     total,
+		CreatedAt: purchase.Date,
+	}
+	if err := createPaidOrder(ctx, tx, order); err != nil {
+		return err
+	}
+
+	if err := insertPurchase(ctx, tx, purchase); err != nil {
+		return fmt.Errorf("failed to create purchase: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PurchaseRepository) Get(ctx context.Context, id uuid.UUID) (*repository.Purchase, error) {
+	return getPurchase(ctx, conn(ctx, r.db), id)
+}
+
+func (r *PurchaseRepository) GetAll(ctx context.Context) ([]*repository.Purchase, error) {
+	return listPurchases(ctx, conn(ctx, r.db), `SELECT `+purchaseColumns+` FROM purchases`)
+}
+
+func (r *PurchaseRepository) GetRecent(ctx context.Context, limit int) ([]*repository.Purchase, error) {
+	query := `SELECT ` + purchaseColumns + ` FROM purchases ORDER BY created_at DESC, id LIMIT $1`
+	return listPurchases(ctx, conn(ctx, r.db), query, limit)
+}
+
+func (r *PurchaseRepository) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM purchases WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
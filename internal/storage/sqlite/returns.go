@@ -0,0 +1,414 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const returnColumns = `id, purchase_id, user_id, quantity, reason, status, refund_amount, restocked, resolved_by, resolved_at, created_at, updated_at`
+
+type ReturnRepository struct {
+	db *sql.DB
+}
+
+func NewReturnStorage(db *sql.DB) (*ReturnRepository, error) {
+	return &ReturnRepository{db: db}, nil
+}
+
+func scanReturn(row interface{ Scan(dest ...any) error }) (*repository.ReturnRequest, error) {
+	var ret repository.ReturnRequest
+	var resolvedBy uuid.NullUUID
+	err := row.Scan(
+		&ret.ID,
+		&ret.PurchaseID,
+		&ret.UserID,
+		&ret.Quantity,
+		&ret.Reason,
+		&ret.Status,
+		&ret.RefundAmount,
+		&ret.Restocked,
+		&resolvedBy,
+		&ret.ResolvedAt,
+		&ret.CreatedAt,
+		&ret.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	ret.ResolvedBy = resolvedBy.UUID
+
+	return &ret, nil
+}
+
+func recordReturnEvent(ctx context.Context, q queryer, event *repository.ReturnEvent) error {
+	query := `
+		INSERT INTO return_events (return_id, actor_id, action, note, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	event.CreatedAt = now()
+
+	return q.QueryRowContext(
+		ctx,
+		query,
+		event.ReturnID,
+		uuid.NullUUID{UUID: event.ActorID, Valid: event.ActorID != uuid.Nil},
+		event.Action,
+		event.Note,
+		event.CreatedAt,
+	).Scan(&event.ID)
+}
+
+func getReturn(ctx context.Context, q queryer, id uuid.UUID) (*repository.ReturnRequest, error) {
+	query := `SELECT ` + returnColumns + ` FROM return_requests WHERE id = $1`
+
+	ret, err := scanReturn(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrReturnNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func pendingReturn(ctx context.Context, tx queryer, id uuid.UUID) (*repository.ReturnRequest, error) {
+	ret, err := getReturn(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != repository.ReturnRequested {
+		return nil, repository.ErrReturnResolved
+	}
+
+	return ret, nil
+}
+
+func (r *ReturnRepository) Create(ctx context.Context, ret *repository.ReturnRequest) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	purchase, err := getPurchase(ctx, tx, ret.PurchaseID)
+	if err != nil {
+		return err
+	}
+	if purchase.UserID != ret.UserID {
+		return repository.ErrPurchaseNotFound
+	}
+
+	if purchase.OrderID != uuid.Nil {
+		var status string
+		err := tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1`, purchase.OrderID).Scan(&status)
+		if err != nil {
+			return fmt.Errorf("failed to check order status: %w", err)
+		}
+		// only delivered orders may move to refunded, see orderTransitions
+		if status != "delivered" {
+			return repository.ErrOrderNotReturnable
+		}
+	}
+
+	const pendingQuery = `
+		SELECT COALESCE(SUM(quantity), 0)
+		FROM return_requests
+		WHERE purchase_id = $1 AND status = 'requested'`
+
+	var pending int
+	if err := tx.QueryRowContext(ctx, pendingQuery, purchase.ID).Scan(&pending); err != nil {
+		return fmt.Errorf("failed to check pending returns: %w", err)
+	}
+
+	if ret.Quantity > purchase.Quantity-purchase.ReturnedQuantity-pending {
+		return repository.ErrReturnQuantity
+	}
+
+	ret.Status = repository.ReturnRequested
+	ret.CreatedAt = now()
+	ret.UpdatedAt = ret.CreatedAt
+
+	query := `
+		INSERT INTO return_requests (id, purchase_id, user_id, quantity, reason, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)`
+
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		ret.ID,
+		ret.PurchaseID,
+		ret.UserID,
+		ret.Quantity,
+		ret.Reason,
+		ret.Status,
+		ret.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create return request: %w", err)
+	}
+
+	event := &repository.ReturnEvent{ReturnID: ret.ID, ActorID: ret.UserID, Action: repository.ReturnRequested, Note: ret.Reason}
+	if err := recordReturnEvent(ctx, tx, event); err != nil {
+		return fmt.Errorf("failed to record return event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReturnRepository) Get(ctx context.Context, id uuid.UUID) (*repository.ReturnRequest, error) {
+	return getReturn(ctx, conn(ctx, r.db), id)
+}
+
+func (r *ReturnRepository) list(ctx context.Context, query string, args ...any) ([]*repository.ReturnRequest, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []*repository.ReturnRequest
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return returns, nil
+}
+
+func (r *ReturnRepository) GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.ReturnRequest, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM return_requests
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $2`
+
+	return r.list(ctx, query, userID, offset, limit)
+}
+
+func (r *ReturnRepository) GetByStatus(ctx context.Context, status string, offset, limit int) ([]*repository.ReturnRequest, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM return_requests
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at
+		LIMIT $3 OFFSET $2`
+
+	return r.list(ctx, query, status, offset, limit)
+}
+
+func (r *ReturnRepository) GetEvents(ctx context.Context, returnID uuid.UUID) ([]*repository.ReturnEvent, error) {
+	query := `
+		SELECT id, return_id, actor_id, action, note, created_at
+		FROM return_events
+		WHERE return_id = $1
+		ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*repository.ReturnEvent
+	for rows.Next() {
+		var event repository.ReturnEvent
+		var actorID uuid.NullUUID
+		err := rows.Scan(
+			&event.ID,
+			&event.ReturnID,
+			&actorID,
+			&event.Action,
+			&event.Note,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		event.ActorID = actorID.UUID
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *ReturnRepository) Approve(ctx context.Context, id, actorID uuid.UUID, refundAmount *money.Amount, restockItems bool, note string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	ret, err := pendingReturn(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	purchase, err := getPurchase(ctx, tx, ret.PurchaseID)
+	if err != nil {
+		return err
+	}
+
+	remainingQuantity := purchase.Quantity - purchase.ReturnedQuantity
+	remainingAmount := purchase.Cost - purchase.RefundedAmount
+	if ret.Quantity > remainingQuantity {
+		return repository.ErrReturnQuantity
+	}
+
+	var amount money.Amount
+	switch {
+	case refundAmount != nil:
+		amount = *refundAmount
+	case ret.Quantity == remainingQuantity:
+		amount = remainingAmount
+	default:
+		amount = purchase.Cost * money.Amount(ret.Quantity) / money.Amount(purchase.Quantity)
+	}
+	if amount < 0 {
+		return repository.ErrInvalidRefundAmount
+	}
+	if amount > remainingAmount {
+		return repository.ErrRefundAmount
+	}
+
+	if err := settleReturn(ctx, tx, purchase, ret.Quantity, amount, restockItems); err != nil {
+		return err
+	}
+
+	if amount > 0 {
+		description := fmt.Sprintf("refund for return %s", ret.ID)
+		if err := creditRefund(ctx, tx, ret.UserID, ret.ID, amount, description); err != nil {
+			return err
+		}
+	}
+
+	const updateQuery = `
+		UPDATE return_requests
+		SET status = 'approved', refund_amount = $2, restocked = $3, resolved_by = $4, resolved_at = $5, updated_at = $5
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, updateQuery, ret.ID, amount, restockItems, actorID, now()); err != nil {
+		return fmt.Errorf("failed to approve return request: %w", err)
+	}
+
+	summary := fmt.Sprintf("refunded %s, restocked %t", amount, restockItems)
+	if note != "" {
+		summary += ": " + note
+	}
+
+	event := &repository.ReturnEvent{ReturnID: ret.ID, ActorID: actorID, Action: repository.ReturnApproved, Note: summary}
+	if err := recordReturnEvent(ctx, tx, event); err != nil {
+		return fmt.Errorf("failed to record return event: %w", err)
+	}
+
+	if purchase.OrderID != uuid.Nil {
+		if err := markOrderRefunded(ctx, tx, purchase.OrderID, actorID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReturnRepository) Reject(ctx context.Context, id, actorID uuid.UUID, note string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	ret, err := pendingReturn(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	const updateQuery = `
+		UPDATE return_requests
+		SET status = 'rejected', resolved_by = $2, resolved_at = $3, updated_at = $3
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, updateQuery, ret.ID, actorID, now()); err != nil {
+		return fmt.Errorf("failed to reject return request: %w", err)
+	}
+
+	event := &repository.ReturnEvent{ReturnID: ret.ID, ActorID: actorID, Action: repository.ReturnRejected, Note: note}
+	if err := recordReturnEvent(ctx, tx, event); err != nil {
+		return fmt.Errorf("failed to record return event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func markOrderRefunded(ctx context.Context, tx queryer, orderID, actorID uuid.UUID) error {
+	var status string
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1`, orderID).Scan(&status); err != nil {
+		return fmt.Errorf("failed to read order: %w", err)
+	}
+	// returns opened before delivery was required stay approved without
+	// moving the order off the state machine
+	if status != "delivered" {
+		return nil
+	}
+
+	purchases, err := orderPurchases(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, purchase := range purchases {
+		if purchase.ReturnedQuantity < purchase.Quantity {
+			return nil
+		}
+	}
+
+	const updateQuery = `UPDATE orders SET status = 'refunded', updated_at = $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, updateQuery, orderID, now()); err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	transition := &repository.OrderTransition{
+		OrderID:    orderID,
+		FromStatus: status,
+		ToStatus:   "refunded",
+		ActorID:    actorID,
+		Note:       "all items returned",
+	}
+	if err := recordTransition(ctx, tx, transition); err != nil {
+		return fmt.Errorf("failed to record order status: %w", err)
+	}
+
+	return nil
+}
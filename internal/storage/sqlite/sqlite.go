@@ -0,0 +1,43 @@
+// Package sqlite keeps the whole shop in a single SQLite file. It implements
+// the same repositories as the Postgres backend for local development and
+// machines that can't run a database server.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"vr-shope/internal/config"
+)
+
+var ErrDriverMissing = errors.New("sqlite support is not compiled in, build with -tags sqlite")
+
+func OpenConnection(cfg *config.DBConfig) (*sql.DB, error) {
+	if !slices.Contains(sql.Drivers(), "sqlite") {
+		return nil, ErrDriverMissing
+	}
+
+	if cfg.Path == "" {
+		return nil, errors.New("database path is not set")
+	}
+
+	// immediate transactions take the write lock up front, which gives every
+	// transaction the serializable behaviour the repositories rely on
+	dsn := fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate",
+		cfg.Path,
+	)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
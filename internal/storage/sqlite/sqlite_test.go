@@ -0,0 +1,65 @@
+//go:build sqlite
+
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"vr-shope/internal/config"
+	"vr-shope/internal/migrate"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+	"vr-shope/internal/repository/repotest"
+	"vr-shope/internal/storage/sqlite"
+
+	"github.com/google/uuid"
+)
+
+func TestSQLiteContract(t *testing.T) {
+	dir := t.TempDir()
+
+	repotest.Run(t, func(t *testing.T) *repotest.Backend {
+		db, err := sqlite.OpenConnection(&config.DBConfig{Path: filepath.Join(dir, uuid.NewString()+".db")})
+		if err != nil {
+			t.Fatalf("open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if err := migrate.Up(context.Background(), db, migrate.SQLite); err != nil {
+			t.Fatalf("run migrations: %v", err)
+		}
+
+		users, _ := sqlite.NewUserStorage(db)
+		products, _ := sqlite.NewProductStorage(db)
+		categories, _ := sqlite.NewCategoryStorage(db)
+		purchases, _ := sqlite.NewPurchaseStorage(db)
+		deposits, _ := sqlite.NewDepositStorage(db)
+
+		return &repotest.Backend{
+			Users:      users,
+			Products:   products,
+			Categories: categories,
+			Purchases:  purchases,
+			Credit: func(t *testing.T, userID uuid.UUID, amount money.Amount) {
+				ctx := context.Background()
+				deposit := &repository.Deposit{
+					ID:          uuid.New(),
+					UserID:      userID,
+					Amount:      amount,
+					Status:      repository.DepositPending,
+					Provider:    "test",
+					ProviderRef: uuid.NewString(),
+					ExpiresAt:   time.Now().Add(time.Hour),
+				}
+				if err := deposits.Create(ctx, deposit); err != nil {
+					t.Fatalf("create deposit: %v", err)
+				}
+				if err := deposits.Confirm(ctx, deposit.ID, uuid.Nil); err != nil {
+					t.Fatalf("confirm deposit: %v", err)
+				}
+			},
+		}
+	})
+}
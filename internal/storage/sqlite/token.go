@@ -0,0 +1,185 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type TokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenStorage(db *sql.DB) (*TokenRepository, error) {
+	return &TokenRepository{db: db}, nil
+}
+
+func insertRefreshToken(ctx context.Context, q queryer, token *repository.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	token.CreatedAt = now()
+
+	_, err := q.ExecContext(
+		ctx,
+		query,
+		token.ID,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt.UTC(),
+		token.CreatedAt,
+	)
+	return err
+}
+
+func revokeFamily(ctx context.Context, q queryer, familyID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := q.ExecContext(ctx, query, familyID, now()); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, token *repository.RefreshToken) error {
+	return insertRefreshToken(ctx, conn(ctx, r.db), token)
+}
+
+func (r *TokenRepository) Rotate(ctx context.Context, tokenHash string, next *repository.RefreshToken) (*repository.RefreshToken, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	const selectQuery = `
+		SELECT id, user_id, family_id, token_hash, expires_at, created_at, used_at, replaced_by, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1`
+
+	var current repository.RefreshToken
+	var replacedBy uuid.NullUUID
+	err = tx.QueryRowContext(ctx, selectQuery, tokenHash).Scan(
+		&current.ID,
+		&current.UserID,
+		&current.FamilyID,
+		&current.TokenHash,
+		&current.ExpiresAt,
+		&current.CreatedAt,
+		&current.UsedAt,
+		&replacedBy,
+		&current.RevokedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrRefreshTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	current.ReplacedBy = replacedBy.UUID
+
+	switch {
+	case current.RevokedAt.Valid:
+		return nil, repository.ErrRefreshTokenRevoked
+	case current.UsedAt.Valid:
+		// an already rotated token was presented again, so assume it leaked
+		if err := revokeFamily(ctx, tx, current.FamilyID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil, repository.ErrRefreshTokenReused
+	case time.Now().After(current.ExpiresAt):
+		return nil, repository.ErrRefreshTokenExpired
+	}
+
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+
+	const updateQuery = `UPDATE refresh_tokens SET used_at = $3, replaced_by = $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, updateQuery, current.ID, next.ID, now()); err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &current, nil
+}
+
+func (r *TokenRepository) RevokeRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	var familyID uuid.UUID
+	query := `SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash, userID).Scan(&familyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrRefreshTokenNotFound
+	} else if err != nil {
+		return err
+	}
+
+	return revokeFamily(ctx, conn(ctx, r.db), familyID)
+}
+
+func (r *TokenRepository) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, now()); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, userID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (token_id, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (token_id) DO NOTHING`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, tokenID, userID, expiresAt.UTC(), now()); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < $1`, now()); err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) IsRevoked(ctx context.Context, tokenID string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE token_id = $1), users.sessions_revoked_at
+		FROM (SELECT 1)
+		LEFT JOIN users ON users.id = $2`
+
+	var revoked bool
+	var sessionsRevokedAt sql.NullTime
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenID, userID).Scan(&revoked, &sessionsRevokedAt)
+	if err != nil {
+		return false, err
+	}
+
+	// iat only has second precision, so compare against the truncated
+	// revocation time to keep tokens issued right after a reset valid
+	if sessionsRevokedAt.Valid && sessionsRevokedAt.Time.Truncate(time.Second).After(issuedAt) {
+		return true, nil
+	}
+
+	return revoked, nil
+}
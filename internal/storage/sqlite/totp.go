@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type TOTPRepository struct {
+	db *sql.DB
+}
+
+func NewTOTPStorage(db *sql.DB) (*TOTPRepository, error) {
+	return &TOTPRepository{db: db}, nil
+}
+
+func (r *TOTPRepository) Get(ctx context.Context, userID uuid.UUID) (*repository.TOTPState, error) {
+	query := `SELECT id, totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = $1`
+
+	var state repository.TOTPState
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
+		&state.UserID,
+		&state.Secret,
+		&state.EnabledAt,
+		&state.LastStep,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (r *TOTPRepository) SetPendingSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `
+		UPDATE users
+		SET totp_secret = $2, totp_last_step = NULL
+		WHERE id = $1 AND totp_enabled_at IS NULL`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrTOTPAlreadyEnabled)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx queryer, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	const query = `INSERT INTO totp_recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, $4)`
+	createdAt := now()
+	for _, codeHash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, query, uuid.New(), userID, codeHash, createdAt); err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *TOTPRepository) Enable(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET totp_enabled_at = $3, totp_last_step = $2
+		WHERE id = $1 AND totp_enabled_at IS NULL AND totp_secret IS NOT NULL`
+
+	result, err := tx.ExecContext(ctx, query, userID, step, now())
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	if err := requireRow(result, repository.ErrTOTPNotEnrolled); err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TOTPRepository) Disable(ctx context.Context, userID uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL
+		WHERE id = $1 AND totp_enabled_at IS NOT NULL`
+
+	result, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	if err := requireRow(result, repository.ErrTOTPNotEnabled); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TOTPRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TOTPRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	// a code is only good once, even inside its validity window
+	query := `
+		UPDATE users
+		SET totp_last_step = $2
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	return affected(result)
+}
+
+func (r *TOTPRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE totp_recovery_codes
+		SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, codeHash, now())
+	if err != nil {
+		return false, err
+	}
+
+	return affected(result)
+}
@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+const maxTxAttempts = 5
+
+type txKey struct{}
+
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Tx is a transaction a repository method runs in. When the method joins a
+// unit of work started by TxManager, Commit and Rollback are left to the
+// manager and do nothing here.
+type Tx struct {
+	*sql.Tx
+	owned bool
+}
+
+func (t *Tx) Commit() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *Tx) Rollback() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+func beginTx(ctx context.Context, db *sql.DB) (*Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return &Tx{Tx: tx}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx, owned: true}, nil
+}
+
+// conn returns the transaction of the surrounding unit of work, or db when
+// there is none.
+func conn(ctx context.Context, db *sql.DB) queryer {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+type TxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithinTx runs fn as one unit of work, see repository.TxManager. Writers are
+// serialized by the database lock, so only a lock that stayed busy past the
+// busy timeout runs fn again.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, fn)
+		if err == nil || !isBusy(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		backoff := time.Duration(attempt)*10*time.Millisecond + rand.N(10*time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteError is implemented by the driver's errors, matching on it keeps the
+// driver out of builds that don't need it.
+type sqliteError interface {
+	error
+	Code() int
+}
+
+const (
+	codeBusy             = 5
+	codeLocked           = 6
+	codeConstraintUnique = 2067
+)
+
+func isBusy(err error) bool {
+	var sqlErr sqliteError
+	if !errors.As(err, &sqlErr) {
+		return false
+	}
+
+	switch sqlErr.Code() & 0xff {
+	case codeBusy, codeLocked:
+		return true
+	}
+
+	return false
+}
+
+func isUniqueViolation(err error, column string) bool {
+	var sqlErr sqliteError
+	return errors.As(err, &sqlErr) && sqlErr.Code() == codeConstraintUnique && strings.Contains(sqlErr.Error(), column)
+}
+
+// now is the current time in UTC. Timestamps are stored as text, so they only
+// compare correctly when written in the same zone.
+func now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,288 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const userColumns = `id, login, name, last_name, phone_number, hashed_password, email, wallet_usdt, number_purchases, role, email_verified_at`
+
+type UserStorage struct {
+	db *sql.DB
+}
+
+func NewUserStorage(db *sql.DB) (*UserStorage, error) {
+	return &UserStorage{db: db}, nil
+}
+
+func scanUser(row interface{ Scan(dest ...any) error }) (*repository.User, error) {
+	user := &repository.User{}
+	err := row.Scan(
+		&user.ID,
+		&user.Login,
+		&user.Name,
+		&user.LastName,
+		&user.PhoneNumber,
+		&user.Password,
+		&user.Email,
+		&user.WalletUSDT,
+		&user.NumberPurchases,
+		&user.Role,
+		&user.EmailVerifiedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserStorage) Create(ctx context.Context, user *repository.User) error {
+	query := `
+		INSERT INTO users (id, login, name, last_name, phone_number, hashed_password, email, salt, role)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		user.ID,
+		user.Login,
+		user.Name,
+		user.LastName,
+		user.PhoneNumber,
+		user.Password,
+		user.Email,
+		user.Salt,
+		user.Role,
+	)
+	if isUniqueViolation(err, "users.email") {
+		return repository.ErrEmailTaken
+	}
+
+	return err
+}
+
+func (r *UserStorage) GetByID(ctx context.Context, id uuid.UUID) (*repository.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserStorage) GetAll(ctx context.Context) ([]*repository.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*repository.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserStorage) Update(ctx context.Context, user *repository.User) error {
+	query := `
+		UPDATE users
+		SET
+			login = $1,
+			name = $2,
+			last_name = $3,
+			phone_number = $4,
+			email = $5,
+			email_verified_at = CASE WHEN email = $5 THEN email_verified_at END
+		WHERE id = $6`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		user.Login,
+		user.Name,
+		user.LastName,
+		user.PhoneNumber,
+		user.Email,
+		user.ID,
+	)
+	if isUniqueViolation(err, "users.email") {
+		return repository.ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrUserNotFound)
+}
+
+func (r *UserStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrUserNotFound)
+}
+
+func (r *UserStorage) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`, email).Scan(&exists)
+	return exists, err
+}
+
+func (r *UserStorage) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+func (r *UserStorage) GetByEmail(ctx context.Context, email string) (*repository.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserStorage) GetUserByLogin(ctx context.Context, login string) (*repository.User, error) {
+	query := `SELECT id, login, email, hashed_password, salt, role, totp_enabled_at FROM users WHERE login = $1`
+
+	user := &repository.User{}
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, login).Scan(
+		&user.ID,
+		&user.Login,
+		&user.Email,
+		&user.Password,
+		&user.Salt,
+		&user.Role,
+		&user.TOTPEnabledAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserStorage) GetUsers(ctx context.Context, offset, limit int) ([]*repository.User, error) {
+	query := `SELECT id, login, email, role FROM users ORDER BY id LIMIT $2 OFFSET $1`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*repository.User
+	for rows.Next() {
+		user := &repository.User{}
+		if err := rows.Scan(&user.ID, &user.Login, &user.Email, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *UserStorage) SetRole(ctx context.Context, id uuid.UUID, role string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE users SET role = $2 WHERE id = $1`, id, role)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrUserNotFound)
+}
+
+func (r *UserStorage) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword, salt string) error {
+	query := `UPDATE users SET hashed_password = $2, salt = $3 WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, hashedPassword, salt)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrUserNotFound)
+}
+
+func (r *UserStorage) MarkVerificationSent(ctx context.Context, id uuid.UUID, notBefore time.Time) (bool, error) {
+	query := `
+		UPDATE users
+		SET verification_sent_at = $3
+		WHERE id = $1
+			AND email_verified_at IS NULL
+			AND (verification_sent_at IS NULL OR verification_sent_at < $2)`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, notBefore.UTC(), now())
+	if err != nil {
+		return false, err
+	}
+
+	return affected(result)
+}
+
+func (r *UserStorage) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	query := `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, $3)
+		WHERE id = $1 AND email = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, email, now())
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result, repository.ErrUserNotFound)
+}
+
+// requireRow returns notFound when the statement behind result changed no
+// rows.
+func requireRow(result sql.Result, notFound error) error {
+	ok, err := affected(result)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return notFound
+	}
+
+	return nil
+}
+
+func affected(result sql.Result) (bool, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
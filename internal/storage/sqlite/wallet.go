@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"vr-shope/internal/money"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type WalletRepository struct {
+	db *sql.DB
+}
+
+func NewWalletStorage(db *sql.DB) (*WalletRepository, error) {
+	return &WalletRepository{db: db}, nil
+}
+
+func postTransaction(ctx context.Context, tx queryer, txn *repository.LedgerTransaction) error {
+	var sum money.Amount
+	for _, entry := range txn.Entries {
+		if entry.Amount == 0 {
+			return fmt.Errorf("%w: zero amount entry", repository.ErrUnbalancedTransaction)
+		}
+		sum += entry.Amount
+	}
+	if len(txn.Entries) < 2 || sum != 0 {
+		return repository.ErrUnbalancedTransaction
+	}
+
+	if txn.ID == uuid.Nil {
+		txn.ID = uuid.New()
+	}
+	if txn.CreatedAt.IsZero() {
+		txn.CreatedAt = now()
+	}
+
+	const insertTransaction = `
+		INSERT INTO ledger_transactions (id, kind, reference_id, description, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := tx.ExecContext(
+		ctx,
+		insertTransaction,
+		txn.ID,
+		txn.Kind,
+		uuid.NullUUID{UUID: txn.ReferenceID, Valid: txn.ReferenceID != uuid.Nil},
+		txn.Description,
+		txn.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create ledger transaction: %w", err)
+	}
+
+	const insertEntry = `
+		INSERT INTO ledger_entries (transaction_id, account, user_id, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	const updateWallet = `UPDATE users SET wallet_usdt = wallet_usdt + $2 WHERE id = $1`
+
+	for _, entry := range txn.Entries {
+		entry.TransactionID = txn.ID
+		entry.CreatedAt = txn.CreatedAt
+
+		err := tx.QueryRowContext(
+			ctx,
+			insertEntry,
+			entry.TransactionID,
+			entry.Account,
+			uuid.NullUUID{UUID: entry.UserID, Valid: entry.UserID != uuid.Nil},
+			entry.Amount,
+			entry.CreatedAt.UTC(),
+		).Scan(&entry.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create ledger entry: %w", err)
+		}
+
+		if entry.UserID == uuid.Nil {
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx, updateWallet, entry.UserID, entry.Amount)
+		if err != nil {
+			return fmt.Errorf("failed to update wallet: %w", err)
+		}
+
+		if err := requireRow(result, repository.ErrUserNotFound); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func creditRefund(ctx context.Context, tx queryer, userID, referenceID uuid.UUID, amount money.Amount, description string) error {
+	txn := &repository.LedgerTransaction{
+		Kind:        repository.LedgerRefund,
+		ReferenceID: referenceID,
+		Description: description,
+		Entries: []*repository.LedgerEntry{
+			{Account: repository.UserAccount(userID), UserID: userID, Amount: amount},
+			{Account: repository.AccountSales, Amount: -amount},
+		},
+	}
+	if err := postTransaction(ctx, tx, txn); err != nil {
+		return fmt.Errorf("failed to credit refund: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WalletRepository) Post(ctx context.Context, txn *repository.LedgerTransaction) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	for _, entry := range txn.Entries {
+		if entry.UserID == uuid.Nil {
+			continue
+		}
+
+		var wallet money.Amount
+		err := tx.QueryRowContext(ctx, `SELECT wallet_usdt FROM users WHERE id = $1`, entry.UserID).Scan(&wallet)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrUserNotFound
+		} else if err != nil {
+			return fmt.Errorf("failed to read wallet: %w", err)
+		}
+
+		if wallet+entry.Amount < 0 {
+			return repository.ErrInsufficientFunds
+		}
+	}
+
+	if err := postTransaction(ctx, tx, txn); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WalletRepository) GetEntries(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.WalletEntry, error) {
+	const query = `
+		SELECT id, transaction_id, account, user_id, amount, created_at, kind, reference_id, description, balance
+		FROM (
+			SELECT
+				e.id,
+				e.transaction_id,
+				e.account,
+				e.user_id,
+				e.amount,
+				e.created_at,
+				t.kind,
+				t.reference_id,
+				t.description,
+				SUM(e.amount) OVER (ORDER BY e.id) AS balance
+			FROM ledger_entries e
+			JOIN ledger_transactions t ON t.id = e.transaction_id
+			WHERE e.account = $1
+		) history
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, repository.UserAccount(userID), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*repository.WalletEntry
+	for rows.Next() {
+		var entry repository.WalletEntry
+		var entryUserID, referenceID uuid.NullUUID
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.Account,
+			&entryUserID,
+			&entry.Amount,
+			&entry.CreatedAt,
+			&entry.Kind,
+			&referenceID,
+			&entry.Description,
+			&entry.Balance,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.UserID = entryUserID.UUID
+		entry.ReferenceID = referenceID.UUID
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *WalletRepository) GetBalance(ctx context.Context, userID uuid.UUID) (money.Amount, money.Amount, error) {
+	const query = `
+		SELECT
+			u.wallet_usdt,
+			COALESCE((SELECT SUM(amount) FROM ledger_entries WHERE account = $2), 0)
+		FROM users u
+		WHERE u.id = $1`
+
+	var cached, ledger money.Amount
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID, repository.UserAccount(userID)).Scan(&cached, &ledger)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, repository.ErrUserNotFound
+	} else if err != nil {
+		return 0, 0, err
+	}
+
+	return cached, ledger, nil
+}
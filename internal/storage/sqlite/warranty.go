@@ -0,0 +1,265 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"vr-shope/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type WarrantyRepository struct {
+	db *sql.DB
+}
+
+func NewWarrantyStorage(db *sql.DB) (*WarrantyRepository, error) {
+	return &WarrantyRepository{db: db}, nil
+}
+
+func scanClaim(row interface{ Scan(dest ...any) error }) (*repository.WarrantyClaim, error) {
+	var claim repository.WarrantyClaim
+	err := row.Scan(
+		&claim.ID,
+		&claim.PurchaseID,
+		&claim.UserID,
+		&claim.Description,
+		&claim.Status,
+		&claim.CreatedAt,
+		&claim.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &claim, nil
+}
+
+func recordClaimTransition(ctx context.Context, q queryer, transition *repository.ClaimTransition) error {
+	query := `
+		INSERT INTO warranty_claim_history (claim_id, from_status, to_status, actor_id, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	transition.CreatedAt = now()
+
+	return q.QueryRowContext(
+		ctx,
+		query,
+		transition.ClaimID,
+		sql.NullString{String: transition.FromStatus, Valid: transition.FromStatus != ""},
+		transition.ToStatus,
+		uuid.NullUUID{UUID: transition.ActorID, Valid: transition.ActorID != uuid.Nil},
+		transition.Note,
+		transition.CreatedAt,
+	).Scan(&transition.ID)
+}
+
+func (r *WarrantyRepository) Create(ctx context.Context, claim *repository.WarrantyClaim) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	purchase, err := getPurchase(ctx, tx, claim.PurchaseID)
+	if err != nil {
+		return err
+	}
+	if purchase.UserID != claim.UserID {
+		return repository.ErrPurchaseNotFound
+	}
+	if !purchase.WarrantyUntil.Valid || purchase.ReturnedQuantity >= purchase.Quantity {
+		return repository.ErrNotCovered
+	}
+	if purchase.WarrantyUntil.Time.Before(time.Now()) {
+		return repository.ErrWarrantyExpired
+	}
+
+	const openQuery = `
+		SELECT EXISTS(
+			SELECT 1
+			FROM warranty_claims
+			WHERE purchase_id = $1 AND status IN ('submitted', 'in_review', 'approved')
+		)`
+
+	var open bool
+	if err := tx.QueryRowContext(ctx, openQuery, purchase.ID).Scan(&open); err != nil {
+		return fmt.Errorf("failed to check open claims: %w", err)
+	}
+	if open {
+		return repository.ErrClaimOpen
+	}
+
+	claim.Status = "submitted"
+	claim.CreatedAt = now()
+	claim.UpdatedAt = claim.CreatedAt
+
+	query := `
+		INSERT INTO warranty_claims (id, purchase_id, user_id, description, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)`
+
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		claim.ID,
+		claim.PurchaseID,
+		claim.UserID,
+		claim.Description,
+		claim.Status,
+		claim.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create warranty claim: %w", err)
+	}
+
+	transition := &repository.ClaimTransition{ClaimID: claim.ID, ToStatus: claim.Status, ActorID: claim.UserID}
+	if err := recordClaimTransition(ctx, tx, transition); err != nil {
+		return fmt.Errorf("failed to record claim status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WarrantyRepository) Get(ctx context.Context, id uuid.UUID) (*repository.WarrantyClaim, error) {
+	query := `
+		SELECT id, purchase_id, user_id, description, status, created_at, updated_at
+		FROM warranty_claims
+		WHERE id = $1`
+
+	claim, err := scanClaim(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrClaimNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+func (r *WarrantyRepository) GetByUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]*repository.WarrantyClaim, error) {
+	query := `
+		SELECT id, purchase_id, user_id, description, status, created_at, updated_at
+		FROM warranty_claims
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $2`
+
+	return r.list(ctx, query, userID, offset, limit)
+}
+
+func (r *WarrantyRepository) GetByStatus(ctx context.Context, status string, offset, limit int) ([]*repository.WarrantyClaim, error) {
+	query := `
+		SELECT id, purchase_id, user_id, description, status, created_at, updated_at
+		FROM warranty_claims
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at
+		LIMIT $3 OFFSET $2`
+
+	return r.list(ctx, query, status, offset, limit)
+}
+
+func (r *WarrantyRepository) list(ctx context.Context, query string, args ...any) ([]*repository.WarrantyClaim, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []*repository.WarrantyClaim
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (r *WarrantyRepository) GetHistory(ctx context.Context, claimID uuid.UUID) ([]*repository.ClaimTransition, error) {
+	query := `
+		SELECT id, claim_id, from_status, to_status, actor_id, note, created_at
+		FROM warranty_claim_history
+		WHERE claim_id = $1
+		ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, claimID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*repository.ClaimTransition
+	for rows.Next() {
+		var transition repository.ClaimTransition
+		var fromStatus sql.NullString
+		var actorID uuid.NullUUID
+		err := rows.Scan(
+			&transition.ID,
+			&transition.ClaimID,
+			&fromStatus,
+			&transition.ToStatus,
+			&actorID,
+			&transition.Note,
+			&transition.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transition.FromStatus = fromStatus.String
+		transition.ActorID = actorID.UUID
+		history = append(history, &transition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (r *WarrantyRepository) Transition(ctx context.Context, transition *repository.ClaimTransition) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	const updateQuery = `
+		UPDATE warranty_claims
+		SET status = $3, updated_at = $4
+		WHERE id = $1 AND status = $2`
+
+	result, err := tx.ExecContext(ctx, updateQuery, transition.ClaimID, transition.FromStatus, transition.ToStatus, now())
+	if err != nil {
+		return fmt.Errorf("failed to update claim status: %w", err)
+	}
+
+	if err := requireRow(result, repository.ErrClaimStatusChanged); err != nil {
+		return err
+	}
+
+	if err := recordClaimTransition(ctx, tx, transition); err != nil {
+		return fmt.Errorf("failed to record claim status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package uuids
+
+import (
+	"hash/crc64"
+
+	"github.com/google/uuid"
+)
+
+var legacyTable = crc64.MakeTable(crc64.ECMA)
+
+// LegacyID returns the integer the API used to expose in place of u. It is a
+// checksum, not an encoding, so it can only be mapped back through a lookup.
+func LegacyID(u uuid.UUID) uint64 {
+	return crc64.Checksum(u[:], legacyTable)
+}